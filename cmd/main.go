@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"ludwig/internal/cli"
+	"os"
 	"ludwig/internal/updater"
 )
 
@@ -37,5 +38,9 @@ func main() {
 		return
 	}
 
+	if flag.Arg(0) == "gc" {
+		os.Exit(cli.RunGC(flag.Args()[1:]))
+	}
+
 	cli.StartInteractive(version)
 }
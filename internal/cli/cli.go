@@ -6,7 +6,7 @@ import (
 	"ludwig/internal/storage"
 )
 
-func GetTasksAndDisplayKanban(taskStore *storage.FileTaskStorage) {
+func GetTasksAndDisplayKanban(taskStore storage.TaskStorage) {
 	tasks, err := taskStore.ListTasks()
 	if err != nil {
 		fmt.Printf("Error loading tasks: %v\n", err)
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+)
+
+// RunGC runs `ludwig gc [--prune]`: it reports the branches, worktrees and response files
+// that are no longer needed, and removes them with --prune. Returns the process exit code.
+func RunGC(args []string) int {
+	flags := flag.NewFlagSet("gc", flag.ContinueOnError)
+	prune := flags.Bool("prune", false, "Remove what the report lists instead of only reporting it")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "Usage: ludwig gc [--prune]")
+		return 2
+	}
+
+	if _, err := orchestrator.RepoRoot(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		return 1
+	}
+	taskStore, err := storage.Open(cfg.TaskStorageBackend())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error initializing task storage: %v\n", err)
+		return 1
+	}
+	defer taskStore.Close()
+
+	report, err := orchestrator.CollectGarbage(taskStore, cfg, *prune)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error collecting garbage: %v\n", err)
+		return 1
+	}
+	fmt.Println(report.String())
+	for _, item := range report.Items {
+		if item.Err != "" {
+			return 1
+		}
+	}
+	return 0
+}
@@ -7,17 +7,21 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 
+	"ludwig/internal/config"
 	"ludwig/internal/storage"
 	"ludwig/internal/types/model"
 )
 
 // StartInteractive runs the interactive bubbletea UI.
+// .ludwig and .worktrees are found at the repository root, so Ludwig can be started from any subdirectory.
 func StartInteractive(version string) {
-	taskStore, err := storage.NewFileTaskStorage()
+	cfg, _ := config.LoadConfig()
+	taskStore, err := storage.Open(cfg.TaskStorageBackend())
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error initializing task storage: %v\n", err)
 		os.Exit(1)
 	}
+	defer taskStore.Close()
 
 	m := model.NewModel(taskStore, version)
 
@@ -14,17 +14,30 @@ var borderColors map[task.Status]string = map[task.Status]string {
 	task.InProgress:  "33", // Yellow
 	task.NeedsReview: "35", // Magenta
 	task.Completed:   "32", // Green
+	task.Failed:      "31", // Red
 }
 
+// columnOrder is the left-to-right order of the kanban columns
+var columnOrder = []task.Status{task.Pending, task.InProgress, task.NeedsReview, task.Completed, task.Failed}
+
 func seperateTaskByStatus(tasks []task.Task) map[task.Status][]task.Task {
 	taskLists := map[task.Status][]task.Task{
 		task.Pending:     {},
 		task.InProgress:  {},
 		task.NeedsReview: {},
 		task.Completed:   {},
+		task.Failed:      {},
 	}
-	for _, task := range tasks {
-		taskLists[task.Status] = append(taskLists[task.Status], task)
+	for _, t := range tasks {
+		switch t.Status {
+		case task.AwaitingApproval:
+			// Waiting on the user like a review, so it shares the In Review column
+			taskLists[task.NeedsReview] = append(taskLists[task.NeedsReview], t)
+		case task.Archived:
+			// Discarded tasks are not shown
+		default:
+			taskLists[t.Status] = append(taskLists[t.Status], t)
+		}
 	}
 	return taskLists
 }
@@ -40,18 +53,18 @@ func printKanbanHeader() {
 func genKanbanHeader() string {
 	var header strings.Builder
 	// top bars in each color
-	header.WriteString(utils.ColoredString(" ╭" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "╮", borderColors[task.Pending]))
-	header.WriteString(utils.ColoredString(" ╭" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "╮", borderColors[task.InProgress]))
-	header.WriteString(utils.ColoredString(" ╭" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "╮", borderColors[task.NeedsReview]))
-	header.WriteString(utils.ColoredString(" ╭" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "╮ \n", borderColors[task.Completed]))
+	for _, status := range columnOrder {
+		header.WriteString(utils.ColoredString(" ╭" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "╮", borderColors[status]))
+	}
+	header.WriteString(" \n")
 
 	//header.WriteString(" " + strings.Repeat("╭" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "╮ ", 4) + "\n")
-	header.WriteString(KanbanTaskName("To Do", task.Pending) + KanbanTaskName("In Progress", task.InProgress) + KanbanTaskName("In Review", task.NeedsReview) + KanbanTaskName("Completed", task.Completed) + "\n")
+	header.WriteString(KanbanTaskName("To Do", task.Pending) + KanbanTaskName("In Progress", task.InProgress) + KanbanTaskName("In Review", task.NeedsReview) + KanbanTaskName("Completed", task.Completed) + KanbanTaskName("Failed", task.Failed) + "\n")
 
-	header.WriteString(utils.ColoredString(" ├" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "┤", borderColors[task.Pending]))
-	header.WriteString(utils.ColoredString(" ├" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "┤", borderColors[task.InProgress]))
-	header.WriteString(utils.ColoredString(" ├" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "┤", borderColors[task.NeedsReview]))
-	header.WriteString(utils.ColoredString(" ├" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "┤ \n", borderColors[task.Completed]))
+	for _, status := range columnOrder {
+		header.WriteString(utils.ColoredString(" ├" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "┤", borderColors[status]))
+	}
+	header.WriteString(" \n")
 	//
 	//header.WriteString(" " + strings.Repeat("├" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "┤ ", 4) + "\n")
 	return header.String()
@@ -64,7 +77,7 @@ func printKanbanFooter() {
 func genKanbanFooter() string {
 	builder := strings.Builder{}
 	// bottom bars in each color
-	for status := task.Pending; status <= task.Completed; status++ {
+	for _, status := range columnOrder {
 		builder.WriteString(utils.ColoredString(" ╰" + strings.Repeat("─", TASK_NAME_LENGTH - 3) + "╯", borderColors[status]))
 	}
 	return builder.String()
@@ -80,11 +93,43 @@ func BorderColorFromString(status string) string {
 		return borderColors[task.NeedsReview]
 	case "Completed":
 		return borderColors[task.Completed]
+	case "Failed":
+		return borderColors[task.Failed]
 	default:
 		return "34" // Default to blue
 	}
 }
 
+// statusesByID maps every task ID to its status, for looking up dependencies
+func statusesByID(tasks []task.Task) map[string]task.Status {
+	statuses := make(map[string]task.Status, len(tasks))
+	for _, t := range tasks {
+		statuses[t.ID] = t.Status
+	}
+	return statuses
+}
+
+// taskMarker labels pending tasks waiting on a failed dependency, "[dep failed] ", or still waiting on a dependency, "[blocked] ",
+// finished tasks waiting for approval, "[approve] ", and tasks whose priority differs from the default, e.g. "[+2] "
+func taskMarker(t task.Task, statuses map[string]task.Status) string {
+	switch {
+	case t.Status == task.Pending && len(task.FailedDependencies(t, statuses)) > 0:
+		return "[dep failed] "
+	case t.Status == task.Pending && len(task.BlockedBy(t, statuses)) > 0:
+		return "[blocked] "
+	case t.Status == task.AwaitingApproval:
+		return "[approve] "
+	case t.Status == task.Completed && !t.MergedAt.IsZero():
+		return "[merged] "
+	case t.Priority > 0:
+		return "[+" + strconv.Itoa(t.Priority) + "] "
+	case t.Priority < 0:
+		return "[" + strconv.Itoa(t.Priority) + "] "
+	default:
+		return ""
+	}
+}
+
 func KanbanTaskName(name string, status task.Status ) string {
 	return utils.LeftRightBorderedString(name, TASK_NAME_LENGTH, len(name), true, borderColors[status])
 }
@@ -93,29 +138,22 @@ func DisplayKanban(tasks []task.Task) {
 	utils.ClearScreen()
 	printKanbanHeader()
 	taskLists := seperateTaskByStatus(tasks)
+	statuses := statusesByID(tasks)
 
-	listLengths := []int{
-		len(taskLists[task.Pending]),
-		len(taskLists[task.InProgress]),
-		len(taskLists[task.NeedsReview]),
-		len(taskLists[task.Completed]),
-	}
 	maxListLength := 0
-	for _, length := range listLengths {
-		if length > maxListLength {
-			maxListLength = length
-		}
+	for _, status := range columnOrder {
+		maxListLength = max(maxListLength, len(taskLists[status]))
 	}
 
 	index := 0
 	for i := 0; i < maxListLength; i++ {
 		var line strings.Builder
-		for status := task.Pending; status <= task.Completed; status++ {
+		for _, status := range columnOrder {
 			if i >= len(taskLists[status]) {
 				line.WriteString(KanbanTaskName("", status))
 				continue;
 			}
-			displayText := strconv.Itoa(index) + " " + taskLists[status][i].Name
+			displayText := strconv.Itoa(index) + " " + taskMarker(taskLists[status][i], statuses) + taskLists[status][i].Name
 			line.WriteString(KanbanTaskName(displayText, status))
 		}
 		fmt.Print(line.String() + " \n")
@@ -129,30 +167,24 @@ func RenderKanban(tasks []task.Task) string {
 	//printKanbanHeader()
 	builder.WriteString(genKanbanHeader())
 	taskLists := seperateTaskByStatus(tasks)
+	statuses := statusesByID(tasks)
 
-	listLengths := []int{
-		len(taskLists[task.Pending]),
-		len(taskLists[task.InProgress]),
-		len(taskLists[task.NeedsReview]),
-		len(taskLists[task.Completed]),
-	}
 	maxListLength := 0
-	for _, length := range listLengths {
-		if length > maxListLength {
-			maxListLength = length
-		}
+	for _, status := range columnOrder {
+		maxListLength = max(maxListLength, len(taskLists[status]))
 	}
 
 	index := 1
 	for i := 0; i < maxListLength; i++ {
 		var line strings.Builder
-		for status := task.Pending; status <= task.Completed; status++ {
+		for _, status := range columnOrder {
 			if i >= len(taskLists[status]) {
 				line.WriteString(KanbanTaskName("", status))
 				continue;
 			}
-			task := taskLists[status][i]
-			displayText := "#" + strconv.Itoa(slices.Index(tasks, task)) + " " + task.Name
+			current := taskLists[status][i]
+			ref := slices.IndexFunc(tasks, func(t task.Task) bool { return t.ID == current.ID })
+			displayText := "#" + strconv.Itoa(ref) + " " + taskMarker(current, statuses) + current.Name
 			index++
 			line.WriteString(KanbanTaskName(displayText, status))
 		}
@@ -0,0 +1,170 @@
+package approvalView
+
+import (
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/types/task"
+	"ludwig/internal/utils"
+
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var BUBBLE_STYLE = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("62")).
+	Padding(0, 1).
+	Margin(1, 1)
+
+var titleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("35")).Bold(true)
+var mutedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+var hintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+
+const APPROVAL_CONTROLS = "\n(a approve, r reject with feedback, d discard the branch, ↑/↓ or Ctrl+S/Ctrl+W to scroll, Esc to close)"
+const FEEDBACK_CONTROLS = "\n(Enter to send the feedback to the AI, Esc to go back to the diff)"
+const DISCARD_CONTROLS = "\n(y to delete the branch and archive the task, any other key to go back)"
+
+// Decision is what the user decided about a task's changes
+type Decision int
+
+const (
+	Undecided Decision = iota
+	Approve
+	Reject
+	Discard
+)
+
+// Model shows a task's branch diff against its base so the user can approve, reject or discard it
+type Model struct {
+	Task     *task.Task
+	Decision Decision // Set once the user decides; the caller applies it
+	Feedback string   // What should change, for a rejection
+	Closed   bool     // Set if the user leaves without deciding
+
+	viewport          viewport.Model
+	feedback          textarea.Model
+	rejecting         bool
+	confirmingDiscard bool
+	hint              string
+}
+
+func NewModel(t *task.Task, diff *orchestrator.BranchDiff) *Model {
+	vp := viewport.New(utils.TermWidth()-6, utils.TermHeight()-10)
+	vp.MouseWheelEnabled = true
+	vp.MouseWheelDelta = 3
+	vp.SetContent(diffContent(diff))
+
+	feedback := textarea.New()
+	feedback.Placeholder = "What should change?"
+	feedback.SetWidth(utils.TermWidth() - 10)
+	feedback.SetHeight(3)
+	feedback.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	feedback.ShowLineNumbers = false
+	feedback.Prompt = ""
+	feedback.CharLimit = 0
+
+	return &Model{
+		Task:     t,
+		viewport: vp,
+		feedback: feedback,
+	}
+}
+
+// diffContent lays out the stat summary above the full patch
+func diffContent(diff *orchestrator.BranchDiff) string {
+	if strings.TrimSpace(diff.Patch) == "" {
+		return mutedStyle.Render("No changes against " + diff.Base + ".")
+	}
+	return mutedStyle.Render("Changes against "+diff.Base+":") + "\n" + diff.Stat + "\n" + diff.Patch
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		var cmd tea.Cmd
+		if m.rejecting {
+			m.feedback, cmd = m.feedback.Update(msg)
+		} else {
+			m.viewport, cmd = m.viewport.Update(msg)
+		}
+		return m, cmd
+	}
+
+	if m.rejecting {
+		switch keyMsg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			m.rejecting = false
+			m.feedback.Blur()
+			return m, nil
+		case tea.KeyEnter:
+			if strings.TrimSpace(m.feedback.Value()) == "" {
+				m.hint = "Describe what should change before rejecting."
+				return m, nil
+			}
+			m.Feedback = strings.TrimSpace(m.feedback.Value())
+			m.Decision = Reject
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.feedback, cmd = m.feedback.Update(msg)
+		return m, cmd
+	}
+
+	if m.confirmingDiscard {
+		m.confirmingDiscard = false
+		if keyMsg.String() == "y" {
+			m.Decision = Discard
+		}
+		return m, nil
+	}
+
+	switch keyMsg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.Closed = true
+		return m, nil
+	case tea.KeyCtrlS:
+		m.viewport.ScrollDown(m.viewport.Height / 2)
+		return m, nil
+	case tea.KeyCtrlW:
+		m.viewport.ScrollUp(m.viewport.Height / 2)
+		return m, nil
+	}
+	switch keyMsg.String() {
+	case "a":
+		m.Decision = Approve
+		return m, nil
+	case "r":
+		m.rejecting = true
+		m.hint = ""
+		return m, m.feedback.Focus()
+	case "d":
+		m.confirmingDiscard = true
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.viewport, cmd = m.viewport.Update(msg)
+	return m, cmd
+}
+
+func (m *Model) View() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Approve: "+m.Task.Name) + "\n")
+	s.WriteString(m.viewport.View())
+
+	controls := APPROVAL_CONTROLS
+	if m.rejecting {
+		s.WriteString("\n\n" + m.feedback.View())
+		controls = FEEDBACK_CONTROLS
+	} else if m.confirmingDiscard {
+		s.WriteString("\n\n" + hintStyle.Render("Discard all changes on "+m.Task.BranchName+"?"))
+		controls = DISCARD_CONTROLS
+	}
+	if m.hint != "" {
+		s.WriteString("\n" + hintStyle.Render(m.hint))
+	}
+	return BUBBLE_STYLE.Width(utils.TermWidth()-4).Render(s.String()) + controls
+}
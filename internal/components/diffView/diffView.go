@@ -0,0 +1,287 @@
+package diffView
+
+import (
+	"ludwig/internal/components/progressBar"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/types/task"
+	"ludwig/internal/utils"
+
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var BUBBLE_STYLE = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("62")).
+	Padding(0, 1).
+	Margin(1, 1)
+
+var titleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("35")).Bold(true)
+var mutedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+var fileStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("75")).Bold(true)
+var hashStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+var hunkStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("37"))
+var addedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("34"))
+var removedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("160"))
+
+const DIFF_CONTROLS = "\n(Tab/Shift+Tab next/previous file, n/p next/previous hunk, Space collapse file, c collapse all, ↑/↓ or Ctrl+S/Ctrl+W to scroll, Esc to exit view)"
+
+// diffFile is the part of a unified diff that changes one file
+type diffFile struct {
+	name      string
+	header    []string   // index, mode and ---/+++ lines
+	hunks     [][]string // each hunk starts with its @@ line
+	added     int
+	removed   int
+	collapsed bool
+}
+
+// Model shows the commits and the colorized diff of a task's branch against its base branch
+type Model struct {
+	Task   *task.Task
+	Closed bool // Set when the user leaves the view
+
+	viewport    viewport.Model
+	progressBar progressBar.Model
+	diff        *orchestrator.BranchDiff
+	files       []diffFile
+	fileStarts  []int // Line of each file's heading in the viewport content
+	hunkStarts  []int // Line of each visible hunk's @@ line in the viewport content
+}
+
+func NewModel(t *task.Task, diff *orchestrator.BranchDiff) *Model {
+	vp := viewport.New(utils.TermWidth()-6, utils.TermHeight()-9)
+	vp.MouseWheelEnabled = true
+	vp.MouseWheelDelta = 3
+
+	m := &Model{
+		Task:        t,
+		viewport:    vp,
+		progressBar: progressBar.NewModel(&vp),
+		diff:        diff,
+		files:       parseDiff(diff.Patch),
+	}
+	m.render()
+	return m
+}
+
+// parseDiff splits a unified diff into its files and their hunks
+func parseDiff(patch string) []diffFile {
+	var files []diffFile
+	for _, line := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			files = append(files, diffFile{name: diffFileName(line)})
+			continue
+		}
+		if len(files) == 0 {
+			continue
+		}
+		file := &files[len(files)-1]
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			file.hunks = append(file.hunks, []string{line})
+		case len(file.hunks) == 0:
+			file.header = append(file.header, line)
+		default:
+			hunk := &file.hunks[len(file.hunks)-1]
+			*hunk = append(*hunk, line)
+			if strings.HasPrefix(line, "+") {
+				file.added++
+			} else if strings.HasPrefix(line, "-") {
+				file.removed++
+			}
+		}
+	}
+	return files
+}
+
+// diffFileName takes the new path from a "diff --git a/<old> b/<new>" line
+func diffFileName(line string) string {
+	paths := strings.TrimPrefix(line, "diff --git ")
+	if i := strings.LastIndex(paths, " b/"); i >= 0 {
+		return paths[i+len(" b/"):]
+	}
+	return paths
+}
+
+// render lays out the commits and files in the viewport, recording where each file and hunk starts
+func (m *Model) render() {
+	var lines []string
+	m.fileStarts = m.fileStarts[:0]
+	m.hunkStarts = m.hunkStarts[:0]
+
+	if len(m.diff.Commits) == 0 {
+		lines = append(lines, mutedStyle.Render("No commits on "+m.Task.BranchName+" since "+m.diff.Base+"."))
+	} else {
+		lines = append(lines, mutedStyle.Render(fmt.Sprintf("%d commit(s) on %s since %s:", len(m.diff.Commits), m.Task.BranchName, m.diff.Base)))
+		for _, commit := range m.diff.Commits {
+			lines = append(lines, hashStyle.Render(commit.Hash)+" "+commit.Subject+mutedStyle.Render(" ("+commit.Author+")"))
+		}
+	}
+	lines = append(lines, "")
+
+	if len(m.files) == 0 {
+		lines = append(lines, mutedStyle.Render("No changes against "+m.diff.Base+"."))
+	}
+	for _, file := range m.files {
+		m.fileStarts = append(m.fileStarts, len(lines))
+		marker := "▾ "
+		if file.collapsed {
+			marker = "▸ "
+		}
+		lines = append(lines, fileStyle.Render(marker+file.name)+" "+
+			addedStyle.Render(fmt.Sprintf("+%d", file.added))+" "+removedStyle.Render(fmt.Sprintf("-%d", file.removed)))
+		if file.collapsed {
+			continue
+		}
+
+		for _, line := range file.header {
+			lines = append(lines, mutedStyle.Render(line))
+		}
+		for _, hunk := range file.hunks {
+			m.hunkStarts = append(m.hunkStarts, len(lines))
+			for _, line := range hunk {
+				lines = append(lines, colorizeLine(line))
+			}
+		}
+		lines = append(lines, "")
+	}
+
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	m.progressBar.Progress = m.viewport.ScrollPercent()
+}
+
+// colorizeLine colors one line of a hunk by its kind
+func colorizeLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return hunkStyle.Render(line)
+	case strings.HasPrefix(line, "+"):
+		return addedStyle.Render(line)
+	case strings.HasPrefix(line, "-"):
+		return removedStyle.Render(line)
+	case strings.HasPrefix(line, "\\"):
+		return mutedStyle.Render(line)
+	}
+	return line
+}
+
+// currentFile returns the index of the file shown at the top of the viewport, or -1 if there are no files
+func (m *Model) currentFile() int {
+	current := -1
+	for i, start := range m.fileStarts {
+		if start > m.viewport.YOffset {
+			break
+		}
+		current = i
+	}
+	if current < 0 && len(m.files) > 0 {
+		return 0
+	}
+	return current
+}
+
+// jumpForward scrolls to the first of starts below the top of the viewport
+func (m *Model) jumpForward(starts []int) {
+	for _, start := range starts {
+		if start > m.viewport.YOffset {
+			m.viewport.SetYOffset(start)
+			return
+		}
+	}
+}
+
+// jumpBack scrolls to the last of starts above the top of the viewport
+func (m *Model) jumpBack(starts []int) {
+	for i := len(starts) - 1; i >= 0; i-- {
+		if starts[i] < m.viewport.YOffset {
+			m.viewport.SetYOffset(starts[i])
+			return
+		}
+	}
+}
+
+// toggleFile collapses or expands a file, keeping its heading at the top of the viewport
+func (m *Model) toggleFile(i int) {
+	if i < 0 {
+		return
+	}
+	m.files[i].collapsed = !m.files[i].collapsed
+	m.render()
+	m.viewport.SetYOffset(m.fileStarts[i])
+}
+
+// toggleAll collapses every file, or expands them all if they are already collapsed
+func (m *Model) toggleAll() {
+	collapse := false
+	for _, file := range m.files {
+		if !file.collapsed {
+			collapse = true
+			break
+		}
+	}
+	for i := range m.files {
+		m.files[i].collapsed = collapse
+	}
+	m.render()
+	m.viewport.GotoTop()
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	var cmd tea.Cmd
+	m.progressBar.Update(msg)
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.viewport.Width = msg.Width - 6
+		m.viewport.Height = msg.Height - 9
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			m.Closed = true
+			return m, nil
+		case tea.KeyCtrlS:
+			m.viewport.ScrollDown(m.viewport.Height / 2)
+		case tea.KeyCtrlW:
+			m.viewport.ScrollUp(m.viewport.Height / 2)
+		case tea.KeyTab:
+			m.jumpForward(m.fileStarts)
+		case tea.KeyShiftTab:
+			m.jumpBack(m.fileStarts)
+		case tea.KeySpace, tea.KeyEnter:
+			m.toggleFile(m.currentFile())
+		default:
+			switch msg.String() {
+			case "n":
+				m.jumpForward(m.hunkStarts)
+			case "p":
+				m.jumpBack(m.hunkStarts)
+			case "c":
+				m.toggleAll()
+			default:
+				m.viewport, cmd = m.viewport.Update(msg)
+			}
+		}
+	default:
+		m.viewport, cmd = m.viewport.Update(msg)
+	}
+
+	m.progressBar.Progress = m.viewport.ScrollPercent()
+	return m, cmd
+}
+
+func (m *Model) View() string {
+	var s strings.Builder
+	s.WriteString(titleStyle.Render("Diff: " + m.Task.Name))
+	if current := m.currentFile(); current >= 0 {
+		s.WriteString(mutedStyle.Render(fmt.Sprintf("  file %d/%d: %s", current+1, len(m.files), m.files[current].name)))
+	}
+	s.WriteString("\n")
+	s.WriteString(m.viewport.View())
+
+	return m.progressBar.View() + BUBBLE_STYLE.Width(utils.TermWidth()-4).Render(s.String()) + DIFF_CONTROLS
+}
@@ -0,0 +1,362 @@
+package reviewForm
+
+import (
+	"ludwig/internal/types/task"
+	"ludwig/internal/utils"
+
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var BORDER_STYLE = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("62")).
+	Padding(0, 1).
+	Margin(1, 1)
+
+var titleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("35")).Bold(true)
+var questionStyle = lipgloss.NewStyle().Bold(true)
+var mutedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+var selectedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("62")).Bold(true)
+var hintStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
+
+const FORM_CONTROLS = "\n(↑/↓ choose an answer, Space to toggle a multi choice, Tab for the next question or notes, Enter to submit, Esc to cancel)"
+
+// workInProgressLines is how many lines from the end of the work-in-progress are shown
+const workInProgressLines = 12
+
+// otherLabel is shown as the last choice of a choice question, for an answer written by the user
+const otherLabel = "Other: "
+
+// questionState holds the answer being given to one question
+type questionState struct {
+	question task.ReviewQuestion
+	cursor   int             // Highlighted choice; len(question.Options) is the "other" choice
+	selected map[string]bool // Chosen options of a multi choice question
+	input    textinput.Model // Free-text answer, or the user's own answer to a choice question
+}
+
+// Model is a form for answering a task's review request.
+// Every question is shown with its options as a selectable list, or a text input for free-text questions,
+// followed by a notes input for anything else the AI should know.
+type Model struct {
+	Task      *task.Task
+	Submitted bool // Set once the user submits a valid answer
+	Cancelled bool // Set if the user leaves the form without answering
+
+	AutoAnswerAt time.Time // When Ludwig answers with the suggested answers if nobody does (zero: never)
+
+	questions []questionState
+	focus     int // Index of the focused question; len(questions) is the notes input
+	notes     textarea.Model
+	hint      string
+}
+
+func NewModel(t *task.Task) *Model {
+	notes := textarea.New()
+	notes.Placeholder = "Notes for the AI (optional)"
+	notes.SetWidth(utils.TermWidth() - 10)
+	notes.SetHeight(3)
+	notes.FocusedStyle.CursorLine = lipgloss.NewStyle()
+	notes.ShowLineNumbers = false
+	notes.Prompt = ""
+	notes.CharLimit = 0
+
+	m := &Model{
+		Task:  t,
+		notes: notes,
+	}
+	for _, question := range t.Review.AllQuestions() {
+		m.questions = append(m.questions, newQuestionState(question))
+	}
+	m.setFocus(0)
+	return m
+}
+
+// newQuestionState starts a question with its default answer filled in
+func newQuestionState(question task.ReviewQuestion) questionState {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Placeholder = "Your answer"
+	input.Width = utils.TermWidth() - 20
+
+	state := questionState{question: question, selected: map[string]bool{}, input: input}
+	switch question.Kind {
+	case task.FreeText:
+		state.input.SetValue(question.Default)
+	case task.MultiChoice:
+		for _, id := range strings.Split(question.Default, ",") {
+			if id != "" {
+				state.selected[id] = true
+			}
+		}
+	default:
+		if i := slices.IndexFunc(question.Options, func(o task.ReviewOption) bool { return o.ID == question.Default }); i != -1 {
+			state.cursor = i
+		}
+	}
+	return state
+}
+
+// choiceCount is the number of selectable answers: the options plus "other", which yes/no questions do not have
+func (q *questionState) choiceCount() int {
+	if q.question.Kind == task.YesNo {
+		return len(q.question.Options)
+	}
+	return len(q.question.Options) + 1
+}
+
+// onOther reports whether the "other" choice is highlighted
+func (q *questionState) onOther() bool {
+	return q.question.Kind != task.YesNo && q.cursor == len(q.question.Options)
+}
+
+// answer returns the answer currently given to the question
+func (q *questionState) answer() task.ReviewAnswer {
+	answer := task.ReviewAnswer{QuestionID: q.question.ID}
+	text := strings.TrimSpace(q.input.Value())
+	switch q.question.Kind {
+	case task.FreeText:
+		answer.Text = text
+	case task.MultiChoice:
+		for _, option := range q.question.Options {
+			if q.selected[option.ID] {
+				answer.OptionIDs = append(answer.OptionIDs, option.ID)
+			}
+		}
+		if text != "" {
+			answer.OptionIDs = append(answer.OptionIDs, task.OtherOptionID)
+			answer.Text = text
+		}
+	default:
+		if q.onOther() {
+			answer.OptionIDs = []string{task.OtherOptionID}
+			answer.Text = text
+		} else if q.cursor < len(q.question.Options) {
+			answer.OptionIDs = []string{q.question.Options[q.cursor].ID}
+		}
+	}
+	return answer
+}
+
+// Response returns the answers currently given in the form
+func (m *Model) Response() task.ReviewResponse {
+	response := task.ReviewResponse{UserNotes: strings.TrimSpace(m.notes.Value())}
+	for i := range m.questions {
+		response.Answers = append(response.Answers, m.questions[i].answer())
+	}
+
+	// The first question's choice is also kept in the single-answer fields
+	if first := m.questions[0]; first.question.Kind == task.SingleChoice && len(response.Answers[0].OptionIDs) == 1 {
+		response.ChosenOptionID = response.Answers[0].OptionIDs[0]
+		response.ChosenLabel = first.question.AnswerText(response.Answers[0])
+	}
+	return response
+}
+
+// validate returns the index of the first question without a usable answer and a hint, or -1
+func (m *Model) validate() (int, string) {
+	for i := range m.questions {
+		q := &m.questions[i]
+		answer := q.answer()
+		switch {
+		case q.question.Kind == task.FreeText && answer.Text == "":
+			return i, fmt.Sprintf("Answer question %d before submitting.", i+1)
+		case q.question.Kind != task.MultiChoice && q.onOther() && answer.Text == "":
+			return i, fmt.Sprintf("Write your own answer to question %d, or choose one of its options.", i+1)
+		}
+	}
+	return -1, ""
+}
+
+func (m *Model) Update(msg tea.Msg) (*Model, tea.Cmd) {
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, m.updateInputs(msg)
+	}
+
+	fields := len(m.questions) + 1
+	switch keyMsg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC:
+		m.Cancelled = true
+		return m, nil
+	case tea.KeyTab:
+		return m, m.setFocus((m.focus + 1) % fields)
+	case tea.KeyShiftTab:
+		return m, m.setFocus((m.focus + fields - 1) % fields)
+	case tea.KeyEnter:
+		if i, hint := m.validate(); i != -1 {
+			m.hint = hint
+			return m, m.setFocus(i)
+		}
+		m.Submitted = true
+		return m, nil
+	}
+
+	if m.focus == len(m.questions) {
+		var cmd tea.Cmd
+		m.notes, cmd = m.notes.Update(msg)
+		return m, cmd
+	}
+
+	q := &m.questions[m.focus]
+	if q.question.Kind == task.FreeText {
+		var cmd tea.Cmd
+		q.input, cmd = q.input.Update(msg)
+		return m, cmd
+	}
+
+	switch keyMsg.Type {
+	case tea.KeyUp:
+		q.cursor = (q.cursor + q.choiceCount() - 1) % q.choiceCount()
+		return m, nil
+	case tea.KeyDown:
+		q.cursor = (q.cursor + 1) % q.choiceCount()
+		return m, nil
+	}
+	// While "other" is highlighted, typing goes into the user's own answer
+	if q.onOther() {
+		var cmd tea.Cmd
+		q.input, cmd = q.input.Update(msg)
+		return m, cmd
+	}
+	switch keyMsg.String() {
+	case "k":
+		q.cursor = (q.cursor + q.choiceCount() - 1) % q.choiceCount()
+	case "j":
+		q.cursor = (q.cursor + 1) % q.choiceCount()
+	case " ":
+		if q.question.Kind == task.MultiChoice {
+			id := q.question.Options[q.cursor].ID
+			q.selected[id] = !q.selected[id]
+		}
+	}
+	return m, nil
+}
+
+// updateInputs passes a non-key message (e.g. a cursor blink) to the focused input
+func (m *Model) updateInputs(msg tea.Msg) tea.Cmd {
+	var cmd tea.Cmd
+	if m.focus == len(m.questions) {
+		m.notes, cmd = m.notes.Update(msg)
+	} else {
+		m.questions[m.focus].input, cmd = m.questions[m.focus].input.Update(msg)
+	}
+	return cmd
+}
+
+// setFocus moves the focus to a question, or to the notes input
+func (m *Model) setFocus(focus int) tea.Cmd {
+	m.focus = focus
+	m.notes.Blur()
+	for i := range m.questions {
+		m.questions[i].input.Blur()
+	}
+	if focus == len(m.questions) {
+		return m.notes.Focus()
+	}
+	return m.questions[focus].input.Focus()
+}
+
+func (m *Model) View() string {
+	review := m.Task.Review
+	var s strings.Builder
+
+	s.WriteString(titleStyle.Render("Review: "+m.Task.Name) + "\n")
+	if review.Context != "" {
+		s.WriteString("\n" + review.Context + "\n")
+	}
+	if m.Task.WorkInProgress != "" {
+		s.WriteString("\n" + mutedStyle.Render("Work in progress:\n"+lastLines(m.Task.WorkInProgress, workInProgressLines)) + "\n")
+	}
+	if review.ParseError != "" && review.Raw != "" {
+		s.WriteString("\n" + mutedStyle.Render("Review block written by the AI:\n"+lastLines(strings.TrimSpace(review.Raw), workInProgressLines)) + "\n")
+	}
+
+	for i := range m.questions {
+		s.WriteString("\n" + m.questionView(i))
+	}
+
+	s.WriteString("\n" + m.notes.View())
+	if !m.AutoAnswerAt.IsZero() {
+		s.WriteString("\n" + mutedStyle.Render("If nobody answers, Ludwig uses the suggested answers at "+m.AutoAnswerAt.Format("15:04 on Jan 2")+"."))
+	}
+	if m.hint != "" {
+		s.WriteString("\n" + hintStyle.Render(m.hint))
+	}
+
+	return BORDER_STYLE.Width(utils.TermWidth()-4).Render(s.String()) + FORM_CONTROLS
+}
+
+// questionView renders one question with its choices or text input
+func (m *Model) questionView(i int) string {
+	q := &m.questions[i]
+	focused := i == m.focus
+	var s strings.Builder
+
+	title := q.question.Text
+	if len(m.questions) > 1 {
+		title = fmt.Sprintf("%d. %s", i+1, title)
+	}
+	if q.question.Kind == task.MultiChoice {
+		title += mutedStyle.Render(" (choose any)")
+	}
+	s.WriteString(questionStyle.Render(title) + "\n")
+
+	if q.question.Kind == task.FreeText {
+		s.WriteString("  " + q.input.View() + "\n")
+		return s.String()
+	}
+
+	defaults := strings.Split(q.question.Default, ",")
+	for j := 0; j < q.choiceCount(); j++ {
+		label := otherLabel + q.input.View()
+		checked := q.cursor == j
+		if j < len(q.question.Options) {
+			option := q.question.Options[j]
+			label = option.Label
+			if slices.Contains(defaults, option.ID) {
+				label += mutedStyle.Render(" (suggested)")
+			}
+			if q.question.Kind == task.MultiChoice {
+				checked = q.selected[option.ID]
+			}
+		} else if q.question.Kind == task.MultiChoice {
+			checked = strings.TrimSpace(q.input.Value()) != ""
+		}
+
+		mark := "○ "
+		if q.question.Kind == task.MultiChoice {
+			mark = "[ ] "
+			if checked {
+				mark = "[x] "
+			}
+		} else if checked {
+			mark = "● "
+		}
+
+		line := mark + label
+		if focused && q.cursor == j {
+			s.WriteString(selectedStyle.Render("> "+line) + "\n")
+		} else {
+			s.WriteString("  " + line + "\n")
+		}
+	}
+	return s.String()
+}
+
+// lastLines returns the last n lines of s
+func lastLines(s string, n int) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) <= n {
+		return strings.Join(lines, "\n")
+	}
+	return "...\n" + strings.Join(lines[len(lines)-n:], "\n")
+}
@@ -6,28 +6,378 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
+
+	"ludwig/internal/utils"
 )
 
 // Config represents the user's configuration
 type Config struct {
 	DelayMs    int    `json:"delayMs"`    // Minimum delay in milliseconds between requests
-	AIProvider string `json:"aiProvider"` // "gemini" (default), "ollama", or "copilot"
+	AIProvider string `json:"aiProvider"` // "gemini" (default), "ollama", "openai", "copilot", "claude", "codex", "aider", or "command"
 	// Ollama-specific settings
-	OllamaBaseURL string `json:"ollamaBaseURL"` // Base URL for Ollama (default: http://localhost:11434)
-	OllamaModel   string `json:"ollamaModel"`   // Model name for Ollama (default: mistral)
+	OllamaBaseURL  string `json:"ollamaBaseURL"`  // Base URL for Ollama (default: http://localhost:11434)
+	OllamaModel    string `json:"ollamaModel"`    // Model name for Ollama (default: mistral)
+	OllamaMaxSteps int    `json:"ollamaMaxSteps"` // Maximum tool-calling steps in one Ollama run (default: 30)
+	// Settings for OpenAI-compatible servers (llama.cpp, vLLM, LM Studio)
+	OpenAIBaseURL   string `json:"openaiBaseURL"`   // Base URL of the API, up to /v1 (default: http://localhost:8080/v1)
+	OpenAIModel     string `json:"openaiModel"`     // Model name to request
+	OpenAIAPIKeyEnv string `json:"openaiAPIKeyEnv"` // Environment variable holding the API key (default: OPENAI_API_KEY)
+	OpenAIMaxSteps  int    `json:"openaiMaxSteps"`  // Maximum tool-calling steps in one run (default: 30)
 	// Copilot-specific settings
 	CopilotModel string `json:"copilotModel"` // Model name for Copilot (default: gpt-5)
+	// Coding-agent CLI settings
+	Claude AgentCLIConfig `json:"claude"`
+	Codex  AgentCLIConfig `json:"codex"`
+	Aider  AgentCLIConfig `json:"aider"`
+	// Any other agent CLI, described by a command template
+	Command CommandProviderConfig `json:"command"`
+	// Task execution settings
+	TaskTimeoutMinutes  int            `json:"taskTimeoutMinutes"`  // Maximum minutes a single AI run may take (0 = no limit)
+	MaxParallelTasks    int            `json:"maxParallelTasks"`    // Maximum tasks running at once across all providers (default: 3)
+	ProviderConcurrency map[string]int `json:"providerConcurrency"` // Maximum tasks running at once per provider (default: ollama 1, others unlimited)
+	// Branch tasks start from and are merged into (default: main, or the current branch if there is no main)
+	BaseBranch string `json:"baseBranch"`
+	// Recovery settings
+	PruneOrphanedWorktrees bool `json:"pruneOrphanedWorktrees"` // Remove .worktrees directories with no matching task on start
+	// Retry settings for failed AI runs
+	Retry RetryPolicy `json:"retry"`
+	// Commands that check a task's work before it is marked Completed
+	Verify VerifyConfig `json:"verify"`
+	// Hold finished tasks in Awaiting Approval until the user approves their diff
+	RequireApproval bool `json:"requireApproval"`
+	// What happens to reviews nobody answers
+	Review ReviewPolicy `json:"review"`
+	// How finished task branches are merged into their base branch
+	Merge MergeConfig `json:"merge"`
+	// Keeping task branches up to date when their base branch moves on
+	Rebase RebasePolicy `json:"rebase"`
+	// Commits Ludwig makes on task branches
+	Commit CommitConfig `json:"commit"`
+	// What the gc command removes
+	GC GCPolicy `json:"gc"`
+	// Storage settings
+	StorageBackend string `json:"storageBackend"` // "json" (default) or "sqlite"
+}
+
+// TaskStorageBackend returns the configured task storage backend, or "" for the default
+func (c *Config) TaskStorageBackend() string {
+	if c == nil {
+		return ""
+	}
+	return c.StorageBackend
+}
+
+// VerifyConfig lists the commands run in a task's worktree after the AI finishes.
+// Each is a shell command line; empty commands are skipped.
+type VerifyConfig struct {
+	Build     string `json:"build"`     // e.g. "go build ./..."
+	Test      string `json:"test"`      // e.g. "go test ./..."
+	Lint      string `json:"lint"`      // e.g. "go vet ./..."
+	MaxRounds int    `json:"maxRounds"` // Follow-up prompts sent to fix a failure before the task is marked Failed (default: 2)
+}
+
+// VerifyStep is a single named verification command
+type VerifyStep struct {
+	Name    string
+	Command string
+}
+
+// DefaultVerifyRounds is the number of fix-up prompts sent when verification fails and maxRounds is unset
+const DefaultVerifyRounds = 2
+
+// VerifySteps returns the configured verification commands in the order they run: build, test, lint
+func (c *Config) VerifySteps() []VerifyStep {
+	if c == nil {
+		return nil
+	}
+	var steps []VerifyStep
+	for _, step := range []VerifyStep{
+		{Name: "build", Command: c.Verify.Build},
+		{Name: "test", Command: c.Verify.Test},
+		{Name: "lint", Command: c.Verify.Lint},
+	} {
+		if strings.TrimSpace(step.Command) != "" {
+			steps = append(steps, step)
+		}
+	}
+	return steps
+}
+
+// VerifyRounds returns how many follow-up prompts may be sent to fix failing verification
+func (c *Config) VerifyRounds() int {
+	if c == nil || c.Verify.MaxRounds <= 0 {
+		return DefaultVerifyRounds
+	}
+	return c.Verify.MaxRounds
+}
+
+// RetryPolicy controls how a task is retried after its AI run fails
+type RetryPolicy struct {
+	MaxAttempts       int      `json:"maxAttempts"`       // Consecutive failed attempts before the task is marked Failed (default: 3)
+	BackoffSeconds    int      `json:"backoffSeconds"`    // Delay before the first retry, doubled for each further failure (default: 30)
+	MaxBackoffSeconds int      `json:"maxBackoffSeconds"` // Upper bound on the retry delay (default: 600)
+	RetryableErrors   []string `json:"retryableErrors"`   // Case-insensitive substrings of retryable error messages (empty: every error is retryable)
+}
+
+// Default retry policy values used when the config leaves them unset
+const (
+	DefaultMaxAttempts       = 3
+	DefaultBackoffSeconds    = 30
+	DefaultMaxBackoffSeconds = 600
+)
+
+// RetryPolicy returns the configured retry policy with defaults filled in for unset values
+func (c *Config) RetryPolicy() RetryPolicy {
+	var policy RetryPolicy
+	if c != nil {
+		policy = c.Retry
+	}
+	if policy.MaxAttempts <= 0 {
+		policy.MaxAttempts = DefaultMaxAttempts
+	}
+	if policy.BackoffSeconds <= 0 {
+		policy.BackoffSeconds = DefaultBackoffSeconds
+	}
+	if policy.MaxBackoffSeconds <= 0 {
+		policy.MaxBackoffSeconds = DefaultMaxBackoffSeconds
+	}
+	return policy
+}
+
+// Backoff returns how long to wait before retrying after the given number of consecutive failures
+func (p RetryPolicy) Backoff(failures int) time.Duration {
+	delay := time.Duration(p.BackoffSeconds) * time.Second
+	maxDelay := time.Duration(p.MaxBackoffSeconds) * time.Second
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+// IsRetryable reports whether a failed run with this error should be retried
+func (p RetryPolicy) IsRetryable(err error) bool {
+	if len(p.RetryableErrors) == 0 {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, pattern := range p.RetryableErrors {
+		if strings.Contains(msg, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Default concurrency limits used when the config leaves them unset
+const (
+	DefaultAIProvider       = "gemini"
+	DefaultMaxParallelTasks = 3
+)
+
+// DefaultProviderConcurrency limits providers that rarely cope with parallel requests.
+// A local Ollama server usually runs one model at a time.
+var DefaultProviderConcurrency = map[string]int{"ollama": 1}
+
+// Provider returns the configured default AI provider
+func (c *Config) Provider() string {
+	if c == nil || c.AIProvider == "" {
+		return DefaultAIProvider
+	}
+	return c.AIProvider
+}
+
+// AgentCLIConfig configures a coding-agent CLI provider (claude, codex or aider)
+type AgentCLIConfig struct {
+	Binary string   `json:"binary"` // Path or name of the CLI (default: the provider name, looked up in PATH)
+	Model  string   `json:"model"`  // Model passed with --model (default: the CLI's own default)
+	Args   []string `json:"args"`   // Extra arguments appended to the command line
+}
+
+// AgentCLI returns the settings of a coding-agent CLI provider
+func (c *Config) AgentCLI(provider string) AgentCLIConfig {
+	if c == nil {
+		return AgentCLIConfig{}
+	}
+	switch provider {
+	case "claude":
+		return c.Claude
+	case "codex":
+		return c.Codex
+	case "aider":
+		return c.Aider
+	}
+	return AgentCLIConfig{}
+}
+
+// CommandProviderConfig describes how the "command" provider runs an agent CLI
+type CommandProviderConfig struct {
+	Binary             string   `json:"binary"`             // CLI to run
+	Args               []string `json:"args"`               // Argument template with {prompt}, {model} and {workdir} placeholders
+	Model              string   `json:"model"`              // Value of {model}
+	PromptVia          string   `json:"promptVia"`          // "arg" (default) or "stdin"
+	Output             string   `json:"output"`             // "plain" (default) or "ndjson"
+	TextField          string   `json:"textField"`          // NDJSON field holding assistant text, e.g. "message.content" (default: "text")
+	RateLimitExitCodes []int    `json:"rateLimitExitCodes"` // Exit codes that mean the CLI was rate limited
+}
+
+// DefaultOpenAIAPIKeyEnv is the environment variable the OpenAI-compatible client reads its API key from
+const DefaultOpenAIAPIKeyEnv = "OPENAI_API_KEY"
+
+// OpenAIAPIKey returns the API key for the OpenAI-compatible server from the configured environment variable.
+// Local servers usually need none, so an unset variable gives "".
+func (c *Config) OpenAIAPIKey() string {
+	name := DefaultOpenAIAPIKeyEnv
+	if c != nil && c.OpenAIAPIKeyEnv != "" {
+		name = c.OpenAIAPIKeyEnv
+	}
+	return os.Getenv(name)
+}
+
+// ParallelTasks returns the maximum number of tasks the orchestrator runs at once
+func (c *Config) ParallelTasks() int {
+	if c == nil || c.MaxParallelTasks <= 0 {
+		return DefaultMaxParallelTasks
+	}
+	return c.MaxParallelTasks
+}
+
+// ProviderLimit returns the maximum number of concurrent tasks for a provider,
+// or 0 if only the global limit applies
+func (c *Config) ProviderLimit(provider string) int {
+	if c != nil {
+		if limit, ok := c.ProviderConcurrency[provider]; ok {
+			return max(limit, 0)
+		}
+	}
+	return DefaultProviderConcurrency[provider]
+}
+
+// TaskTimeout returns the configured per-task timeout, or 0 if tasks may run indefinitely
+func (c *Config) TaskTimeout() time.Duration {
+	if c == nil || c.TaskTimeoutMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(c.TaskTimeoutMinutes) * time.Minute
+}
+
+// ReviewPolicy controls automatic answers to reviews that wait too long
+type ReviewPolicy struct {
+	TimeoutMinutes       int  `json:"timeoutMinutes"`       // Minutes a review waits before it is answered automatically, unless the AI proposed its own timeout (0 = wait for the user)
+	FirstOptionAsDefault bool `json:"firstOptionAsDefault"` // Answer choice questions without a suggested answer with their first option
+}
+
+// ReviewTimeout returns how long a review without its own timeout waits for the user, or 0 if it waits indefinitely
+func (c *Config) ReviewTimeout() time.Duration {
+	if c == nil || c.Review.TimeoutMinutes <= 0 {
+		return 0
+	}
+	return time.Duration(c.Review.TimeoutMinutes) * time.Minute
+}
+
+// MergeConfig controls how the merge command brings a task branch into its base branch
+type MergeConfig struct {
+	Strategy string `json:"strategy"` // "ff" (default) to fast-forward the base branch, or "squash" for a single commit on top of it
+}
+
+// Merge strategies for finished task branches
+const (
+	MergeFastForward = "ff"
+	MergeSquash      = "squash"
+)
+
+// MergeStrategy returns the configured merge strategy, or fast-forward if unset
+func (c *Config) MergeStrategy() string {
+	if c == nil || c.Merge.Strategy == "" {
+		return MergeFastForward
+	}
+	return c.Merge.Strategy
+}
+
+// RebasePolicy controls how a task branch is brought up to date when its base branch has new commits
+type RebasePolicy struct {
+	Auto       bool   `json:"auto"`       // Rebase a task's worktree onto its base branch before the task resumes or is approved
+	OnConflict string `json:"onConflict"` // "review" (default) pauses the task with the conflicting files; "agent" has the AI resolve them
+}
+
+// What happens when an automatic rebase runs into conflicts
+const (
+	ConflictReview = "review"
+	ConflictAgent  = "agent"
+)
+
+// AutoRebase reports whether stale task branches are rebased automatically
+func (c *Config) AutoRebase() bool {
+	return c != nil && c.Rebase.Auto
+}
+
+// RebaseConflictPolicy returns what happens when an automatic rebase conflicts, or "review" if unset
+func (c *Config) RebaseConflictPolicy() string {
+	if c == nil || c.Rebase.OnConflict != ConflictAgent {
+		return ConflictReview
+	}
+	return ConflictAgent
+}
+
+// CommitConfig controls the commits Ludwig makes on task branches
+type CommitConfig struct {
+	Squash      bool   `json:"squash"`      // Collapse a finished task's commits into one commit
+	AuthorName  string `json:"authorName"`  // Author and committer name of Ludwig's commits (empty: the user's git identity)
+	AuthorEmail string `json:"authorEmail"` // Author and committer email of Ludwig's commits
+}
+
+// SquashCommits reports whether a finished task's commits are collapsed into one
+func (c *Config) SquashCommits() bool {
+	return c != nil && c.Commit.Squash
+}
+
+// CommitIdentity returns the name and email Ludwig commits with; empty values keep the user's git identity
+func (c *Config) CommitIdentity() (name, email string) {
+	if c == nil {
+		return "", ""
+	}
+	return c.Commit.AuthorName, c.Commit.AuthorEmail
+}
+
+// CommitEnv returns the GIT_AUTHOR_* and GIT_COMMITTER_* variables that set the commit identity, or nil if none is configured
+func (c *Config) CommitEnv() []string {
+	name, email := c.CommitIdentity()
+	var env []string
+	if name != "" {
+		env = append(env, "GIT_AUTHOR_NAME="+name, "GIT_COMMITTER_NAME="+name)
+	}
+	if email != "" {
+		env = append(env, "GIT_AUTHOR_EMAIL="+email, "GIT_COMMITTER_EMAIL="+email)
+	}
+	return env
+}
+
+// GCPolicy controls which branches, worktrees and response files the gc command removes
+type GCPolicy struct {
+	MergedBranchDays     int  `json:"mergedBranchDays"`     // Days a merged task's branch is kept after merging (0: removed by the next gc)
+	MaxBranchAgeDays     int  `json:"maxBranchAgeDays"`     // Remove branches of finished tasks without commits for this many days, merged or not (0: never)
+	KeepOrphanedBranches bool `json:"keepOrphanedBranches"` // Keep ludwig/ branches that no task refers to
+	DeleteResponses      bool `json:"deleteResponses"`      // Delete the response files of deleted tasks instead of archiving them
+}
+
+// GCPolicy returns the garbage collection rules, or the defaults if there is no config
+func (c *Config) GCPolicy() GCPolicy {
+	if c == nil {
+		return GCPolicy{}
+	}
+	return c.GC
 }
 
-// LoadConfig loads configuration from .ludwig/config.json in the current project
+// LoadConfig loads configuration from .ludwig/config.json at the repository root
 // Returns nil if file doesn't exist (which is fine - optional config)
 func LoadConfig() (*Config, error) {
-	cwd, err := os.Getwd()
+	projectDir, err := utils.ProjectDir()
 	if err != nil {
 		return nil, err
 	}
 
-	ludwigDir := filepath.Join(cwd, ".ludwig")
+	ludwigDir := filepath.Join(projectDir, ".ludwig")
 	configPath := filepath.Join(ludwigDir, "config.json")
 
 	// File doesn't exist - that's okay
@@ -48,14 +398,14 @@ func LoadConfig() (*Config, error) {
 	return &cfg, nil
 }
 
-// SaveConfig saves configuration to .ludwig/config.json in the current project
+// SaveConfig saves configuration to .ludwig/config.json at the repository root
 func SaveConfig(cfg *Config) error {
-	cwd, err := os.Getwd()
+	projectDir, err := utils.ProjectDir()
 	if err != nil {
 		return err
 	}
 
-	ludwigDir := filepath.Join(cwd, ".ludwig")
+	ludwigDir := filepath.Join(projectDir, ".ludwig")
 	if err := os.MkdirAll(ludwigDir, 0755); err != nil {
 		return fmt.Errorf("failed to create .ludwig directory: %w", err)
 	}
@@ -0,0 +1,124 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TaskDiff returns the changes on a task's branch since it left the branch it started from
+func TaskDiff(t *task.Task) (*BranchDiff, error) {
+	if t.BranchName == "" {
+		return nil, fmt.Errorf("task has no branch yet")
+	}
+	return DiffBranch(t.BranchName, taskStartRef(t))
+}
+
+// awaitingApproval loads a task and checks that it is waiting for approval
+func awaitingApproval(taskStore storage.TaskStorage, taskID string) (*task.Task, error) {
+	t, err := taskStore.GetTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if t.Status != task.AwaitingApproval {
+		return nil, fmt.Errorf("task is not awaiting approval (task is %s)", task.StatusString(*t))
+	}
+	return t, nil
+}
+
+// ApproveTask accepts the changes of a task awaiting approval.
+// The task is Completed and its worktree removed; the branch is kept (squashed into one commit in squash mode).
+// With automatic rebasing on, a stale branch is rebased first; if that conflicts the task goes back In Review instead.
+func ApproveTask(taskStore storage.TaskStorage, taskID string) error {
+	t, err := awaitingApproval(taskStore, taskID)
+	if err != nil {
+		return err
+	}
+	if t.WorktreePath != "" && WorktreeExists(t.WorktreePath) {
+		cfg, err := config.LoadConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.AutoRebase() {
+			var conflict *MergeConflictError
+			if _, err := rebaseStaleWorktree(cfg, t); errors.As(err, &conflict) {
+				if err := pauseForConflicts(taskStore, cfg, t, conflict); err != nil {
+					return err
+				}
+				return fmt.Errorf("%s has new commits that conflict with the task in %s; the task is back In Review", conflict.Onto, strings.Join(conflict.Files, ", "))
+			}
+		}
+		_ = finishTaskCommits(cfg, t)
+		if err := RemoveWorktree(t.WorktreePath); err != nil {
+			return err
+		}
+	}
+	return modifyTask(taskStore, taskID, func(stored *task.Task) {
+		stored.Status = task.Completed
+		stored.WorktreePath = ""
+	})
+}
+
+// RejectTask sends a task awaiting approval back to the AI with the user's feedback.
+// The task resumes in its existing worktree, as if the feedback answered a review.
+func RejectTask(taskStore storage.TaskStorage, taskID string, feedback string) error {
+	if _, err := awaitingApproval(taskStore, taskID); err != nil {
+		return err
+	}
+	if feedback == "" {
+		return fmt.Errorf("describe what should change")
+	}
+
+	question := task.ReviewQuestion{
+		ID:   "q1",
+		Kind: task.FreeText,
+		Text: "The user reviewed the finished changes and did not approve them. What should change?",
+	}
+	now := time.Now()
+	return modifyTask(taskStore, taskID, func(stored *task.Task) {
+		stored.Status = task.NeedsReview
+		stored.Review = &task.ReviewRequest{
+			Question:  question.Text,
+			CreatedAt: now,
+			Questions: []task.ReviewQuestion{question},
+			Manual:    true,
+		}
+		stored.ReviewResponse = &task.ReviewResponse{
+			Answers:     []task.ReviewAnswer{{QuestionID: question.ID, Text: feedback}},
+			RespondedAt: now,
+		}
+	})
+}
+
+// DiscardTask throws away the work of a finished task: its worktree and branch are deleted and it is Archived
+func DiscardTask(taskStore storage.TaskStorage, taskID string) error {
+	t, err := taskStore.GetTask(taskID)
+	if err != nil {
+		return err
+	}
+	switch t.Status {
+	case task.AwaitingApproval, task.Completed, task.Failed:
+	default:
+		return fmt.Errorf("only finished tasks can be discarded (task is %s)", task.StatusString(*t))
+	}
+
+	if t.WorktreePath != "" {
+		_ = PruneWorktree(t.WorktreePath)
+	}
+	if t.BranchName != "" {
+		if exists, _ := BranchExists(t.BranchName); exists {
+			if err := DeleteBranch(t.BranchName); err != nil {
+				return err
+			}
+		}
+	}
+	return modifyTask(taskStore, taskID, func(stored *task.Task) {
+		stored.Status = task.Archived
+		stored.WorktreePath = ""
+	})
+}
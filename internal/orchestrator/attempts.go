@@ -0,0 +1,134 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator/clients"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// errAttemptAbandoned is recorded on an attempt that was still open when the orchestrator exited
+var errAttemptAbandoned = errors.New("orchestrator exited during the attempt")
+
+// describeClient returns the provider name and model of an AI client for attempt records
+func describeClient(aiClient clients.AIClient) (string, string) {
+	switch c := aiClient.(type) {
+	case *clients.GeminiClient:
+		return "gemini", "" // The model is picked from a fallback chain at run time
+	case *clients.OllamaClient:
+		return "ollama", c.Model
+	case *clients.OpenAIClient:
+		return "openai", c.Model
+	case *clients.CopilotClient:
+		return "copilot", c.Model
+	case *clients.ClaudeClient:
+		return "claude", c.Model
+	case *clients.CodexClient:
+		return "codex", c.Model
+	case *clients.AiderClient:
+		return "aider", c.Model
+	case *clients.CommandClient:
+		return "command", c.Model
+	default:
+		return fmt.Sprintf("%T", aiClient), ""
+	}
+}
+
+// beginAttempt appends a new open attempt to the task's history
+func beginAttempt(t *task.Task, aiClient clients.AIClient) {
+	provider, model := describeClient(aiClient)
+	t.Attempts = append(t.Attempts, task.Attempt{
+		StartedAt: time.Now(),
+		Provider:  provider,
+		Model:     model,
+	})
+}
+
+// currentAttempt returns the task's most recent attempt, or nil if it has none
+func currentAttempt(t *task.Task) *task.Attempt {
+	if len(t.Attempts) == 0 {
+		return nil
+	}
+	return &t.Attempts[len(t.Attempts)-1]
+}
+
+// finishAttempt closes the task's current attempt, recording err if the run did not succeed.
+// A successful attempt resets the task's failure count.
+func finishAttempt(t *task.Task, err error) {
+	if attempt := currentAttempt(t); attempt != nil && attempt.EndedAt.IsZero() {
+		attempt.EndedAt = time.Now()
+		if err != nil {
+			attempt.Error = err.Error()
+		}
+	}
+	if err == nil {
+		t.FailureCount = 0
+		t.NextAttemptAt = time.Time{}
+	}
+}
+
+// readyForAttempt reports whether a task's retry backoff (if any) has elapsed
+func readyForAttempt(t *task.Task) bool {
+	return !time.Now().Before(t.NextAttemptAt)
+}
+
+// handleFailedAttempt applies the retry policy to a task whose AI run returned an error.
+// - Partial changes are committed and the partial output is kept as work-in-progress
+// - If the error is retryable and attempts remain, the task returns to retryStatus after a backoff
+// - A rate-limited run is always retryable, whatever retry.retryableErrors lists
+// - Otherwise the task moves to Failed and keeps its worktree for inspection or a manual retry
+func handleFailedAttempt(taskStore storage.TaskStorage, t *task.Task, cfg *config.Config, err error, partial string, retryStatus task.Status) {
+	finishAttempt(t, err)
+	if t.WorktreePath != "" {
+		_ = CommitPartialChanges(cfg, t.WorktreePath, t.ID, "attempt failed")
+	}
+	appendWorkInProgress(t, partial)
+
+	t.FailureCount++
+	policy := cfg.RetryPolicy()
+	retryable := errors.Is(err, clients.ErrRateLimited) || policy.IsRetryable(err)
+	if !retryable || t.FailureCount >= policy.MaxAttempts {
+		t.Status = task.Failed
+		t.NextAttemptAt = time.Time{}
+	} else {
+		t.Status = retryStatus
+		t.NextAttemptAt = time.Now().Add(policy.Backoff(t.FailureCount))
+	}
+	_ = saveTask(taskStore, t)
+}
+
+// appendWorkInProgress adds the output of an unfinished run to the task's work-in-progress
+func appendWorkInProgress(t *task.Task, partial string) {
+	if partial = strings.TrimSpace(partial); partial == "" {
+		return
+	}
+	if t.WorkInProgress != "" {
+		partial = t.WorkInProgress + "\n\n" + partial
+	}
+	t.WorkInProgress = partial
+}
+
+// RetryTask moves a Failed task back into the queue with a fresh retry budget.
+// A task that failed while acting on a review answer returns to In Review so the answer is replayed.
+func RetryTask(taskStore storage.TaskStorage, taskID string) error {
+	t, err := taskStore.GetTask(taskID)
+	if err != nil {
+		return err
+	}
+	if t.Status != task.Failed {
+		return fmt.Errorf("only failed tasks can be retried (task is %s)", task.StatusString(*t))
+	}
+
+	t.Status = task.Pending
+	if t.Review != nil && t.ReviewResponse != nil {
+		t.Status = task.NeedsReview
+	}
+	t.FailureCount = 0
+	t.NextAttemptAt = time.Time{}
+	return taskStore.UpdateTask(t)
+}
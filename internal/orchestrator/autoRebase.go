@@ -0,0 +1,129 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// Options of the review asking how to bring a branch with conflicts up to date
+const (
+	ResolveConflictsOptionID = "resolve"
+	SkipUpdateOptionID       = "skip"
+)
+
+// staleBaseBranch returns the base branch of a task and whether it has commits the task branch does not
+func staleBaseBranch(t *task.Task) (string, bool) {
+	target, err := mergeTarget(t)
+	if err != nil {
+		return "", false
+	}
+	return target, !isAncestor(getRepoRoot(), target, t.BranchName)
+}
+
+// rebaseStaleWorktree rebases a task's worktree onto its base branch if the base branch has moved on.
+// Work not yet committed is committed first. Returns the base branch and a *MergeConflictError if the rebase conflicted.
+func rebaseStaleWorktree(cfg *config.Config, t *task.Task) (string, error) {
+	target, stale := staleBaseBranch(t)
+	if !stale {
+		return target, nil
+	}
+	if err := commitAll(cfg, t.WorktreePath, fmt.Sprintf("Task %s: save work before updating from %s", t.ID, target)); err != nil {
+		return target, err
+	}
+	return target, rebaseWorktree(cfg, t.WorktreePath, t.BranchName, target)
+}
+
+// conflictReview asks the user how to continue a task whose branch conflicts with its updated base branch
+func conflictReview(target string, files []string) *task.ReviewRequest {
+	question := task.ReviewQuestion{
+		ID:   "q1",
+		Kind: task.SingleChoice,
+		Text: fmt.Sprintf("%s has new commits that conflict with this task. How should the task continue?", target),
+		Options: []task.ReviewOption{
+			{ID: ResolveConflictsOptionID, Label: "Merge " + target + " in and have the AI resolve the conflicts"},
+			{ID: SkipUpdateOptionID, Label: "Continue without the new commits of " + target},
+		},
+		Default: ResolveConflictsOptionID,
+	}
+	return &task.ReviewRequest{
+		Question:  question.Text,
+		Options:   question.Options,
+		Context:   "Conflicting files:\n  " + strings.Join(files, "\n  "),
+		CreatedAt: time.Now(),
+		Questions: []task.ReviewQuestion{question},
+		Conflicts: files,
+	}
+}
+
+// pauseForConflicts handles an automatic rebase that conflicted, according to the rebase policy:
+// the task waits In Review with the conflicting files, or resumes with the AI resolving a merge of the base branch.
+// An answer the task was about to resume with is kept in its work in progress.
+func pauseForConflicts(taskStore storage.TaskStorage, cfg *config.Config, t *task.Task, conflict *MergeConflictError) error {
+	if cfg.RebaseConflictPolicy() == config.ConflictAgent {
+		files, err := mergeLeavingConflicts(cfg, t.WorktreePath, conflict.Onto)
+		if len(files) == 0 {
+			return err
+		}
+		return handConflictsToAgent(taskStore, t.ID, t.WorktreePath, conflict.Onto, files)
+	}
+
+	if t.Review != nil && t.ReviewResponse != nil {
+		appendWorkInProgress(t, "Before the task was paused, the user answered your questions:\n"+formatReviewAnswers(t.Review, t.ReviewResponse))
+	}
+	t.Status = task.NeedsReview
+	t.Review = conflictReview(conflict.Onto, conflict.Files)
+	t.ReviewResponse = nil
+	return saveTask(taskStore, t)
+}
+
+// updateBeforeResume brings the worktree of a task about to resume up to date with its base branch.
+// With automatic rebasing on, a stale branch is rebased; if that conflicts the task is paused (paused is true)
+// or, under the agent policy, the base branch is merged in and note asks the AI to resolve the conflicts first.
+// Answering a conflict review with "resolve" merges the base branch in the same way; "skip" leaves the branch as it is.
+func updateBeforeResume(taskStore storage.TaskStorage, cfg *config.Config, t *task.Task) (note string, paused bool) {
+	if t.WorktreePath == "" || !WorktreeExists(t.WorktreePath) || t.Review == nil {
+		return "", false
+	}
+
+	if len(t.Review.Conflicts) > 0 {
+		if t.ReviewResponse == nil || t.ReviewResponse.ChosenOptionID == SkipUpdateOptionID {
+			return "", false
+		}
+		return mergeBaseForAgent(cfg, t)
+	}
+
+	if !cfg.AutoRebase() {
+		return "", false
+	}
+	_, err := rebaseStaleWorktree(cfg, t)
+	var conflict *MergeConflictError
+	if !errors.As(err, &conflict) {
+		// A rebase that failed for another reason leaves the branch as it was; the task carries on from there
+		return "", false
+	}
+	if cfg.RebaseConflictPolicy() == config.ConflictAgent {
+		return mergeBaseForAgent(cfg, t)
+	}
+	_ = pauseForConflicts(taskStore, cfg, t, conflict)
+	return "", true
+}
+
+// mergeBaseForAgent merges a task's base branch into its worktree, returning a note for the AI if there are conflicts to resolve
+func mergeBaseForAgent(cfg *config.Config, t *task.Task) (string, bool) {
+	target, stale := staleBaseBranch(t)
+	if !stale {
+		return "", false
+	}
+	_ = commitAll(cfg, t.WorktreePath, fmt.Sprintf("Task %s: save work before updating from %s", t.ID, target))
+	files, _ := mergeLeavingConflicts(cfg, t.WorktreePath, target)
+	if len(files) == 0 {
+		return "", false
+	}
+	return BuildConflictNote(target, files), false
+}
@@ -1,8 +1,18 @@
 package clients
 
-import "io"
+import (
+	"context"
+	"io"
+)
 
+// AIClient is implemented by every AI provider.
+// Cancelling ctx aborts the request: CLI providers kill their process group
+// and HTTP providers abort the in-flight request.
 type AIClient interface {
-	SendPrompt(prompt string, writer io.Writer) (string, error)
-	SendPromptWithDir(prompt string, writer io.Writer, workDir string) (string, error)
+	SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error)
+	SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error)
+	// StreamPrompt and StreamPromptWithDir emit typed events to onEvent while the
+	// provider runs and return the normalized final assistant text
+	StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error)
+	StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error)
 }
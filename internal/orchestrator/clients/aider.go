@@ -0,0 +1,65 @@
+package clients
+
+import (
+	"context"
+	"io"
+)
+
+type AiderClient struct {
+	Binary string   // CLI to run (default: "aider")
+	Model  string   // e.g., "sonnet", "ollama_chat/qwen2.5-coder"; empty uses the CLI's default
+	Args   []string // Extra arguments appended to the command line
+}
+
+// NewAiderClient creates a new Aider CLI client
+// Binary defaults to aider, looked up in PATH
+func NewAiderClient(binary, model string, args []string) *AiderClient {
+	if binary == "" {
+		binary = "aider"
+	}
+	return &AiderClient{
+		Binary: binary,
+		Model:  model,
+		Args:   args,
+	}
+}
+
+// SendPrompt sends a prompt to the Aider CLI with streaming
+// - Streams output in real-time to the provided writer
+// - Runs in the current working directory (main repo)
+func (c *AiderClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return c.SendPromptWithDir(ctx, prompt, writer, "")
+}
+
+// SendPromptWithDir sends a prompt to the Aider CLI in a specific working directory (e.g., worktree)
+// - If workDir is empty, uses current working directory
+// - If ctx is cancelled the aider process and its children are killed
+func (c *AiderClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	return c.executeStreamInDir(ctx, prompt, writer, workDir)
+}
+
+// StreamPrompt sends a prompt to the Aider CLI and emits typed events as output arrives
+func (c *AiderClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return c.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir sends a prompt to the Aider CLI in a specific working directory and emits typed events
+// - Aider has no structured output, so every output line becomes an assistant text delta
+func (c *AiderClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	ew := newEventWriter(decodePlainLine, onEvent)
+	_, err := c.executeStreamInDir(ctx, prompt, ew, workDir)
+	return ew.finish(err)
+}
+
+// executeStreamInDir executes a single request to the Aider CLI in a specific working directory
+// - Uses "aider --message" to run one instruction and exit, confirming every question with --yes-always
+// - Colours and the update check are turned off, since the output goes to a file
+func (c *AiderClient) executeStreamInDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	args := []string{"--message", prompt, "--yes-always", "--no-pretty", "--no-check-update"}
+	if c.Model != "" {
+		args = append(args, "--model", c.Model)
+	}
+	args = append(args, c.Args...)
+	cmd := newAgentCommand(ctx, workDir, c.Binary, args...)
+	return streamCommand(ctx, cmd, "aider", writer)
+}
@@ -0,0 +1,185 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type ClaudeClient struct {
+	Binary string   // CLI to run (default: "claude")
+	Model  string   // e.g., "sonnet", "opus"; empty uses the CLI's default
+	Args   []string // Extra arguments appended to the command line
+}
+
+// NewClaudeClient creates a new Claude Code CLI client
+// Binary defaults to claude, looked up in PATH
+func NewClaudeClient(binary, model string, args []string) *ClaudeClient {
+	if binary == "" {
+		binary = "claude"
+	}
+	return &ClaudeClient{
+		Binary: binary,
+		Model:  model,
+		Args:   args,
+	}
+}
+
+// SendPrompt sends a prompt to the Claude Code CLI with streaming
+// - Streams the raw stream-json output in real-time to the provided writer
+// - Runs in the current working directory (main repo)
+func (c *ClaudeClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return c.SendPromptWithDir(ctx, prompt, writer, "")
+}
+
+// SendPromptWithDir sends a prompt to the Claude Code CLI in a specific working directory (e.g., worktree)
+// - If workDir is empty, uses current working directory
+// - If ctx is cancelled the claude process and its children are killed
+func (c *ClaudeClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	return c.executeStreamInDir(ctx, prompt, writer, workDir)
+}
+
+// StreamPrompt sends a prompt to the Claude Code CLI and emits typed events as output arrives
+func (c *ClaudeClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return c.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir sends a prompt to the Claude Code CLI in a specific working directory and emits typed events
+// - Text and tool use blocks of assistant messages become text deltas and tool calls
+// - Tool results come back in user messages, and the final result line carries the token usage
+func (c *ClaudeClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	ew := newEventWriter(decodeClaudeLine, onEvent)
+	_, err := c.executeStreamInDir(ctx, prompt, ew, workDir)
+	return ew.finish(err)
+}
+
+// executeStreamInDir executes a single streaming request to the Claude Code CLI in a specific working directory
+// - Uses "claude -p" for non-interactive mode; stream-json output requires --verbose
+// - Permission prompts are skipped, since nobody is there to answer them
+func (c *ClaudeClient) executeStreamInDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	args := []string{"-p", prompt, "--output-format", "stream-json", "--verbose", "--dangerously-skip-permissions"}
+	if c.Model != "" {
+		args = append(args, "--model", c.Model)
+	}
+	args = append(args, c.Args...)
+	cmd := newAgentCommand(ctx, workDir, c.Binary, args...)
+	return streamCommand(ctx, cmd, "claude", writer)
+}
+
+// claudeStreamLine mirrors the fields of a single claude --output-format stream-json line
+type claudeStreamLine struct {
+	Type    string `json:"type"`
+	Subtype string `json:"subtype"`
+	Message *struct {
+		Content json.RawMessage `json:"content"` // A list of content blocks, or a plain string
+	} `json:"message"`
+	Result  string `json:"result"`
+	IsError bool   `json:"is_error"`
+	Usage   *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// claudeContentBlock is a block of a Claude message: text, a tool use or a tool result
+type claudeContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     map[string]any  `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"` // Tool output: a string or a list of text blocks
+	IsError   bool            `json:"is_error"`
+}
+
+// decodeClaudeLine converts one stream-json line from the claude CLI into events
+// - Each text block of an assistant message becomes a text delta, ended with a newline
+// - Lines that are not JSON become error events
+func decodeClaudeLine(line string) []Event {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+
+	var raw claudeStreamLine
+	if err := json.Unmarshal([]byte(line), &raw); err != nil || raw.Type == "" {
+		return []Event{{Type: EventError, Text: line}}
+	}
+
+	switch raw.Type {
+	case "assistant", "user":
+		if raw.Message == nil {
+			return nil
+		}
+		var blocks []claudeContentBlock
+		if err := json.Unmarshal(raw.Message.Content, &blocks); err != nil {
+			return nil
+		}
+		var events []Event
+		for _, block := range blocks {
+			switch block.Type {
+			case "text":
+				if raw.Type == "assistant" && block.Text != "" {
+					text := block.Text
+					if !strings.HasSuffix(text, "\n") {
+						text += "\n"
+					}
+					events = append(events, Event{Type: EventText, Text: text})
+				}
+			case "tool_use":
+				events = append(events, Event{Type: EventToolCall, ToolID: block.ID, ToolName: block.Name, Parameters: block.Input})
+			case "tool_result":
+				status := "success"
+				if block.IsError {
+					status = "error"
+				}
+				events = append(events, Event{Type: EventToolResult, ToolID: block.ToolUseID, Status: status, Output: claudeToolOutput(block.Content)})
+			}
+		}
+		return events
+	case "result":
+		var events []Event
+		if raw.IsError {
+			message := raw.Result
+			if message == "" {
+				message = "claude finished with " + raw.Subtype
+			}
+			events = append(events, Event{Type: EventError, Text: message})
+		}
+		if raw.Usage != nil {
+			events = append(events, Event{
+				Type: EventUsage,
+				Usage: &Usage{
+					InputTokens:  raw.Usage.InputTokens,
+					OutputTokens: raw.Usage.OutputTokens,
+					TotalTokens:  raw.Usage.InputTokens + raw.Usage.OutputTokens,
+				},
+			})
+		}
+		return events
+	default:
+		// system init lines and any future event types carry nothing worth rendering
+		return nil
+	}
+}
+
+// claudeToolOutput flattens a tool result's content, which is either a string or a list of text blocks
+func claudeToolOutput(content json.RawMessage) string {
+	var text string
+	if err := json.Unmarshal(content, &text); err == nil {
+		return text
+	}
+	var blocks []claudeContentBlock
+	if err := json.Unmarshal(content, &blocks); err != nil {
+		return ""
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Text != "" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
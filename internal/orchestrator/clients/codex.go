@@ -0,0 +1,196 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+type CodexClient struct {
+	Binary string   // CLI to run (default: "codex")
+	Model  string   // e.g., "gpt-5-codex"; empty uses the CLI's default
+	Args   []string // Extra arguments appended to the command line
+}
+
+// NewCodexClient creates a new Codex CLI client
+// Binary defaults to codex, looked up in PATH
+func NewCodexClient(binary, model string, args []string) *CodexClient {
+	if binary == "" {
+		binary = "codex"
+	}
+	return &CodexClient{
+		Binary: binary,
+		Model:  model,
+		Args:   args,
+	}
+}
+
+// SendPrompt sends a prompt to the Codex CLI with streaming
+// - Streams the raw JSON Lines output in real-time to the provided writer
+// - Runs in the current working directory (main repo)
+func (c *CodexClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return c.SendPromptWithDir(ctx, prompt, writer, "")
+}
+
+// SendPromptWithDir sends a prompt to the Codex CLI in a specific working directory (e.g., worktree)
+// - If workDir is empty, uses current working directory
+// - If ctx is cancelled the codex process and its children are killed
+func (c *CodexClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	return c.executeStreamInDir(ctx, prompt, writer, workDir)
+}
+
+// StreamPrompt sends a prompt to the Codex CLI and emits typed events as output arrives
+func (c *CodexClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return c.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir sends a prompt to the Codex CLI in a specific working directory and emits typed events
+// - Agent messages become text deltas, and commands and file changes become tool calls and results
+// - Each completed turn reports its token usage
+func (c *CodexClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	ew := newEventWriter(decodeCodexLine, onEvent)
+	_, err := c.executeStreamInDir(ctx, prompt, ew, workDir)
+	return ew.finish(err)
+}
+
+// executeStreamInDir executes a single streaming request to the Codex CLI in a specific working directory
+// - Uses "codex exec" for non-interactive mode, with --full-auto so it may edit the worktree and run commands
+// - The prompt comes last, after "--", so a prompt starting with a dash is not read as a flag
+func (c *CodexClient) executeStreamInDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	args := []string{"exec", "--json", "--full-auto"}
+	if c.Model != "" {
+		args = append(args, "--model", c.Model)
+	}
+	args = append(args, c.Args...)
+	args = append(args, "--", prompt)
+	cmd := newAgentCommand(ctx, workDir, c.Binary, args...)
+	return streamCommand(ctx, cmd, "codex", writer)
+}
+
+// codexStreamLine mirrors the fields of a single codex exec --json line
+type codexStreamLine struct {
+	Type    string     `json:"type"`
+	Item    *codexItem `json:"item"`
+	Message string     `json:"message"`
+	Error   *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Usage *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	} `json:"usage"`
+}
+
+// codexItem is a unit of a Codex turn: a message, a command, a file change or an MCP tool call
+type codexItem struct {
+	ID               string `json:"id"`
+	Type             string `json:"type"`
+	Text             string `json:"text"`
+	Command          string `json:"command"`
+	AggregatedOutput string `json:"aggregated_output"`
+	ExitCode         *int   `json:"exit_code"`
+	Status           string `json:"status"`
+	Changes          []struct {
+		Path string `json:"path"`
+		Kind string `json:"kind"`
+	} `json:"changes"`
+	Server    string         `json:"server"`
+	Tool      string         `json:"tool"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+// decodeCodexLine converts one JSON line from codex exec into events
+// - A started command or MCP call becomes a tool call, and its completion a tool result
+// - File changes are only reported once applied, so they become a call and a result together
+// - Reasoning and bookkeeping lines are dropped; lines that are not JSON become error events
+func decodeCodexLine(line string) []Event {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
+
+	var raw codexStreamLine
+	if err := json.Unmarshal([]byte(line), &raw); err != nil || raw.Type == "" {
+		return []Event{{Type: EventError, Text: line}}
+	}
+
+	switch raw.Type {
+	case "item.started":
+		if raw.Item == nil {
+			return nil
+		}
+		if call, ok := codexToolCall(raw.Item); ok && raw.Item.Type != "file_change" {
+			return []Event{call}
+		}
+		return nil
+	case "item.completed":
+		item := raw.Item
+		if item == nil {
+			return nil
+		}
+		if item.Type == "agent_message" {
+			text := item.Text
+			if text != "" && !strings.HasSuffix(text, "\n") {
+				text += "\n"
+			}
+			return []Event{{Type: EventText, Text: text}}
+		}
+		call, ok := codexToolCall(item)
+		if !ok {
+			return nil
+		}
+		result := Event{Type: EventToolResult, ToolID: item.ID, ToolName: call.ToolName, Status: "success", Output: item.AggregatedOutput}
+		if item.Status == "failed" || (item.ExitCode != nil && *item.ExitCode != 0) {
+			result.Status = "error"
+		}
+		if item.Type == "file_change" {
+			return []Event{call, result}
+		}
+		return []Event{result}
+	case "turn.completed":
+		if raw.Usage == nil {
+			return nil
+		}
+		return []Event{{
+			Type: EventUsage,
+			Usage: &Usage{
+				InputTokens:  raw.Usage.InputTokens,
+				OutputTokens: raw.Usage.OutputTokens,
+				TotalTokens:  raw.Usage.InputTokens + raw.Usage.OutputTokens,
+			},
+		}}
+	case "turn.failed":
+		if raw.Error != nil {
+			return []Event{{Type: EventError, Text: raw.Error.Message}}
+		}
+		return []Event{{Type: EventError, Text: "codex turn failed"}}
+	case "error":
+		return []Event{{Type: EventError, Text: raw.Message}}
+	default:
+		// thread.started, turn.started and any future event types carry nothing worth rendering
+		return nil
+	}
+}
+
+// codexToolCall describes an item that acts on the worktree as a tool call, reporting false for other items
+func codexToolCall(item *codexItem) (Event, bool) {
+	call := Event{Type: EventToolCall, ToolID: item.ID}
+	switch item.Type {
+	case "command_execution":
+		call.ToolName = "shell"
+		call.Parameters = map[string]any{"command": item.Command}
+	case "file_change":
+		call.ToolName = "file_change"
+		call.Parameters = map[string]any{}
+		for _, change := range item.Changes {
+			call.Parameters[change.Path] = change.Kind
+		}
+	case "mcp_tool_call":
+		call.ToolName = item.Server + "/" + item.Tool
+		call.Parameters = item.Arguments
+	default:
+		return Event{}, false
+	}
+	return call, true
+}
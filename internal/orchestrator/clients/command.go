@@ -0,0 +1,148 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrRateLimited marks a run the provider refused because of a rate limit
+var ErrRateLimited = errors.New("rate limited")
+
+// Output formats of a command provider
+const (
+	CommandOutputPlain  = "plain"  // Every output line is assistant text
+	CommandOutputNDJSON = "ndjson" // One JSON object per line, with the assistant text in TextField
+)
+
+// CommandClient runs any agent CLI described by a command template
+type CommandClient struct {
+	Binary             string   // CLI to run
+	Args               []string // Argument template; {prompt}, {model} and {workdir} are replaced in every argument
+	Model              string   // Value of {model}
+	PromptOnStdin      bool     // Write the prompt to the CLI's stdin instead of passing it as an argument
+	Output             string   // CommandOutputPlain or CommandOutputNDJSON
+	TextField          string   // NDJSON field holding assistant text; dots reach nested fields, e.g. "message.content"
+	RateLimitExitCodes []int    // Exit codes that mean the CLI was rate limited
+}
+
+// NewCommandClient creates a new command-template client
+// Output defaults to plain text, and the NDJSON text field to "text"
+func NewCommandClient(binary string, args []string) *CommandClient {
+	return &CommandClient{
+		Binary:    binary,
+		Args:      args,
+		Output:    CommandOutputPlain,
+		TextField: "text",
+	}
+}
+
+// SendPrompt runs the command without a specific working directory
+// - Streams the raw output in real-time to the provided writer
+func (c *CommandClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return c.SendPromptWithDir(ctx, prompt, writer, "")
+}
+
+// SendPromptWithDir runs the command in a specific working directory (e.g., worktree)
+// - If workDir is empty, uses current working directory
+// - If ctx is cancelled the process and its children are killed
+func (c *CommandClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	return c.executeStreamInDir(ctx, prompt, writer, workDir)
+}
+
+// StreamPrompt runs the command and emits typed events as output arrives
+func (c *CommandClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return c.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir runs the command in a specific working directory and emits typed events
+// - Plain output becomes one text delta per line
+// - NDJSON output becomes a text delta per object with a text field; lines that are not JSON are kept as text
+func (c *CommandClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	decode := decodePlainLine
+	if c.Output == CommandOutputNDJSON {
+		decode = ndjsonDecoder(c.TextField)
+	}
+	ew := newEventWriter(decode, onEvent)
+	_, err := c.executeStreamInDir(ctx, prompt, ew, workDir)
+	return ew.finish(err)
+}
+
+// executeStreamInDir runs the command template in a specific working directory
+// - Without {prompt} in the template or PromptOnStdin, the prompt is passed as the last argument
+// - An exit code listed in RateLimitExitCodes is reported as ErrRateLimited
+func (c *CommandClient) executeStreamInDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	if c.Binary == "" {
+		return "", fmt.Errorf("the command provider has no binary configured")
+	}
+	switch c.Output {
+	case "", CommandOutputPlain, CommandOutputNDJSON:
+	default:
+		return "", fmt.Errorf("unknown command output format %q (use %q or %q)", c.Output, CommandOutputPlain, CommandOutputNDJSON)
+	}
+
+	dir := workDir
+	if dir == "" {
+		dir, _ = os.Getwd()
+	}
+	placeholders := strings.NewReplacer("{prompt}", prompt, "{model}", c.Model, "{workdir}", dir)
+	args := make([]string, 0, len(c.Args)+1)
+	promptPassed := c.PromptOnStdin
+	for _, arg := range c.Args {
+		if strings.Contains(arg, "{prompt}") {
+			promptPassed = true
+		}
+		args = append(args, placeholders.Replace(arg))
+	}
+	if !promptPassed {
+		args = append(args, prompt)
+	}
+
+	name := filepath.Base(c.Binary)
+	cmd := newAgentCommand(ctx, workDir, c.Binary, args...)
+	if c.PromptOnStdin {
+		cmd.Stdin = strings.NewReader(prompt)
+	}
+	response, err := streamCommand(ctx, cmd, name, writer)
+
+	var exitErr *exec.ExitError
+	if err != nil && ctx.Err() == nil && errors.As(err, &exitErr) && slices.Contains(c.RateLimitExitCodes, exitErr.ExitCode()) {
+		return response, fmt.Errorf("%s was %w (exit code %d)", name, ErrRateLimited, exitErr.ExitCode())
+	}
+	return response, err
+}
+
+// ndjsonDecoder returns a decoder that takes the assistant text of each JSON line from field
+func ndjsonDecoder(field string) lineDecoder {
+	path := strings.Split(field, ".")
+	return func(line string) []Event {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			return nil
+		}
+		var object map[string]any
+		if err := json.Unmarshal([]byte(trimmed), &object); err != nil {
+			return []Event{{Type: EventText, Text: line}}
+		}
+
+		var value any = object
+		for _, key := range path {
+			nested, ok := value.(map[string]any)
+			if !ok {
+				return nil
+			}
+			value = nested[key]
+		}
+		if text, ok := value.(string); ok && text != "" {
+			return []Event{{Type: EventText, Text: text}}
+		}
+		return nil
+	}
+}
@@ -1,10 +1,8 @@
 package clients
 
 import (
-	"bytes"
-	"fmt"
+	"context"
 	"io"
-	"os/exec"
 )
 
 type CopilotClient struct {
@@ -26,85 +24,48 @@ func NewCopilotClient(model string) *CopilotClient {
 // - Streams output in real-time to the provided writer
 // - Returns the complete response text once done
 // - Runs in the current working directory (main repo)
-func (c *CopilotClient) SendPrompt(prompt string, writer io.Writer) (string, error) {
-	return c.SendPromptWithDir(prompt, writer, "")
+func (c *CopilotClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return c.SendPromptWithDir(ctx, prompt, writer, "")
 }
 
 // SendPromptWithDir sends a prompt to GitHub Copilot CLI in a specific working directory (e.g., worktree)
 // - Same behavior as SendPrompt but executes in the provided workDir
 // - If workDir is empty, uses current working directory
 // - GitHub Copilot CLI runs with context awareness of the current directory
-func (c *CopilotClient) SendPromptWithDir(prompt string, writer io.Writer, workDir string) (string, error) {
-	return c.executeStreamInDir(prompt, writer, workDir)
+// - If ctx is cancelled the copilot process and its children are killed
+func (c *CopilotClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	return c.executeStreamInDir(ctx, prompt, writer, workDir)
+}
+
+// StreamPrompt sends a prompt to GitHub Copilot CLI and emits typed events as output arrives
+// - Returns the normalized final assistant text
+// - Runs in the current working directory (main repo)
+func (c *CopilotClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return c.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir sends a prompt to GitHub Copilot CLI in a specific working directory and emits typed events
+// - Copilot prints plain text, so every output line becomes an assistant text delta
+func (c *CopilotClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	ew := newEventWriter(decodePlainLine, onEvent)
+	_, err := c.executeStreamInDir(ctx, prompt, ew, workDir)
+	return ew.finish(err)
+}
+
+// decodePlainLine turns a line of plain text output (Copilot, Aider) into a text delta
+func decodePlainLine(line string) []Event {
+	if line == "" {
+		return nil
+	}
+	return []Event{{Type: EventText, Text: line}}
 }
 
 // executeStreamInDir executes a single streaming request to Copilot in a specific working directory
 // - Uses "copilot -p" for non-interactive mode with --allow-all-tools for automation
 // - If workDir is empty, uses current working directory
-func (c *CopilotClient) executeStreamInDir(prompt string, writer io.Writer, workDir string) (string, error) {
+func (c *CopilotClient) executeStreamInDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
 	// GitHub Copilot CLI command: copilot --model <model> -p <prompt> --allow-all-tools
 	// --allow-all-tools is required for non-interactive/automated use
-	cmd := exec.Command("copilot", "--model", c.Model, "-p", prompt, "--allow-all-tools")
-	
-	// Set working directory for the command if provided
-	if workDir != "" {
-		cmd.Dir = workDir
-	}
-
-	// Create a pipe to read stdout in real-time
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	// Capture stderr separately for error reporting
-	var stderror bytes.Buffer
-	cmd.Stderr = &stderror
-
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		stderr := stderror.String()
-		if stderr != "" {
-			return "", fmt.Errorf("failed to start copilot: %w\nstderr: %s", err, stderr)
-		}
-		return "", fmt.Errorf("failed to start copilot: %w", err)
-	}
-
-	// Stream the output to the writer in real-time
-	var fullResponse bytes.Buffer
-	buf := make([]byte, 4096)
-
-	for {
-		n, err := stdout.Read(buf)
-		if n > 0 {
-			chunk := buf[:n]
-			// Write to response writer (streams to file immediately)
-			if _, writeErr := writer.Write(chunk); writeErr != nil {
-				cmd.Wait()
-				return "", fmt.Errorf("failed to write response chunk: %w", writeErr)
-			}
-			// Also accumulate for return value
-			fullResponse.Write(chunk)
-		}
-
-		if err != nil {
-			if err != io.EOF {
-				cmd.Wait()
-				return "", fmt.Errorf("failed to read from copilot output: %w", err)
-			}
-			break
-		}
-	}
-
-	// Wait for command to complete
-	if err := cmd.Wait(); err != nil {
-		stderr := stderror.String()
-		response := fullResponse.String()
-		if stderr != "" {
-			return response, fmt.Errorf("copilot command exited with error: %w\nstderr: %s", err, stderr)
-		}
-		return response, fmt.Errorf("copilot command exited with error: %w", err)
-	}
-
-	return fullResponse.String(), nil
+	cmd := newAgentCommand(ctx, workDir, "copilot", "--model", c.Model, "-p", prompt, "--allow-all-tools")
+	return streamCommand(ctx, cmd, "copilot", writer)
 }
@@ -0,0 +1,177 @@
+package clients
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// EventType identifies the kind of a streamed provider event
+type EventType string
+
+const (
+	EventText       EventType = "text"        // Assistant text delta
+	EventToolCall   EventType = "tool_call"   // The agent invoked a tool
+	EventToolResult EventType = "tool_result" // A tool finished running
+	EventUsage      EventType = "usage"       // Token usage reported by the provider
+	EventError      EventType = "error"       // Provider error or warning notice
+	EventResult     EventType = "result"      // Final event carrying the normalized assistant text
+)
+
+// Event is a single provider-agnostic streaming event.
+// Every provider's raw output is decoded into these so that the orchestrator,
+// the response files and the output renderer only deal with one format.
+type Event struct {
+	Type       EventType      `json:"type"`
+	Timestamp  time.Time      `json:"timestamp"`
+	Text       string         `json:"text,omitempty"`       // Text delta, error message or final assistant text
+	ToolID     string         `json:"tool_id,omitempty"`    // Correlates tool calls with their results
+	ToolName   string         `json:"tool_name,omitempty"`  // Name of the tool being called
+	Parameters map[string]any `json:"parameters,omitempty"` // Arguments passed to the tool
+	Status     string         `json:"status,omitempty"`     // "success" or "error" for tool results and final results
+	Output     string         `json:"output,omitempty"`     // Tool output
+	Usage      *Usage         `json:"usage,omitempty"`      // Token counts for usage events
+}
+
+// Usage holds token counts reported by a provider
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// EventHandler receives events as they are decoded from a provider stream
+type EventHandler func(Event)
+
+// NewEventLogger returns an EventHandler that writes each event as a JSON line to w.
+// This is the format stored in response files and rendered by utils.OutputLine.
+func NewEventLogger(w io.Writer) EventHandler {
+	return func(ev Event) {
+		if w == nil {
+			return
+		}
+		data, err := json.Marshal(ev)
+		if err != nil {
+			return
+		}
+		w.Write(append(data, '\n'))
+	}
+}
+
+// lineDecoder converts one line of raw provider output (including its trailing newline) into events
+type lineDecoder func(line string) []Event
+
+// eventWriter is an io.Writer that splits raw provider output into lines,
+// decodes them into events and accumulates the assistant text.
+// It lets the streaming API reuse each client's existing byte-oriented code path.
+type eventWriter struct {
+	decode  lineDecoder
+	onEvent EventHandler
+	pending []byte
+	text    strings.Builder
+}
+
+func newEventWriter(decode lineDecoder, onEvent EventHandler) *eventWriter {
+	return &eventWriter{decode: decode, onEvent: onEvent}
+}
+
+// Write implements io.Writer, decoding every complete line it receives
+func (w *eventWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(w.pending[:i+1])
+		w.pending = w.pending[i+1:]
+		w.emitAll(w.decode(line))
+	}
+	return len(p), nil
+}
+
+// emit timestamps an event, records assistant text and forwards it to the handler
+func (w *eventWriter) emit(ev Event) {
+	if ev.Timestamp.IsZero() {
+		ev.Timestamp = time.Now()
+	}
+	if ev.Type == EventText {
+		w.text.WriteString(ev.Text)
+	}
+	if w.onEvent != nil {
+		w.onEvent(ev)
+	}
+}
+
+func (w *eventWriter) emitAll(events []Event) {
+	for _, ev := range events {
+		w.emit(ev)
+	}
+}
+
+// flush decodes a trailing partial line
+func (w *eventWriter) flush() {
+	if len(w.pending) > 0 {
+		line := string(w.pending)
+		w.pending = nil
+		w.emitAll(w.decode(line))
+	}
+}
+
+// startAttempt discards the assistant text of an earlier run, so a retried or fallen back run
+// returns only its own text; the earlier run's events have already been emitted
+func (w *eventWriter) startAttempt() {
+	w.flush()
+	w.text.Reset()
+}
+
+// finish decodes any trailing partial line, emits the error (if any) and the final
+// result event, and returns the normalized assistant text
+func (w *eventWriter) finish(err error) (string, error) {
+	w.flush()
+
+	status := "success"
+	if err != nil {
+		status = "error"
+		w.emit(Event{Type: EventError, Text: err.Error()})
+	}
+
+	final := w.text.String()
+	w.emit(Event{Type: EventResult, Text: final, Status: status})
+	return final, err
+}
+
+// parseTimestamp parses an RFC3339 provider timestamp, returning the zero time if invalid
+func parseTimestamp(s string) time.Time {
+	ts, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return ts
+}
+
+// ReplayText recovers the assistant text from a logged event stream (e.g. a response file).
+// Returns the text of the last result event, or the concatenated text deltas if the
+// stream was cut off before a result was written. Lines that are not events are ignored.
+func ReplayText(log string) string {
+	var text strings.Builder
+	for _, line := range strings.Split(log, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "{") {
+			continue
+		}
+		var ev Event
+		if err := json.Unmarshal([]byte(line), &ev); err != nil {
+			continue
+		}
+		switch ev.Type {
+		case EventText:
+			text.WriteString(ev.Text)
+		case EventResult:
+			return ev.Text
+		}
+	}
+	return text.String()
+}
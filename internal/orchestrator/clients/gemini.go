@@ -1,10 +1,10 @@
 package clients
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"os/exec"
 	"strings"
 	"time"
 )
@@ -26,21 +26,27 @@ var modelFallbackChain = []string{
 // - On failure (non-rate-limit), falls back to the next weaker model
 // - Returns the complete response text once done
 // - Runs in the current working directory (main repo)
-func (g *GeminiClient) SendPrompt(prompt string, writer io.Writer) (string, error) {
-	return g.SendPromptWithDir(prompt, writer, "")
+func (g *GeminiClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return g.SendPromptWithDir(ctx, prompt, writer, "")
 }
 
 // SendPromptWithDir sends a prompt to Gemini in a specific working directory (e.g., worktree).
 // - Same behavior as SendPrompt but executes in the provided workDir
 // - If workDir is empty, uses current working directory
-func (g *GeminiClient) SendPromptWithDir(prompt string, writer io.Writer, workDir string) (string, error) {
+// - If ctx is cancelled the gemini process is killed and no further models are tried
+func (g *GeminiClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
 	for _, model := range modelFallbackChain {
-		response, err := g.SendPromptWithModelAndDir(prompt, writer, model, workDir)
+		response, err := g.SendPromptWithModelAndDir(ctx, prompt, writer, model, workDir)
 		
 		// If successful, return
 		if err == nil {
 			return response, nil
 		}
+
+		// Cancelled or timed out: falling back would just start another process
+		if ctx.Err() != nil {
+			return response, err
+		}
 		
 		// If it's a rate limit error, don't fall back - return immediately
 		if isRateLimitError(response, err) {
@@ -57,19 +63,37 @@ func (g *GeminiClient) SendPromptWithDir(prompt string, writer io.Writer, workDi
 	return "", fmt.Errorf("all models exhausted")
 }
 
+// StreamPrompt sends a prompt to Gemini and emits typed events as the stream-json output arrives.
+// - Returns the normalized final assistant text
+// - Runs in the current working directory (main repo)
+func (g *GeminiClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return g.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir sends a prompt to Gemini in a specific working directory and emits typed events.
+// - Uses the same retry and model fallback behavior as SendPromptWithDir
+// - Fallback and rate limit notices are emitted as error events
+// - Returns the normalized assistant text of the last run, without text from failed attempts
+func (g *GeminiClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	ew := newEventWriter(decodeGeminiLine, onEvent)
+	_, err := g.SendPromptWithDir(ctx, prompt, ew, workDir)
+	return ew.finish(err)
+}
+
 // SendPromptWithModel sends a prompt to Gemini using a specific model with rate limit retries
 // - Retries up to 3 times on rate limit (429) errors with exponential backoff
 // - Includes partial work from previous attempt so AI can catch up and continue
 // - Returns the complete response text once done
 // - Runs in the current working directory (main repo)
-func (g *GeminiClient) SendPromptWithModel(prompt string, writer io.Writer, model string) (string, error) {
-	return g.SendPromptWithModelAndDir(prompt, writer, model, "")
+func (g *GeminiClient) SendPromptWithModel(ctx context.Context, prompt string, writer io.Writer, model string) (string, error) {
+	return g.SendPromptWithModelAndDir(ctx, prompt, writer, model, "")
 }
 
 // SendPromptWithModelAndDir sends a prompt to Gemini in a specific directory using a specific model
 // - Same behavior as SendPromptWithModel but executes in the provided workDir
 // - If workDir is empty, uses current working directory
-func (g *GeminiClient) SendPromptWithModelAndDir(prompt string, writer io.Writer, model string, workDir string) (string, error) {
+// - Backoff waits are interrupted when ctx is cancelled
+func (g *GeminiClient) SendPromptWithModelAndDir(ctx context.Context, prompt string, writer io.Writer, model string, workDir string) (string, error) {
 	maxRetries := 3
 	baseDelay := 30 * time.Second
 	var lastPartialResponse string
@@ -81,7 +105,7 @@ func (g *GeminiClient) SendPromptWithModelAndDir(prompt string, writer io.Writer
 			promptToUse = buildRetryPrompt(prompt, lastPartialResponse)
 		}
 
-		response, err := g.executeStreamInDir(promptToUse, writer, model, workDir)
+		response, err := g.executeStreamInDir(ctx, promptToUse, writer, model, workDir)
 
 		// Check for rate limit error (429)
 		if isRateLimitError(response, err) {
@@ -92,7 +116,9 @@ func (g *GeminiClient) SendPromptWithModelAndDir(prompt string, writer io.Writer
 				if writer != nil {
 					writer.Write([]byte(msg))
 				}
-				time.Sleep(delay)
+				if err := sleepContext(ctx, delay); err != nil {
+					return response, fmt.Errorf("gemini cancelled while waiting to retry: %w", err)
+				}
 				continue
 			}
 			// Out of retries
@@ -108,77 +134,108 @@ func (g *GeminiClient) SendPromptWithModelAndDir(prompt string, writer io.Writer
 
 // executeStream executes a single streaming request to Gemini using a specific model
 // - Runs in the current working directory (main repo)
-func (g *GeminiClient) executeStream(prompt string, writer io.Writer, model string) (string, error) {
-	return g.executeStreamInDir(prompt, writer, model, "")
+func (g *GeminiClient) executeStream(ctx context.Context, prompt string, writer io.Writer, model string) (string, error) {
+	return g.executeStreamInDir(ctx, prompt, writer, model, "")
 }
 
 // executeStreamInDir executes a single streaming request to Gemini in a specific working directory
 // - If workDir is empty, uses current working directory
-func (g *GeminiClient) executeStreamInDir(prompt string, writer io.Writer, model string, workDir string) (string, error) {
-	// Use --output-format stream-json for real-time event streaming
-	cmd := exec.Command("gemini", "--yolo", "--model", model, "--output-format", "stream-json", prompt)
-	
-	// Set working directory for the command
-	if workDir != "" {
-		cmd.Dir = workDir
+func (g *GeminiClient) executeStreamInDir(ctx context.Context, prompt string, writer io.Writer, model string, workDir string) (string, error) {
+	// Each retry and fallback is a new run, and only the last run's text is the response
+	if ew, ok := writer.(*eventWriter); ok {
+		ew.startAttempt()
 	}
+	// Use --output-format stream-json for real-time event streaming
+	cmd := newAgentCommand(ctx, workDir, "gemini", "--yolo", "--model", model, "--output-format", "stream-json", prompt)
+	return streamCommand(ctx, cmd, "gemini", writer)
+}
 
-	// Create a pipe to read stdout in real-time
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return "", fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
+// geminiStreamLine mirrors the fields of a single gemini --output-format stream-json line
+type geminiStreamLine struct {
+	Type       string         `json:"type"`
+	Timestamp  string         `json:"timestamp"`
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolName   string         `json:"tool_name"`
+	ToolID     string         `json:"tool_id"`
+	Parameters map[string]any `json:"parameters"`
+	Status     string         `json:"status"`
+	Output     string         `json:"output"`
+	Message    string         `json:"message"`
+	Error      *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Stats *struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"stats"`
+}
 
-	// Capture stderr separately for error reporting
-	var stderror bytes.Buffer
-	cmd.Stderr = &stderror
+// decodeGeminiLine converts one stream-json line from the gemini CLI into events
+// - Assistant messages become text deltas; echoed user prompts and init lines are dropped
+// - Lines that are not JSON (e.g. fallback notices) become error events
+func decodeGeminiLine(line string) []Event {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil
+	}
 
-	// Start the command
-	if err := cmd.Start(); err != nil {
-		stderr := stderror.String()
-		if stderr != "" {
-			return "", fmt.Errorf("failed to start gemini: %w\nstderr: %s", err, stderr)
-		}
-		return "", fmt.Errorf("failed to start gemini: %w", err)
+	var raw geminiStreamLine
+	if err := json.Unmarshal([]byte(line), &raw); err != nil || raw.Type == "" {
+		return []Event{{Type: EventError, Text: line}}
 	}
+	timestamp := parseTimestamp(raw.Timestamp)
 
-	// Stream the output to the writer in real-time
-	var fullResponse bytes.Buffer
-	buf := make([]byte, 4096)
-
-	for {
-		n, err := stdout.Read(buf)
-		if n > 0 {
-			chunk := buf[:n]
-			// Write to response writer (streams to file immediately)
-			if _, writeErr := writer.Write(chunk); writeErr != nil {
-				cmd.Wait()
-				return "", fmt.Errorf("failed to write response chunk: %w", writeErr)
-			}
-			// Also accumulate for return value
-			fullResponse.Write(chunk)
+	switch raw.Type {
+	case "message":
+		if raw.Role == "user" {
+			return nil
 		}
-
-		if err != nil {
-			if err != io.EOF {
-				cmd.Wait()
-				return "", fmt.Errorf("failed to read from gemini output: %w", err)
-			}
-			break
+		return []Event{{Type: EventText, Timestamp: timestamp, Text: raw.Content}}
+	case "tool_use":
+		return []Event{{
+			Type:       EventToolCall,
+			Timestamp:  timestamp,
+			ToolID:     raw.ToolID,
+			ToolName:   raw.ToolName,
+			Parameters: raw.Parameters,
+		}}
+	case "tool_result":
+		output := raw.Output
+		if raw.Error != nil && output == "" {
+			output = raw.Error.Message
 		}
-	}
-
-	// Wait for command to complete
-	if err := cmd.Wait(); err != nil {
-		stderr := stderror.String()
-		response := fullResponse.String()
-		if stderr != "" {
-			return response, fmt.Errorf("gemini command exited with error: %w\nstderr: %s", err, stderr)
+		return []Event{{
+			Type:      EventToolResult,
+			Timestamp: timestamp,
+			ToolID:    raw.ToolID,
+			Status:    raw.Status,
+			Output:    output,
+		}}
+	case "error":
+		return []Event{{Type: EventError, Timestamp: timestamp, Text: raw.Message}}
+	case "result":
+		var events []Event
+		if raw.Error != nil {
+			events = append(events, Event{Type: EventError, Timestamp: timestamp, Text: raw.Error.Message})
+		}
+		if raw.Stats != nil {
+			events = append(events, Event{
+				Type:      EventUsage,
+				Timestamp: timestamp,
+				Usage: &Usage{
+					InputTokens:  raw.Stats.InputTokens,
+					OutputTokens: raw.Stats.OutputTokens,
+					TotalTokens:  raw.Stats.TotalTokens,
+				},
+			})
 		}
-		return response, fmt.Errorf("gemini command exited with error: %w", err)
+		return events
+	default:
+		// init and any future event types carry nothing worth rendering
+		return nil
 	}
-
-	return fullResponse.String(), nil
 }
 
 // buildRetryPrompt creates a new prompt that includes the partial work from the previous attempt
@@ -2,6 +2,8 @@ package clients
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,8 +11,10 @@ import (
 )
 
 type OllamaClient struct {
-	BaseURL string // e.g., "http://localhost:11434"
-	Model   string // e.g., "mistral", "neural-chat", "dolphin-mixtral"
+	BaseURL   string   // e.g., "http://localhost:11434"
+	Model     string   // e.g., "mistral", "neural-chat", "dolphin-mixtral"
+	MaxSteps  int      // Maximum chat requests in one run; each answers the previous tool calls
+	CommitEnv []string // Extra environment for the model's commits, e.g. GIT_AUTHOR_NAME (nil: git's own identity)
 }
 
 // NewOllamaClient creates a new Ollama client with default settings
@@ -24,91 +28,178 @@ func NewOllamaClient(baseURL, model string) *OllamaClient {
 		model = "mistral"
 	}
 	return &OllamaClient{
-		BaseURL: baseURL,
-		Model:   model,
+		BaseURL:  baseURL,
+		Model:    model,
+		MaxSteps: DefaultMaxToolSteps,
 	}
 }
 
 // SendPrompt sends a prompt to Ollama without a specific working directory
-func (o *OllamaClient) SendPrompt(prompt string, writer io.Writer) (string, error) {
-	return o.SendPromptWithDir(prompt, writer, "")
+func (o *OllamaClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return o.SendPromptWithDir(ctx, prompt, writer, "")
 }
 
-// SendPromptWithDir sends a prompt to Ollama (working directory is ignored for Ollama)
-// Ollama doesn't support working directory context like the gemini CLI does,
-// but we include it in the interface for compatibility
-func (o *OllamaClient) SendPromptWithDir(prompt string, writer io.Writer, workDir string) (string, error) {
+// SendPromptWithDir runs a prompt through Ollama's /api/chat endpoint, writing the assistant text to writer
+// - With a working directory the model can read, write and search files, run commands and commit there
+// - Cancelling ctx aborts the HTTP request
+func (o *OllamaClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	return o.StreamPromptWithDir(ctx, prompt, workDir, func(ev Event) {
+		if ev.Type == EventText && writer != nil {
+			writer.Write([]byte(ev.Text))
+		}
+	})
+}
+
+// StreamPrompt sends a prompt to Ollama and emits typed events as the NDJSON stream arrives
+func (o *OllamaClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return o.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir runs a prompt through Ollama's /api/chat endpoint and emits typed events
+// - Each streamed message delta becomes an assistant text delta, and each reply's token counts a usage event
+// - With a working directory the model is offered Ludwig's tools, and each call and result is emitted as tool events
+// - Tool results are sent back to the model until it replies without calling a tool
+// - The loop stops with an error after MaxSteps requests
+func (o *OllamaClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	conv := &ollamaConversation{client: o}
 	if workDir != "" {
-		// Include workdir context in the prompt for Ollama
-		prompt = fmt.Sprintf("Current working directory: %s\n\n%s", workDir, prompt)
+		conv.messages = append(conv.messages, ollamaMessage{Role: "system", Content: fmt.Sprintf(toolSystemPrompt, workDir)})
+		conv.tools = toolDefinitions()
+	}
+	conv.messages = append(conv.messages, ollamaMessage{Role: "user", Content: prompt})
+
+	ew := newEventWriter(nil, onEvent)
+	return ew.finish(runToolLoop(ctx, "ollama", conv, toolWorkspace{Dir: workDir, CommitEnv: o.CommitEnv}, o.MaxSteps, ew))
+}
+
+// ollamaMessage is a message of an /api/chat conversation
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"` // Tool whose result a "tool" message carries
+}
+
+// ollamaToolCall is a tool call requested by the model
+type ollamaToolCall struct {
+	Function struct {
+		Name      string         `json:"name"`
+		Arguments map[string]any `json:"arguments"`
+	} `json:"function"`
+}
+
+// ollamaChatChunk mirrors a single object streamed by /api/chat
+type ollamaChatChunk struct {
+	Message         ollamaMessage `json:"message"`
+	Done            bool          `json:"done"`
+	Error           string        `json:"error"`
+	CreatedAt       string        `json:"created_at"`
+	PromptEvalCount int           `json:"prompt_eval_count"`
+	EvalCount       int           `json:"eval_count"`
+}
+
+// ollamaConversation is an /api/chat conversation driven by runToolLoop
+type ollamaConversation struct {
+	client   *OllamaClient
+	messages []ollamaMessage
+	tools    []map[string]any
+	steps    int
+}
+
+func (c *ollamaConversation) next(ctx context.Context, ew *eventWriter) ([]toolCall, error) {
+	reply, err := c.client.chat(ctx, c.messages, c.tools, ew)
+	if err != nil {
+		return nil, err
 	}
+	c.messages = append(c.messages, reply)
 
-	return o.sendToOllama(prompt, writer)
+	// Ollama does not identify tool calls, so they are numbered for the events
+	calls := make([]toolCall, 0, len(reply.ToolCalls))
+	for i, call := range reply.ToolCalls {
+		calls = append(calls, toolCall{ID: fmt.Sprintf("ollama-%d-%d", c.steps, i), Name: call.Function.Name, Arguments: call.Function.Arguments})
+	}
+	c.steps++
+	return calls, nil
 }
 
-// sendToOllama makes the actual HTTP request to Ollama's /api/generate endpoint
-func (o *OllamaClient) sendToOllama(prompt string, writer io.Writer) (string, error) {
-	// Prepare request body
-	reqBody := fmt.Sprintf(`{"model":"%s","prompt":"%s","stream":true,"raw":true}`,
-		o.Model, escapeJSON(prompt))
+func (c *ollamaConversation) addToolResult(call toolCall, result string) {
+	c.messages = append(c.messages, ollamaMessage{Role: "tool", Content: result, ToolName: call.Name})
+}
 
-	// Create HTTP request
-	url := fmt.Sprintf("%s/api/generate", strings.TrimSuffix(o.BaseURL, "/"))
-	req, err := http.NewRequest("POST", url, bytes.NewBufferString(reqBody))
+// chat sends one /api/chat request and streams the reply, returning the complete assistant message
+func (o *OllamaClient) chat(ctx context.Context, messages []ollamaMessage, tools []map[string]any, ew *eventWriter) (ollamaMessage, error) {
+	reply := ollamaMessage{Role: "assistant"}
+	request := map[string]any{
+		"model":    o.Model,
+		"messages": messages,
+		"stream":   true,
+	}
+	if len(tools) > 0 {
+		request["tools"] = tools
+	}
+	body, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return reply, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/api/chat", strings.TrimSuffix(o.BaseURL, "/"))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return reply, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
-	// Send request
 	client := &http.Client{}
 	resp, err := client.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("failed to connect to Ollama at %s: %w. Make sure Ollama is running with `ollama serve`", o.BaseURL, err)
+		if ctx.Err() != nil {
+			return reply, fmt.Errorf("ollama request cancelled: %w", context.Cause(ctx))
+		}
+		return reply, fmt.Errorf("failed to connect to Ollama at %s: %w. Make sure Ollama is running with `ollama serve`", o.BaseURL, err)
 	}
 	defer resp.Body.Close()
 
-	// Check for HTTP errors
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
+		return reply, fmt.Errorf("ollama returned status %d: %s", resp.StatusCode, string(body))
 	}
 
-	// Stream the response
-	var fullResponse bytes.Buffer
-	buf := make([]byte, 4096)
-
+	// The stream is a sequence of JSON objects, usually one per line
+	var content strings.Builder
+	dec := json.NewDecoder(resp.Body)
 	for {
-		n, err := resp.Body.Read(buf)
-		if n > 0 {
-			chunk := buf[:n]
-			// Write to response writer if provided
-			if writer != nil {
-				if _, writeErr := writer.Write(chunk); writeErr != nil {
-					return "", fmt.Errorf("failed to write response chunk: %w", writeErr)
-				}
+		var chunk ollamaChatChunk
+		if err := dec.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			if ctx.Err() != nil {
+				return reply, fmt.Errorf("ollama request cancelled: %w", context.Cause(ctx))
 			}
-			// Also accumulate for return value
-			fullResponse.Write(chunk)
+			return reply, fmt.Errorf("failed to read from ollama output: %w", err)
 		}
 
-		if err != nil {
-			if err != io.EOF {
-				return fullResponse.String(), fmt.Errorf("failed to read from ollama output: %w", err)
-			}
-			break
+		timestamp := parseTimestamp(chunk.CreatedAt)
+		if chunk.Error != "" {
+			ew.emit(Event{Type: EventError, Timestamp: timestamp, Text: chunk.Error})
+		}
+		if chunk.Message.Content != "" {
+			content.WriteString(chunk.Message.Content)
+			ew.emit(Event{Type: EventText, Timestamp: timestamp, Text: chunk.Message.Content})
+		}
+		reply.ToolCalls = append(reply.ToolCalls, chunk.Message.ToolCalls...)
+		if chunk.Done {
+			ew.emit(Event{
+				Type:      EventUsage,
+				Timestamp: timestamp,
+				Usage: &Usage{
+					InputTokens:  chunk.PromptEvalCount,
+					OutputTokens: chunk.EvalCount,
+					TotalTokens:  chunk.PromptEvalCount + chunk.EvalCount,
+				},
+			})
 		}
 	}
-
-	return fullResponse.String(), nil
-}
-
-// escapeJSON escapes special characters for JSON string
-func escapeJSON(s string) string {
-	s = strings.ReplaceAll(s, "\\", "\\\\")
-	s = strings.ReplaceAll(s, "\"", "\\\"")
-	s = strings.ReplaceAll(s, "\n", "\\n")
-	s = strings.ReplaceAll(s, "\r", "\\r")
-	s = strings.ReplaceAll(s, "\t", "\\t")
-	return s
+	reply.Content = content.String()
+	return reply, nil
 }
@@ -0,0 +1,269 @@
+package clients
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// OpenAIClient talks to any server implementing the OpenAI chat completions API,
+// such as llama.cpp's server, vLLM or LM Studio
+type OpenAIClient struct {
+	BaseURL   string   // e.g., "http://localhost:8080/v1"
+	Model     string   // Model to request; servers that host a single model may ignore it
+	APIKey    string   // Sent as a bearer token when set
+	MaxSteps  int      // Maximum chat requests in one run; each answers the previous tool calls
+	CommitEnv []string // Extra environment for the model's commits, e.g. GIT_AUTHOR_NAME (nil: git's own identity)
+}
+
+// NewOpenAIClient creates a new OpenAI-compatible client
+// BaseURL defaults to http://localhost:8080/v1 (llama.cpp's server)
+func NewOpenAIClient(baseURL, model, apiKey string) *OpenAIClient {
+	if baseURL == "" {
+		baseURL = "http://localhost:8080/v1"
+	}
+	return &OpenAIClient{
+		BaseURL:  baseURL,
+		Model:    model,
+		APIKey:   apiKey,
+		MaxSteps: DefaultMaxToolSteps,
+	}
+}
+
+// SendPrompt sends a prompt to the server without a specific working directory
+func (o *OpenAIClient) SendPrompt(ctx context.Context, prompt string, writer io.Writer) (string, error) {
+	return o.SendPromptWithDir(ctx, prompt, writer, "")
+}
+
+// SendPromptWithDir runs a prompt through the chat completions endpoint, writing the assistant text to writer
+// - With a working directory the model can read, write and search files, run commands and commit there
+// - Cancelling ctx aborts the HTTP request
+func (o *OpenAIClient) SendPromptWithDir(ctx context.Context, prompt string, writer io.Writer, workDir string) (string, error) {
+	return o.StreamPromptWithDir(ctx, prompt, workDir, func(ev Event) {
+		if ev.Type == EventText && writer != nil {
+			writer.Write([]byte(ev.Text))
+		}
+	})
+}
+
+// StreamPrompt sends a prompt to the server and emits typed events as the SSE stream arrives
+func (o *OpenAIClient) StreamPrompt(ctx context.Context, prompt string, onEvent EventHandler) (string, error) {
+	return o.StreamPromptWithDir(ctx, prompt, "", onEvent)
+}
+
+// StreamPromptWithDir runs a prompt through the chat completions endpoint and emits typed events
+// - Each streamed content delta becomes an assistant text delta, and each reply's token counts a usage event
+// - With a working directory the model is offered Ludwig's tools, and each call and result is emitted as tool events
+// - Tool results are sent back to the model until it replies without calling a tool
+// - The loop stops with an error after MaxSteps requests
+func (o *OpenAIClient) StreamPromptWithDir(ctx context.Context, prompt string, workDir string, onEvent EventHandler) (string, error) {
+	conv := &openAIConversation{client: o}
+	if workDir != "" {
+		conv.messages = append(conv.messages, openAIMessage{Role: "system", Content: fmt.Sprintf(toolSystemPrompt, workDir)})
+		conv.tools = toolDefinitions()
+	}
+	conv.messages = append(conv.messages, openAIMessage{Role: "user", Content: prompt})
+
+	ew := newEventWriter(nil, onEvent)
+	return ew.finish(runToolLoop(ctx, "openai", conv, toolWorkspace{Dir: workDir, CommitEnv: o.CommitEnv}, o.MaxSteps, ew))
+}
+
+// openAIMessage is a message of a chat completions conversation
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"` // Call whose result a "tool" message carries
+}
+
+// openAIToolCall is a tool call requested by the model
+type openAIToolCall struct {
+	ID       string             `json:"id"`
+	Type     string             `json:"type"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+// openAIFunctionCall names the called tool; the arguments are a JSON object encoded as a string
+type openAIFunctionCall struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// openAIToolCallDelta is a fragment of a streamed tool call.
+// The fragments of one call share an index, and its name and arguments are split across them.
+type openAIToolCallDelta struct {
+	Index    int                `json:"index"`
+	ID       string             `json:"id"`
+	Function openAIFunctionCall `json:"function"`
+}
+
+// openAIChunk mirrors a single server-sent event of a streamed chat completion
+type openAIChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content   string                `json:"content"`
+			ToolCalls []openAIToolCallDelta `json:"tool_calls"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// openAIConversation is a chat completions conversation driven by runToolLoop
+type openAIConversation struct {
+	client   *OpenAIClient
+	messages []openAIMessage
+	tools    []map[string]any
+	steps    int
+}
+
+func (c *openAIConversation) next(ctx context.Context, ew *eventWriter) ([]toolCall, error) {
+	reply, err := c.client.chat(ctx, c.messages, c.tools, ew)
+	if err != nil {
+		return nil, err
+	}
+
+	calls := make([]toolCall, 0, len(reply.ToolCalls))
+	for i := range reply.ToolCalls {
+		call := &reply.ToolCalls[i]
+		if call.ID == "" {
+			// Some servers leave calls unnamed, but every result must name its call
+			call.ID = fmt.Sprintf("call-%d-%d", c.steps, i)
+		}
+		parsed := toolCall{ID: call.ID, Name: call.Function.Name}
+		if strings.TrimSpace(call.Function.Arguments) != "" {
+			parsed.ArgumentsErr = json.Unmarshal([]byte(call.Function.Arguments), &parsed.Arguments)
+		}
+		calls = append(calls, parsed)
+	}
+	c.messages = append(c.messages, reply)
+	c.steps++
+	return calls, nil
+}
+
+func (c *openAIConversation) addToolResult(call toolCall, result string) {
+	c.messages = append(c.messages, openAIMessage{Role: "tool", Content: result, ToolCallID: call.ID})
+}
+
+// chat sends one chat completions request and streams the reply, returning the complete assistant message
+func (o *OpenAIClient) chat(ctx context.Context, messages []openAIMessage, tools []map[string]any, ew *eventWriter) (openAIMessage, error) {
+	reply := openAIMessage{Role: "assistant"}
+	request := map[string]any{
+		"messages":       messages,
+		"stream":         true,
+		"stream_options": map[string]any{"include_usage": true},
+	}
+	if o.Model != "" {
+		request["model"] = o.Model
+	}
+	if len(tools) > 0 {
+		request["tools"] = tools
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return reply, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	url := strings.TrimSuffix(o.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return reply, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if o.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.APIKey)
+	}
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return reply, fmt.Errorf("openai request cancelled: %w", context.Cause(ctx))
+		}
+		return reply, fmt.Errorf("failed to connect to the OpenAI-compatible server at %s: %w", o.BaseURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return reply, fmt.Errorf("openai server returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var content strings.Builder
+	calls := map[int]*openAIToolCall{}
+	var order []int
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		// Server-sent events: only "data:" lines carry chunks, and "[DONE]" ends the stream
+		data, found := strings.CutPrefix(scanner.Text(), "data:")
+		data = strings.TrimSpace(data)
+		if !found || data == "" {
+			continue
+		}
+		if data == "[DONE]" {
+			break
+		}
+		var chunk openAIChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return reply, fmt.Errorf("failed to decode openai stream: %w", err)
+		}
+		if chunk.Error != nil {
+			return reply, fmt.Errorf("openai server error: %s", chunk.Error.Message)
+		}
+
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				ew.emit(Event{Type: EventText, Text: choice.Delta.Content})
+			}
+			for _, fragment := range choice.Delta.ToolCalls {
+				call, ok := calls[fragment.Index]
+				if !ok {
+					call = &openAIToolCall{Type: "function"}
+					calls[fragment.Index] = call
+					order = append(order, fragment.Index)
+				}
+				if fragment.ID != "" {
+					call.ID = fragment.ID
+				}
+				call.Function.Name += fragment.Function.Name
+				call.Function.Arguments += fragment.Function.Arguments
+			}
+		}
+		if chunk.Usage != nil {
+			ew.emit(Event{
+				Type: EventUsage,
+				Usage: &Usage{
+					InputTokens:  chunk.Usage.PromptTokens,
+					OutputTokens: chunk.Usage.CompletionTokens,
+					TotalTokens:  chunk.Usage.TotalTokens,
+				},
+			})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		if ctx.Err() != nil {
+			return reply, fmt.Errorf("openai request cancelled: %w", context.Cause(ctx))
+		}
+		return reply, fmt.Errorf("failed to read from openai stream: %w", err)
+	}
+
+	reply.Content = content.String()
+	for _, index := range order {
+		reply.ToolCalls = append(reply.ToolCalls, *calls[index])
+	}
+	return reply, nil
+}
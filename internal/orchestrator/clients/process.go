@@ -0,0 +1,114 @@
+package clients
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// newAgentCommand creates a command for an agent CLI that is bound to ctx.
+// Cancelling ctx kills the CLI together with every process it spawned.
+func newAgentCommand(ctx context.Context, workDir string, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	if workDir != "" {
+		cmd.Dir = workDir
+	}
+	configureProcessGroup(cmd)
+	return cmd
+}
+
+// NewShellCommand creates a command that runs a shell command line in workDir.
+// Like an agent CLI, it is bound to ctx and cancelling ctx kills every process it spawned.
+func NewShellCommand(ctx context.Context, workDir string, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return newAgentCommand(ctx, workDir, "cmd", "/C", command)
+	}
+	return newAgentCommand(ctx, workDir, "sh", "-c", command)
+}
+
+// streamCommand runs cmd, streaming stdout to writer in real-time
+// - Returns the complete stdout once the command exits
+// - stderr is captured separately and included in errors
+// - If ctx is cancelled the process group is killed and the cancellation cause is returned
+func streamCommand(ctx context.Context, cmd *exec.Cmd, name string, writer io.Writer) (string, error) {
+	// Create a pipe to read stdout in real-time
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("failed to create stdout pipe: %w", err)
+	}
+
+	// Capture stderr separately for error reporting
+	var stderror bytes.Buffer
+	cmd.Stderr = &stderror
+
+	// Start the command
+	if err := cmd.Start(); err != nil {
+		stderr := stderror.String()
+		if stderr != "" {
+			return "", fmt.Errorf("failed to start %s: %w\nstderr: %s", name, err, stderr)
+		}
+		return "", fmt.Errorf("failed to start %s: %w", name, err)
+	}
+
+	// Stream the output to the writer in real-time
+	var fullResponse bytes.Buffer
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := stdout.Read(buf)
+		if n > 0 {
+			chunk := buf[:n]
+			// Write to response writer (streams to file immediately)
+			if writer != nil {
+				if _, writeErr := writer.Write(chunk); writeErr != nil {
+					cmd.Wait()
+					return "", fmt.Errorf("failed to write response chunk: %w", writeErr)
+				}
+			}
+			// Also accumulate for return value
+			fullResponse.Write(chunk)
+		}
+
+		if err != nil {
+			if err != io.EOF {
+				cmd.Wait()
+				if ctx.Err() != nil {
+					return fullResponse.String(), fmt.Errorf("%s cancelled: %w", name, context.Cause(ctx))
+				}
+				return "", fmt.Errorf("failed to read from %s output: %w", name, err)
+			}
+			break
+		}
+	}
+
+	// Wait for command to complete
+	if err := cmd.Wait(); err != nil {
+		response := fullResponse.String()
+		if ctx.Err() != nil {
+			return response, fmt.Errorf("%s cancelled: %w", name, context.Cause(ctx))
+		}
+		stderr := stderror.String()
+		if stderr != "" {
+			return response, fmt.Errorf("%s command exited with error: %w\nstderr: %s", name, err, stderr)
+		}
+		return response, fmt.Errorf("%s command exited with error: %w", name, err)
+	}
+
+	return fullResponse.String(), nil
+}
+
+// sleepContext waits for d or until ctx is done, returning the cancellation cause in the latter case
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
@@ -0,0 +1,21 @@
+//go:build !windows
+
+package clients
+
+import (
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// configureProcessGroup starts the command in its own process group so that
+// cancellation also kills any tools or shells the agent CLI spawned
+func configureProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// A negative pid signals every process in the group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	// Don't wait forever for grandchildren that still hold the output pipes
+	cmd.WaitDelay = 5 * time.Second
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package clients
+
+import (
+	"os/exec"
+	"strconv"
+	"time"
+)
+
+// configureProcessGroup makes cancellation kill the whole process tree of the agent CLI
+func configureProcessGroup(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		// taskkill /T terminates the process and every child it started
+		return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+	}
+	// Don't wait forever for grandchildren that still hold the output pipes
+	cmd.WaitDelay = 5 * time.Second
+}
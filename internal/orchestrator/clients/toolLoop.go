@@ -0,0 +1,81 @@
+package clients
+
+import (
+	"context"
+	"fmt"
+)
+
+// DefaultMaxToolSteps bounds the tool-calling loop of a client that sets no limit
+const DefaultMaxToolSteps = 30
+
+// toolSystemPrompt tells the model where it works and how to use the tools
+const toolSystemPrompt = `You are working in the directory %s, a git worktree for your task.
+Use the tools to look at and change the files there: paths are relative to that directory.
+Make the changes yourself with write_file rather than describing them, check your work with run_command,
+and commit it with git_commit. When you are done, reply with a short summary of what you changed.`
+
+// toolCall is a tool call requested by the model, independent of the provider's wire format
+type toolCall struct {
+	ID           string
+	Name         string
+	Arguments    map[string]any
+	ArgumentsErr error // Set when the model's arguments could not be decoded
+}
+
+// toolConversation is a provider's side of the tool-calling loop
+type toolConversation interface {
+	// next sends the conversation so far, streams the model's reply through ew and returns the tool calls it made
+	next(ctx context.Context, ew *eventWriter) ([]toolCall, error)
+	// addToolResult records a tool's result, which is sent with the next request
+	addToolResult(call toolCall, result string)
+}
+
+// runToolLoop runs the model's tool calls in ws and sends their results back,
+// until the model replies without calling a tool or maxSteps requests were made
+func runToolLoop(ctx context.Context, provider string, conv toolConversation, ws toolWorkspace, maxSteps int, ew *eventWriter) error {
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxToolSteps
+	}
+	for step := 0; step < maxSteps; step++ {
+		calls, err := conv.next(ctx, ew)
+		if err != nil {
+			return err
+		}
+		if len(calls) == 0 {
+			return nil
+		}
+		for _, call := range calls {
+			result := runToolCall(ctx, ws, call, ew)
+			if ctx.Err() != nil {
+				return fmt.Errorf("%s request cancelled: %w", provider, context.Cause(ctx))
+			}
+			conv.addToolResult(call, result)
+		}
+	}
+	return fmt.Errorf("%s stopped after %d steps without finishing", provider, maxSteps)
+}
+
+// runToolCall runs one tool call in ws, emitting the call and its result, and returns the result for the model
+func runToolCall(ctx context.Context, ws toolWorkspace, call toolCall, ew *eventWriter) string {
+	ew.emit(Event{Type: EventToolCall, ToolID: call.ID, ToolName: call.Name, Parameters: call.Arguments})
+
+	output, err := "", fmt.Errorf("unknown tool %q", call.Name)
+	tool := findAgentTool(call.Name)
+	switch {
+	case call.ArgumentsErr != nil:
+		err = fmt.Errorf("invalid arguments: %w", call.ArgumentsErr)
+	case tool == nil:
+	case ws.Dir == "":
+		// Tools are only offered with a working directory, but a model may call one anyway
+		err = fmt.Errorf("no working directory to run %s in", call.Name)
+	default:
+		output, err = tool.Run(ctx, ws, call.Arguments)
+	}
+	result := Event{Type: EventToolResult, ToolID: call.ID, ToolName: call.Name, Status: "success", Output: output}
+	if err != nil {
+		result.Status = "error"
+		result.Output = "Error: " + err.Error()
+	}
+	ew.emit(result)
+	return result.Output
+}
@@ -0,0 +1,341 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Limits keeping tool output within what a local model can take in
+const (
+	toolOutputLimit  = 16000 // Bytes of file content or command output returned to the model
+	searchMatchLimit = 100   // Matching lines returned by a search
+)
+
+// agentTool is a tool offered to a model over a chat API, implemented by Ludwig in the task's worktree
+type agentTool struct {
+	Name        string
+	Description string
+	Parameters  map[string]string // Parameter name to description; every parameter is a string
+	Required    []string
+	Run         func(ctx context.Context, ws toolWorkspace, args map[string]any) (string, error)
+}
+
+// toolWorkspace is where a model's tool calls run
+type toolWorkspace struct {
+	Dir       string   // The task's worktree
+	CommitEnv []string // Extra environment for git_commit, e.g. the configured commit identity
+}
+
+// agentTools are the tools a model can call to work on a task
+var agentTools = []agentTool{
+	{
+		Name:        "read_file",
+		Description: "Read a file in the working directory",
+		Parameters:  map[string]string{"path": "Path of the file, relative to the working directory"},
+		Required:    []string{"path"},
+		Run:         readFileTool,
+	},
+	{
+		Name:        "write_file",
+		Description: "Create or overwrite a file in the working directory with the given content",
+		Parameters: map[string]string{
+			"path":    "Path of the file, relative to the working directory",
+			"content": "The complete new content of the file",
+		},
+		Required: []string{"path", "content"},
+		Run:      writeFileTool,
+	},
+	{
+		Name:        "list_directory",
+		Description: "List the files and directories in a directory of the working directory",
+		Parameters:  map[string]string{"path": "Path of the directory, relative to the working directory (default: the working directory)"},
+		Run:         listDirectoryTool,
+	},
+	{
+		Name:        "search",
+		Description: "Search the files of the working directory for lines matching a regular expression",
+		Parameters: map[string]string{
+			"pattern": "Regular expression to search for",
+			"path":    "Directory or file to search, relative to the working directory (default: the working directory)",
+		},
+		Required: []string{"pattern"},
+		Run:      searchTool,
+	},
+	{
+		Name:        "run_command",
+		Description: "Run a shell command in the working directory and return its output, e.g. to build or test the project",
+		Parameters:  map[string]string{"command": "The shell command line to run"},
+		Required:    []string{"command"},
+		Run:         runCommandTool,
+	},
+	{
+		Name:        "git_commit",
+		Description: "Stage every change in the working directory and commit it",
+		Parameters:  map[string]string{"message": "The commit message"},
+		Required:    []string{"message"},
+		Run:         gitCommitTool,
+	},
+}
+
+// findAgentTool returns the tool with the given name, or nil
+func findAgentTool(name string) *agentTool {
+	for i := range agentTools {
+		if agentTools[i].Name == name {
+			return &agentTools[i]
+		}
+	}
+	return nil
+}
+
+// toolDefinitions describes the tools as function definitions, the format both Ollama and OpenAI-compatible servers expect
+func toolDefinitions() []map[string]any {
+	definitions := make([]map[string]any, 0, len(agentTools))
+	for _, tool := range agentTools {
+		properties := map[string]any{}
+		for name, description := range tool.Parameters {
+			properties[name] = map[string]any{"type": "string", "description": description}
+		}
+		required := tool.Required
+		if required == nil {
+			required = []string{}
+		}
+		definitions = append(definitions, map[string]any{
+			"type": "function",
+			"function": map[string]any{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters": map[string]any{
+					"type":       "object",
+					"properties": properties,
+					"required":   required,
+				},
+			},
+		})
+	}
+	return definitions
+}
+
+// stringArg returns a tool argument as a string
+func stringArg(args map[string]any, name string) string {
+	switch value := args[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// resolveToolPath resolves a path given by the model inside workDir, refusing paths that leave it.
+// Symlinks in the part of the path that exists are followed first, so a link cannot lead outside workDir.
+func resolveToolPath(workDir, path string) (string, error) {
+	if path == "" {
+		path = "."
+	}
+	full := path
+	if !filepath.IsAbs(full) {
+		full = filepath.Join(workDir, path)
+	}
+	full = filepath.Clean(full)
+
+	root, err := filepath.EvalSymlinks(workDir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the working directory: %w", err)
+	}
+	resolved, err := evalExistingSymlinks(full)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the working directory", path)
+	}
+	return full, nil
+}
+
+// evalExistingSymlinks resolves the symlinks in the deepest existing ancestor of path and appends the rest of path.
+// A broken symlink is an error, since writing through it would create its target wherever it points.
+func evalExistingSymlinks(path string) (string, error) {
+	rest := ""
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		if _, lstatErr := os.Lstat(path); lstatErr == nil {
+			return "", fmt.Errorf("%s is a broken symlink", path)
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(path), rest)
+		path = parent
+	}
+}
+
+// truncateOutput keeps the start of long output, noting how much was left out
+func truncateOutput(s string) string {
+	if len(s) <= toolOutputLimit {
+		return s
+	}
+	return s[:toolOutputLimit] + fmt.Sprintf("\n... (%d more bytes)", len(s)-toolOutputLimit)
+}
+
+func readFileTool(ctx context.Context, ws toolWorkspace, args map[string]any) (string, error) {
+	path, err := resolveToolPath(ws.Dir, stringArg(args, "path"))
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return truncateOutput(string(content)), nil
+}
+
+func writeFileTool(ctx context.Context, ws toolWorkspace, args map[string]any) (string, error) {
+	path, err := resolveToolPath(ws.Dir, stringArg(args, "path"))
+	if err != nil {
+		return "", err
+	}
+	content := stringArg(args, "content")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Wrote %d bytes to %s", len(content), stringArg(args, "path")), nil
+}
+
+func listDirectoryTool(ctx context.Context, ws toolWorkspace, args map[string]any) (string, error) {
+	path, err := resolveToolPath(ws.Dir, stringArg(args, "path"))
+	if err != nil {
+		return "", err
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return "", err
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() == ".git" {
+			continue
+		}
+		if entry.IsDir() {
+			names = append(names, entry.Name()+"/")
+		} else {
+			names = append(names, entry.Name())
+		}
+	}
+	if len(names) == 0 {
+		return "(empty directory)", nil
+	}
+	return strings.Join(names, "\n"), nil
+}
+
+func searchTool(ctx context.Context, ws toolWorkspace, args map[string]any) (string, error) {
+	pattern, err := regexp.Compile(stringArg(args, "pattern"))
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern: %w", err)
+	}
+	root, err := resolveToolPath(ws.Dir, stringArg(args, "path"))
+	if err != nil {
+		return "", err
+	}
+
+	var matches []string
+	err = filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.Type()&fs.ModeSymlink != 0 {
+			// A link may point outside the working directory
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil || strings.ContainsRune(string(content[:min(len(content), 512)]), 0) {
+			// Unreadable or binary
+			return nil
+		}
+		rel, _ := filepath.Rel(ws.Dir, path)
+		for i, line := range strings.Split(string(content), "\n") {
+			if pattern.MatchString(line) {
+				matches = append(matches, fmt.Sprintf("%s:%d: %s", filepath.ToSlash(rel), i+1, strings.TrimSpace(line)))
+				if len(matches) >= searchMatchLimit {
+					return fs.SkipAll
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "No matches", nil
+	}
+	sort.Strings(matches)
+	return truncateOutput(strings.Join(matches, "\n")), nil
+}
+
+func runCommandTool(ctx context.Context, ws toolWorkspace, args map[string]any) (string, error) {
+	command := stringArg(args, "command")
+	if strings.TrimSpace(command) == "" {
+		return "", fmt.Errorf("no command given")
+	}
+	output, err := NewShellCommand(ctx, ws.Dir, command).CombinedOutput()
+	result := truncateOutput(string(output))
+	if err != nil {
+		// A failing command is a normal result for the model to act on, not a tool error
+		return result + "\n(" + err.Error() + ")", nil
+	}
+	if result == "" {
+		return "(no output)", nil
+	}
+	return result, nil
+}
+
+func gitCommitTool(ctx context.Context, ws toolWorkspace, args map[string]any) (string, error) {
+	message := stringArg(args, "message")
+	if strings.TrimSpace(message) == "" {
+		return "", fmt.Errorf("no commit message given")
+	}
+	add := exec.CommandContext(ctx, "git", "add", "-A")
+	add.Dir = ws.Dir
+	if output, err := add.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git add failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	commit := exec.CommandContext(ctx, "git", "commit", "-q", "-m", message)
+	commit.Dir = ws.Dir
+	// Commit as Ludwig's configured identity, like the commits Ludwig makes itself
+	if ws.CommitEnv != nil {
+		commit.Env = append(os.Environ(), ws.CommitEnv...)
+	}
+	if output, err := commit.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("git commit failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return "Committed", nil
+}
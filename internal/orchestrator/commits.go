@@ -0,0 +1,114 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"ludwig/internal/config"
+	"ludwig/internal/types/task"
+)
+
+// TaskTrailer is the git trailer naming the task a commit was made for
+const TaskTrailer = "Ludwig-Task"
+
+// Limits keeping commit messages readable in git log
+const (
+	subjectLimit = 72
+	summaryLimit = 2000
+)
+
+// commitSummary trims the AI's final response into the body of a commit message.
+// Long responses are cut at a line boundary.
+func commitSummary(response string) string {
+	summary := strings.TrimSpace(response)
+	if len(summary) <= summaryLimit {
+		return summary
+	}
+	summary = summary[:summaryLimit]
+	if i := strings.LastIndex(summary, "\n"); i > 0 {
+		summary = summary[:i]
+	}
+	return strings.TrimSpace(summary) + "\n…"
+}
+
+// CommitMessage builds the message of a task's commit:
+// the task name as the subject, the AI's summary as the body and a Ludwig-Task trailer
+func CommitMessage(t *task.Task) string {
+	subject := strings.TrimSpace(strings.SplitN(t.Name, "\n", 2)[0])
+	if len(subject) > subjectLimit {
+		subject = strings.TrimSpace(subject[:subjectLimit-3]) + "..."
+	}
+	if subject == "" {
+		subject = "Task " + t.ID
+	}
+
+	var message strings.Builder
+	message.WriteString(subject + "\n\n")
+	if t.Summary != "" {
+		message.WriteString(t.Summary + "\n\n")
+	}
+	message.WriteString(TaskTrailer + ": " + t.ID)
+	return message.String()
+}
+
+// CommitTaskChanges stages and commits any uncommitted changes in a task's worktree with the task's commit message
+func CommitTaskChanges(cfg *config.Config, t *task.Task) error {
+	return commitAll(cfg, t.WorktreePath, CommitMessage(t))
+}
+
+// finishTaskCommits commits the remaining work of a finished task.
+// In squash mode the task's commits are then collapsed into one.
+func finishTaskCommits(cfg *config.Config, t *task.Task) error {
+	if err := CommitTaskChanges(cfg, t); err != nil {
+		return err
+	}
+	if !cfg.SquashCommits() {
+		return nil
+	}
+	return squashTaskCommits(cfg, t)
+}
+
+// squashTaskCommits replaces the commits on a task's branch since it left the branch it started from with a single commit.
+// A branch with merge commits (combined dependencies or a merged-in base branch) is left as it is,
+// since collapsing it would copy the merged work into the task's commit.
+func squashTaskCommits(cfg *config.Config, t *task.Task) error {
+	start := taskStartRef(t)
+	if start == "" {
+		base, err := defaultBaseBranch(getRepoRoot())
+		if err != nil {
+			return fmt.Errorf("failed to determine base branch: %w", err)
+		}
+		start = base
+	}
+
+	forkPoint, err := runGitIn(t.WorktreePath, "merge-base", start, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to find where %s left %s: %w", t.BranchName, start, err)
+	}
+	if count, _ := runGitIn(t.WorktreePath, "rev-list", "--count", forkPoint+"..HEAD"); count == "0" {
+		return nil
+	}
+	if merges, _ := runGitIn(t.WorktreePath, "rev-list", "--merges", forkPoint+"..HEAD"); merges != "" {
+		return nil
+	}
+
+	if output, err := runGitIn(t.WorktreePath, "reset", "--soft", forkPoint); err != nil {
+		return fmt.Errorf("failed to squash commits: %w: %s", err, output)
+	}
+	if output, err := gitCommitCommand(cfg, t.WorktreePath, "commit", "-q", "-m", CommitMessage(t)).CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to commit squashed changes: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// gitCommitCommand creates a git command in dir that commits as the identity configured in cfg, if any
+func gitCommitCommand(cfg *config.Config, dir string, args ...string) *exec.Cmd {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if env := cfg.CommitEnv(); env != nil {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	return cmd
+}
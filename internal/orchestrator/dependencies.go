@@ -0,0 +1,158 @@
+package orchestrator
+
+import (
+	"fmt"
+	"slices"
+
+	"ludwig/internal/config"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// dependencyStatuses looks up the status of every task the given tasks depend on.
+// Dependencies that no longer exist are left out, so they do not block anything.
+func dependencyStatuses(taskStore storage.TaskStorage, tasks []*task.Task) map[string]task.Status {
+	statuses := make(map[string]task.Status)
+	for _, t := range tasks {
+		for _, id := range t.DependsOn {
+			if _, seen := statuses[id]; seen {
+				continue
+			}
+			if dep, err := taskStore.GetTask(id); err == nil {
+				statuses[id] = dep.Status
+			}
+		}
+	}
+	return statuses
+}
+
+// dependencyBranches returns the branches of a task's completed dependencies, in DependsOn order
+func dependencyBranches(taskStore storage.TaskStorage, t *task.Task) []string {
+	var branches []string
+	for _, id := range t.DependsOn {
+		dep, err := taskStore.GetTask(id)
+		if err != nil || dep.Status != task.Completed || dep.BranchName == "" {
+			continue
+		}
+		if exists, _ := BranchExists(dep.BranchName); exists {
+			branches = append(branches, dep.BranchName)
+		}
+	}
+	return branches
+}
+
+// createTaskWorktree creates the worktree for a task that has not started yet.
+// A task with its own base branch starts from it, and the branches of its dependencies are merged in.
+// Otherwise a task with dependencies starts from its first dependency's branch (recorded as its StartRef),
+// and the branches of any further dependencies are merged in; other tasks start from the base branch.
+// The task is still merged into its base branch either way.
+func createTaskWorktree(taskStore storage.TaskStorage, cfg *config.Config, t *task.Task, branchName string) (string, error) {
+	branches := dependencyBranches(taskStore, t)
+	start := t.BaseBranch
+	if start == "" && len(branches) > 0 {
+		start, branches = branches[0], branches[1:]
+	}
+
+	worktreePath, err := CreateWorktreeFrom(branchName, t.ID, start)
+	if err != nil {
+		return "", err
+	}
+	if len(branches) > 0 {
+		if err := MergeIntoWorktree(cfg, worktreePath, branches...); err != nil {
+			// Start from scratch on the next attempt rather than from a half-merged branch
+			_ = PruneWorktree(worktreePath)
+			_ = DeleteBranch(branchName)
+			return "", fmt.Errorf("failed to combine dependency branches: %w", err)
+		}
+	}
+	if start != t.BaseBranch {
+		t.StartRef = start
+	}
+	return worktreePath, nil
+}
+
+// taskStartRef returns the branch a task's worktree was created from, so its own commits can be told
+// apart from its dependencies'. Falls back to the base branch (empty: the configured base branch)
+// if the task started from it or the dependency branch has since been deleted.
+func taskStartRef(t *task.Task) string {
+	if t.StartRef != "" {
+		if exists, _ := BranchExists(t.StartRef); exists {
+			return t.StartRef
+		}
+	}
+	return t.BaseBranch
+}
+
+// ValidateDependencies checks that a task may depend on the given tasks:
+// every dependency must exist and the new edges must not create a cycle.
+func ValidateDependencies(taskStore storage.TaskStorage, taskID string, dependsOn []string) error {
+	tasks, err := taskStore.ListTasks()
+	if err != nil {
+		return err
+	}
+	edges := make(map[string][]string, len(tasks)+1)
+	names := make(map[string]string, len(tasks))
+	for _, t := range tasks {
+		edges[t.ID] = t.DependsOn
+		names[t.ID] = t.Name
+	}
+	edges[taskID] = dependsOn
+
+	for _, id := range dependsOn {
+		if id == taskID {
+			return fmt.Errorf("a task cannot depend on itself")
+		}
+		if _, ok := names[id]; !ok {
+			return fmt.Errorf("dependency %s: %w", id, storage.ErrTaskNotFound)
+		}
+	}
+
+	// Depth-first search from each dependency; reaching taskID again means a cycle
+	visited := make(map[string]bool)
+	var reaches func(id string) bool
+	reaches = func(id string) bool {
+		if id == taskID {
+			return true
+		}
+		if visited[id] {
+			return false
+		}
+		visited[id] = true
+		for _, next := range edges[id] {
+			if reaches(next) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, id := range dependsOn {
+		if reaches(id) {
+			return fmt.Errorf("depending on %q would create a dependency cycle", names[id])
+		}
+	}
+	return nil
+}
+
+// AddDependencies makes a pending task wait for more tasks, after checking for cycles
+func AddDependencies(taskStore storage.TaskStorage, taskID string, dependsOn []string) error {
+	t, err := taskStore.GetTask(taskID)
+	if err != nil {
+		return err
+	}
+	if t.Status != task.Pending {
+		return fmt.Errorf("only pending tasks can get new dependencies (task is %s)", task.StatusString(*t))
+	}
+
+	combined := append([]string{}, t.DependsOn...)
+	for _, id := range dependsOn {
+		if !slices.Contains(combined, id) {
+			combined = append(combined, id)
+		}
+	}
+	if err := ValidateDependencies(taskStore, taskID, combined); err != nil {
+		return err
+	}
+	return modifyTask(taskStore, taskID, func(stored *task.Task) {
+		stored.DependsOn = combined
+	})
+}
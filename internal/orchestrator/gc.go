@@ -0,0 +1,292 @@
+package orchestrator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// Kinds of things garbage collection removes
+const (
+	GCBranch   = "branch"
+	GCWorktree = "worktree"
+	GCMetadata = "metadata" // git's bookkeeping for a worktree whose directory is gone
+	GCResponse = "response"
+)
+
+// GCItem is a branch, worktree or response file found by garbage collection
+type GCItem struct {
+	Kind   string // GCBranch, GCWorktree, GCMetadata or GCResponse
+	Name   string // Branch name, worktree directory or response file (relative to .ludwig)
+	Reason string // Why it is removed, e.g. "merged 12 days ago"
+	TaskID string // Task it belonged to, if the task still exists
+	Err    string // Why removing it failed
+}
+
+// GCReport lists what garbage collection found, and whether it was removed
+type GCReport struct {
+	Items  []GCItem
+	Pruned bool // False for a dry run, which only reports
+}
+
+// String describes the report, one item per line
+func (r *GCReport) String() string {
+	if len(r.Items) == 0 {
+		return "Nothing to clean up."
+	}
+
+	counts := map[string]int{}
+	failed := 0
+	for _, item := range r.Items {
+		counts[item.Kind]++
+		if item.Err != "" {
+			failed++
+		}
+	}
+	var parts []string
+	for _, kind := range []string{GCBranch, GCWorktree, GCMetadata, GCResponse} {
+		if counts[kind] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s(s)", counts[kind], gcKindLabel(kind)))
+		}
+	}
+
+	var s strings.Builder
+	if r.Pruned {
+		s.WriteString("Removed " + strings.Join(parts, ", ") + ":\n")
+	} else {
+		s.WriteString("Would remove " + strings.Join(parts, ", ") + ":\n")
+	}
+	for _, item := range r.Items {
+		line := fmt.Sprintf("  %-9s %s - %s", item.Kind, item.Name, item.Reason)
+		if item.TaskID != "" {
+			line += " (task " + item.TaskID + ")"
+		}
+		if item.Err != "" {
+			line += " - failed: " + item.Err
+		}
+		s.WriteString(line + "\n")
+	}
+	if failed > 0 {
+		s.WriteString(fmt.Sprintf("%d item(s) could not be removed.\n", failed))
+	}
+	if !r.Pruned {
+		s.WriteString("Run 'gc --prune' to remove them.\n")
+	}
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// gcKindLabel names a kind of item in the report's summary
+func gcKindLabel(kind string) string {
+	switch kind {
+	case GCMetadata:
+		return "stale worktree record"
+	case GCResponse:
+		return "response file"
+	}
+	return kind
+}
+
+// CollectGarbage finds the Ludwig branches, worktrees and response files that are no longer needed,
+// cross-referenced against the stored tasks, and removes them if prune is true:
+// - Worktrees no task refers to, or left behind by a Completed or Archived task
+// - git worktree records whose directory no longer exists
+// - ludwig/ branches no task refers to, or of tasks merged more than gc.mergedBranchDays ago
+// - Branches of finished tasks without commits for gc.maxBranchAgeDays
+// - Response files of deleted tasks, which are archived (or deleted with gc.deleteResponses)
+// Branches and worktrees of tasks that are pending, running, in review or awaiting approval are never touched.
+// Pruning is refused while the orchestrator runs, since a task being set up briefly has a worktree no task refers to yet.
+func CollectGarbage(taskStore storage.TaskStorage, cfg *config.Config, prune bool) (*GCReport, error) {
+	if prune && IsRunning() {
+		return nil, fmt.Errorf("stop the orchestrator before pruning")
+	}
+	tasks, err := taskStore.ListTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	policy := cfg.GCPolicy()
+
+	byBranch := make(map[string]*task.Task)
+	byWorktree := make(map[string]*task.Task)
+	responses := make(map[string]bool)
+	for _, t := range tasks {
+		if t.BranchName != "" {
+			byBranch[t.BranchName] = t
+		}
+		if t.WorktreePath != "" {
+			byWorktree[filepath.Clean(t.WorktreePath)] = t
+		}
+		responses[t.ID] = true
+	}
+
+	report := &GCReport{Pruned: prune}
+	worktrees, err := staleWorktrees(byWorktree)
+	if err != nil {
+		return nil, err
+	}
+	report.Items = append(report.Items, worktrees...)
+	report.Items = append(report.Items, staleWorktreeRecords()...)
+	branches, err := staleBranches(byBranch, policy)
+	if err != nil {
+		return nil, err
+	}
+	report.Items = append(report.Items, branches...)
+	files, err := orphanedResponses(responses)
+	if err != nil {
+		return nil, err
+	}
+	report.Items = append(report.Items, files...)
+
+	if prune {
+		// Worktrees go first, so the branches checked out in them can be deleted
+		for i := range report.Items {
+			if err := removeGCItem(taskStore, &report.Items[i], policy); err != nil {
+				report.Items[i].Err = err.Error()
+			}
+		}
+	}
+	return report, nil
+}
+
+// finishedForGC reports whether a task's branch and worktree may be garbage collected
+func finishedForGC(t *task.Task) bool {
+	switch t.Status {
+	case task.Completed, task.Archived:
+		return true
+	case task.Failed:
+		// A failed task's kept worktree still has its branch checked out for a retry
+		return t.WorktreePath == "" || !WorktreeExists(t.WorktreePath)
+	}
+	return false
+}
+
+// staleWorktrees lists .worktrees directories that no task refers to, or that a Completed or Archived task left behind
+func staleWorktrees(byWorktree map[string]*task.Task) ([]GCItem, error) {
+	dirs, err := ListWorktreeDirs()
+	if err != nil {
+		return nil, err
+	}
+	var items []GCItem
+	for _, dir := range dirs {
+		owner := byWorktree[filepath.Clean(dir)]
+		switch {
+		case owner == nil:
+			items = append(items, GCItem{Kind: GCWorktree, Name: dir, Reason: "no task refers to it"})
+		case owner.Status == task.Completed || owner.Status == task.Archived:
+			// Failed tasks keep their worktree so a retry continues where it stopped
+			items = append(items, GCItem{Kind: GCWorktree, Name: dir, Reason: "left behind by a " + strings.ToLower(task.StatusString(*owner)) + " task", TaskID: owner.ID})
+		}
+	}
+	return items, nil
+}
+
+// staleWorktreeRecords lists the worktrees git still records although their directory is gone
+func staleWorktreeRecords() []GCItem {
+	output, err := runGitIn(getRepoRoot(), "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil
+	}
+	var items []GCItem
+	path := ""
+	for _, line := range strings.Split(output, "\n") {
+		if strings.HasPrefix(line, "worktree ") {
+			path = strings.TrimPrefix(line, "worktree ")
+		} else if strings.HasPrefix(line, "prunable") {
+			items = append(items, GCItem{Kind: GCMetadata, Name: path, Reason: "directory no longer exists"})
+		}
+	}
+	return items
+}
+
+// staleBranches lists the ludwig/ branches the retention rules no longer keep
+func staleBranches(byBranch map[string]*task.Task, policy config.GCPolicy) ([]GCItem, error) {
+	repoRoot := getRepoRoot()
+	output, err := runGitIn(repoRoot, "for-each-ref", "--format=%(refname:short)%09%(committerdate:unix)", "refs/heads/ludwig/")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w: %s", err, output)
+	}
+	current, _ := getCurrentBranch(repoRoot)
+
+	var items []GCItem
+	for _, line := range strings.Split(output, "\n") {
+		name, committed, found := strings.Cut(line, "\t")
+		if !found || name == current {
+			continue
+		}
+		lastCommit := time.Now()
+		if seconds, err := strconv.ParseInt(committed, 10, 64); err == nil {
+			lastCommit = time.Unix(seconds, 0)
+		}
+
+		owner := byBranch[name]
+		switch {
+		case owner == nil:
+			if !policy.KeepOrphanedBranches {
+				items = append(items, GCItem{Kind: GCBranch, Name: name, Reason: "no task refers to it"})
+			}
+		case !finishedForGC(owner):
+			continue
+		case !owner.MergedAt.IsZero() && daysSince(owner.MergedAt) >= policy.MergedBranchDays:
+			items = append(items, GCItem{Kind: GCBranch, Name: name, Reason: fmt.Sprintf("merged %d day(s) ago", daysSince(owner.MergedAt)), TaskID: owner.ID})
+		case policy.MaxBranchAgeDays > 0 && daysSince(lastCommit) >= policy.MaxBranchAgeDays:
+			items = append(items, GCItem{Kind: GCBranch, Name: name, Reason: fmt.Sprintf("no commits for %d day(s)", daysSince(lastCommit)), TaskID: owner.ID})
+		}
+	}
+	return items, nil
+}
+
+// orphanedResponses lists response files whose task no longer exists
+func orphanedResponses(taskIDs map[string]bool) ([]GCItem, error) {
+	files, err := storage.ListResponseFiles()
+	if err != nil {
+		return nil, err
+	}
+	var items []GCItem
+	for _, file := range files {
+		if !taskIDs[storage.ResponseTaskID(file)] {
+			items = append(items, GCItem{Kind: GCResponse, Name: file, Reason: "task was deleted"})
+		}
+	}
+	return items, nil
+}
+
+// removeGCItem removes one item of a garbage collection report
+func removeGCItem(taskStore storage.TaskStorage, item *GCItem, policy config.GCPolicy) error {
+	switch item.Kind {
+	case GCWorktree:
+		if err := PruneWorktree(item.Name); err != nil {
+			return err
+		}
+		if item.TaskID == "" {
+			return nil
+		}
+		return modifyTask(taskStore, item.TaskID, func(stored *task.Task) {
+			stored.WorktreePath = ""
+		})
+	case GCMetadata:
+		if output, err := runGitIn(getRepoRoot(), "worktree", "prune"); err != nil {
+			return fmt.Errorf("%w: %s", err, output)
+		}
+		return nil
+	case GCBranch:
+		return DeleteBranch(item.Name)
+	case GCResponse:
+		if policy.DeleteResponses {
+			item.Reason += ", deleted"
+			return storage.DeleteResponse(item.Name)
+		}
+		item.Reason += ", archived"
+		return storage.ArchiveResponse(item.Name)
+	}
+	return fmt.Errorf("unknown item kind %q", item.Kind)
+}
+
+// daysSince returns the number of whole days since t
+func daysSince(t time.Time) int {
+	return int(time.Since(t).Hours() / 24)
+}
@@ -1,17 +1,35 @@
 package orchestrator
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+
+	"ludwig/internal/config"
+	"ludwig/internal/utils"
+)
+
+// Errors returned by FindRepoRoot when Ludwig is not run from a usable repository
+var (
+	ErrNotRepository  = utils.ErrNotRepository
+	ErrBareRepository = utils.ErrBareRepository
 )
 
-// CreateWorktree creates a new git worktree for a given branch
+// CreateWorktree creates a new git worktree for a given branch, starting from the base branch
 // Returns the path to the worktree directory
 func CreateWorktree(branchName, taskID string) (string, error) {
+	return CreateWorktreeFrom(branchName, taskID, "")
+}
+
+// CreateWorktreeFrom creates a new git worktree for a given branch, starting from baseRef
+// An empty baseRef starts from the base branch (see defaultBaseBranch)
+// Returns the path to the worktree directory
+func CreateWorktreeFrom(branchName, taskID, baseRef string) (string, error) {
 	repoRoot := getRepoRoot()
 	worktreeDir := filepath.Join(repoRoot, ".worktrees", taskID)
 	
@@ -19,21 +37,22 @@ func CreateWorktree(branchName, taskID string) (string, error) {
 	if err := os.MkdirAll(filepath.Join(repoRoot, ".worktrees"), 0755); err != nil {
 		return "", fmt.Errorf("failed to create .worktrees directory: %w", err)
 	}
-	
-	// Try to create worktree based on "main" branch first
-	cmd := exec.Command("git", "worktree", "add", "-b", branchName, worktreeDir, "main")
-	cmd.Dir = repoRoot
-	if err := cmd.Run(); err == nil {
+
+	if baseRef != "" {
+		cmd := exec.Command("git", "worktree", "add", "-b", branchName, worktreeDir, baseRef)
+		cmd.Dir = repoRoot
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return "", fmt.Errorf("failed to create worktree from %s: %w: %s", baseRef, err, strings.TrimSpace(string(output)))
+		}
 		return worktreeDir, nil
 	}
 	
-	// If "main" doesn't exist, try the current branch as fallback
-	currentBranch, err := getCurrentBranch(repoRoot)
+	baseBranch, err := defaultBaseBranch(repoRoot)
 	if err != nil {
-		return "", fmt.Errorf("failed to create worktree: main branch not found and unable to determine current branch")
+		return "", fmt.Errorf("failed to create worktree: %w", err)
 	}
 	
-	cmd = exec.Command("git", "worktree", "add", "-b", branchName, worktreeDir, currentBranch)
+	cmd := exec.Command("git", "worktree", "add", "-b", branchName, worktreeDir, baseBranch)
 	cmd.Dir = repoRoot
 	if err := cmd.Run(); err != nil {
 		return "", fmt.Errorf("failed to create worktree: %w", err)
@@ -42,6 +61,42 @@ func CreateWorktree(branchName, taskID string) (string, error) {
 	return worktreeDir, nil
 }
 
+// defaultBaseBranch returns the branch task worktrees start from: baseBranch from the config,
+// otherwise main, or the current branch if main doesn't exist
+func defaultBaseBranch(repoRoot string) (string, error) {
+	cfg, _ := config.LoadConfig()
+	if cfg != nil && cfg.BaseBranch != "" {
+		if !localBranchExists(repoRoot, cfg.BaseBranch) {
+			return "", fmt.Errorf("baseBranch %q from .ludwig/config.json does not exist", cfg.BaseBranch)
+		}
+		return cfg.BaseBranch, nil
+	}
+	if localBranchExists(repoRoot, "main") {
+		return "main", nil
+	}
+
+	current, err := getCurrentBranch(repoRoot)
+	if err != nil {
+		return "", fmt.Errorf("the repository has no commits yet; make a first commit to start tasks from")
+	}
+	if current == "HEAD" {
+		return "", fmt.Errorf("there is no main branch and HEAD is detached; set baseBranch in .ludwig/config.json")
+	}
+	return current, nil
+}
+
+// localBranchExists reports whether refs/heads/<branch> exists
+func localBranchExists(repoRoot, branch string) bool {
+	cmd := exec.Command("git", "rev-parse", "--verify", "--quiet", "refs/heads/"+branch)
+	cmd.Dir = repoRoot
+	return cmd.Run() == nil
+}
+
+// BaseBranch returns the branch new tasks start from and are merged into
+func BaseBranch() (string, error) {
+	return defaultBaseBranch(getRepoRoot())
+}
+
 // getCurrentBranch returns the current branch name or HEAD ref
 func getCurrentBranch(repoRoot string) (string, error) {
 	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
@@ -72,10 +127,187 @@ func RemoveWorktree(worktreePath string) error {
 	return nil
 }
 
-// CommitAnyChanges stages and commits any uncommitted changes in the worktree
-// This ensures that AI work is preserved even if the AI didn't explicitly commit
-// Uses the task ID to create a descriptive commit message
-func CommitAnyChanges(worktreePath string, taskID string) error {
+// MergeIntoWorktree merges branches into the branch checked out in a worktree
+// If a merge fails (e.g. on a conflict) it is aborted and the worktree is left as it was before that merge
+func MergeIntoWorktree(cfg *config.Config, worktreePath string, branches ...string) error {
+	for _, branch := range branches {
+		cmd := gitCommitCommand(cfg, worktreePath, "merge", "--no-edit", branch)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			abort := exec.Command("git", "merge", "--abort")
+			abort.Dir = worktreePath
+			_ = abort.Run()
+			return fmt.Errorf("failed to merge %s: %w: %s", branch, err, strings.TrimSpace(string(output)))
+		}
+	}
+	return nil
+}
+
+// BranchDiff describes the changes made on a task branch since it left its base branch
+type BranchDiff struct {
+	Base    string         // Branch the diff is taken against
+	Stat    string         // Files changed with insertion and deletion counts (git diff --stat)
+	Patch   string         // Full unified diff
+	Commits []BranchCommit // Commits on the branch that are not on the base branch, newest first
+}
+
+// BranchCommit is one commit of a task branch
+type BranchCommit struct {
+	Hash    string // Abbreviated commit hash
+	Author  string
+	Subject string
+}
+
+// DiffBranch returns the changes on branchName since it forked from baseRef.
+// An empty baseRef compares against the base branch.
+func DiffBranch(branchName, baseRef string) (*BranchDiff, error) {
+	repoRoot := getRepoRoot()
+	if baseRef == "" {
+		base, err := defaultBaseBranch(repoRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine base branch: %w", err)
+		}
+		baseRef = base
+	}
+
+	// Three dots compare against the merge base, so later commits on the base branch are not shown as removed
+	diffRange := baseRef + "..." + branchName
+	diff := &BranchDiff{Base: baseRef}
+	for _, out := range []struct {
+		target *string
+		args   []string
+	}{
+		{&diff.Stat, []string{"diff", "--stat", diffRange}},
+		{&diff.Patch, []string{"diff", diffRange}},
+	} {
+		cmd := exec.Command("git", out.args...)
+		cmd.Dir = repoRoot
+		output, err := cmd.CombinedOutput()
+		if err != nil {
+			return nil, fmt.Errorf("failed to diff %s: %w: %s", diffRange, err, strings.TrimSpace(string(output)))
+		}
+		*out.target = string(output)
+	}
+
+	commits, err := branchCommits(repoRoot, baseRef+".."+branchName)
+	if err != nil {
+		return nil, err
+	}
+	diff.Commits = commits
+	return diff, nil
+}
+
+// branchCommits lists the commits in a revision range, newest first
+func branchCommits(repoRoot, revRange string) ([]BranchCommit, error) {
+	// Fields are separated by the unit separator, which does not appear in names or subjects
+	cmd := exec.Command("git", "log", "--format=%h%x1f%an%x1f%s", revRange)
+	cmd.Dir = repoRoot
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list commits in %s: %w: %s", revRange, err, strings.TrimSpace(string(output)))
+	}
+
+	var commits []BranchCommit
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.SplitN(line, "\x1f", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		commits = append(commits, BranchCommit{Hash: fields[0], Author: fields[1], Subject: fields[2]})
+	}
+	return commits, nil
+}
+
+// DeleteBranch force-deletes a local branch
+func DeleteBranch(branchName string) error {
+	cmd := exec.Command("git", "branch", "-D", branchName)
+	cmd.Dir = getRepoRoot()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to delete branch %s: %w: %s", branchName, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// RestoreWorktree re-creates the worktree for a task whose branch still exists but whose directory was lost
+// Returns the path to the worktree directory
+func RestoreWorktree(branchName, taskID string) (string, error) {
+	repoRoot := getRepoRoot()
+	worktreeDir := filepath.Join(repoRoot, ".worktrees", taskID)
+
+	// Drop git's bookkeeping for worktree directories that no longer exist
+	pruneCmd := exec.Command("git", "worktree", "prune")
+	pruneCmd.Dir = repoRoot
+	_ = pruneCmd.Run()
+
+	cmd := exec.Command("git", "worktree", "add", worktreeDir, branchName)
+	cmd.Dir = repoRoot
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to restore worktree: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return worktreeDir, nil
+}
+
+// PruneWorktree removes a worktree directory that no task refers to
+// Falls back to deleting the directory if git no longer tracks it as a worktree
+func PruneWorktree(worktreePath string) error {
+	if err := RemoveWorktree(worktreePath); err != nil {
+		if err := os.RemoveAll(worktreePath); err != nil {
+			return fmt.Errorf("failed to delete worktree directory: %w", err)
+		}
+	}
+	cmd := exec.Command("git", "worktree", "prune")
+	cmd.Dir = getRepoRoot()
+	_ = cmd.Run()
+	return nil
+}
+
+// ListWorktreeDirs returns the paths of every directory under .worktrees
+func ListWorktreeDirs() ([]string, error) {
+	worktreesDir := filepath.Join(getRepoRoot(), ".worktrees")
+	entries, err := os.ReadDir(worktreesDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .worktrees directory: %w", err)
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(worktreesDir, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// BranchCommitCount returns how many commits the worktree's branch has that no other
+// (non-ludwig) local branch contains, i.e. the work done for the task so far
+func BranchCommitCount(worktreePath string) (int, error) {
+	cmd := exec.Command("git", "rev-list", "--count", "HEAD", "--not", "--exclude=ludwig/*", "--branches")
+	cmd.Dir = worktreePath
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count branch commits: %w", err)
+	}
+	return strconv.Atoi(strings.TrimSpace(string(output)))
+}
+
+// WorktreeExists reports whether a task's worktree directory is still present on disk
+func WorktreeExists(worktreePath string) bool {
+	info, err := os.Stat(worktreePath)
+	return err == nil && info.IsDir()
+}
+
+// CommitPartialChanges commits whatever the AI had changed when its run was interrupted
+// The reason (e.g. "task cancelled by user") is recorded in the commit message
+func CommitPartialChanges(cfg *config.Config, worktreePath string, taskID string, reason string) error {
+	commitMsg := fmt.Sprintf("Task interrupted: %s\n\nAuto-committed partial changes (%s) to preserve work.", taskID, reason)
+	return commitAll(cfg, worktreePath, commitMsg)
+}
+
+// commitAll stages and commits every change in the worktree with the given message
+// Does nothing if the worktree is clean
+func commitAll(cfg *config.Config, worktreePath string, commitMsg string) error {
 	// Check if there are any changes
 	cmd := exec.Command("git", "status", "--porcelain")
 	cmd.Dir = worktreePath
@@ -97,9 +329,7 @@ func CommitAnyChanges(worktreePath string, taskID string) error {
 	}
 	
 	// Commit the changes
-	commitMsg := fmt.Sprintf("Task completed: %s\n\nAuto-committed any uncommitted changes to preserve work.", taskID)
-	commitCmd := exec.Command("git", "commit", "-m", commitMsg)
-	commitCmd.Dir = worktreePath
+	commitCmd := gitCommitCommand(cfg, worktreePath, "commit", "-m", commitMsg)
 	if err := commitCmd.Run(); err != nil {
 		// Commit might fail if there are no staged changes after add, which is fine
 		return nil
@@ -212,13 +442,23 @@ func toKebabCase(s string) string {
 	return s
 }
 
-// getRepoRoot returns the root directory of the repository
+// FindRepoRoot returns the top-level directory of the git working tree containing dir.
+// Task worktrees are created from a working tree, so a bare repository is an error.
+func FindRepoRoot(dir string) (string, error) {
+	return utils.FindRepoRoot(dir)
+}
+
+// RepoRoot returns the top-level directory of the git working tree containing the current directory
+func RepoRoot() (string, error) {
+	return utils.RepoRoot()
+}
+
+// getRepoRoot returns the root directory of the repository,
+// or the current directory if it is not inside one (git then reports the error)
 func getRepoRoot() string {
-	// For now, assume current working directory is within the repo
-	// In production, you might want to use git to find the root
-	cwd, err := os.Getwd()
+	dir, err := utils.ProjectDir()
 	if err != nil {
 		return "."
 	}
-	return cwd
+	return dir
 }
@@ -0,0 +1,303 @@
+package orchestrator
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// MergeConflictError is returned when a task branch and its base branch change the same lines
+type MergeConflictError struct {
+	Branch string   // The task branch
+	Onto   string   // The branch it was combined with
+	Files  []string // Files with conflicting changes
+}
+
+func (e *MergeConflictError) Error() string {
+	return fmt.Sprintf("%s conflicts with %s in %s", e.Branch, e.Onto, strings.Join(e.Files, ", "))
+}
+
+// mergeTarget returns the branch a task's work is merged into.
+// Tasks started from another task's branch are still merged into their base branch.
+func mergeTarget(t *task.Task) (string, error) {
+	if t.BaseBranch != "" {
+		return t.BaseBranch, nil
+	}
+	base, err := defaultBaseBranch(getRepoRoot())
+	if err != nil {
+		return "", fmt.Errorf("failed to determine base branch: %w", err)
+	}
+	return base, nil
+}
+
+// finishedTask loads a task and checks that its branch can be merged or rebased
+func finishedTask(taskStore storage.TaskStorage, taskID string, statuses ...task.Status) (*task.Task, error) {
+	t, err := taskStore.GetTask(taskID)
+	if err != nil {
+		return nil, err
+	}
+	if t.BranchName == "" {
+		return nil, fmt.Errorf("task has no branch yet")
+	}
+	for _, status := range statuses {
+		if t.Status == status {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("task is %s", task.StatusString(*t))
+}
+
+// MergeTask brings a Completed task's branch into its base branch with the given strategy
+// ("ff" or "squash"; empty uses the configured strategy) and returns the base branch.
+// The branch is kept; the task is marked as merged.
+func MergeTask(taskStore storage.TaskStorage, taskID string, strategy string) (string, error) {
+	t, err := finishedTask(taskStore, taskID, task.Completed)
+	if err != nil {
+		return "", err
+	}
+	target, err := mergeTarget(t)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+	if strategy == "" {
+		strategy = cfg.MergeStrategy()
+	}
+
+	repoRoot := getRepoRoot()
+	switch strategy {
+	case config.MergeFastForward:
+		if !isAncestor(repoRoot, target, t.BranchName) {
+			return "", fmt.Errorf("%s has new commits since the task started; rebase the task first or merge with --strategy=squash", target)
+		}
+		err = advanceBranch(repoRoot, target, t.BranchName)
+	case config.MergeSquash:
+		err = squashOnto(cfg, repoRoot, t, target)
+	default:
+		return "", fmt.Errorf("unknown merge strategy %q (use %q or %q)", strategy, config.MergeFastForward, config.MergeSquash)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return target, modifyTask(taskStore, taskID, func(stored *task.Task) {
+		stored.MergedAt = time.Now()
+	})
+}
+
+// squashOnto commits the whole of a task branch as one commit on top of target.
+// The commit is made in a temporary worktree, so the user's checkout is only touched to fast-forward it.
+func squashOnto(cfg *config.Config, repoRoot string, t *task.Task, target string) error {
+	tempDir := filepath.Join(repoRoot, ".worktrees", "merge-"+t.ID)
+	if output, err := runGitIn(repoRoot, "worktree", "add", "--detach", tempDir, target); err != nil {
+		return fmt.Errorf("failed to check out %s: %w: %s", target, err, output)
+	}
+	defer PruneWorktree(tempDir)
+
+	if _, err := runGitIn(tempDir, "merge", "--squash", t.BranchName); err != nil {
+		if files := conflictedFiles(tempDir); len(files) > 0 {
+			return &MergeConflictError{Branch: t.BranchName, Onto: target, Files: files}
+		}
+		return fmt.Errorf("failed to squash %s: %w", t.BranchName, err)
+	}
+	if output, _ := runGitIn(tempDir, "status", "--porcelain"); output == "" {
+		return fmt.Errorf("%s has no changes that are not already on %s", t.BranchName, target)
+	}
+
+	message := fmt.Sprintf("%s\n\nSquashed from %s.", t.Name, t.BranchName)
+	if output, err := runGitCommitIn(cfg, tempDir, "commit", "-q", "-m", message); err != nil {
+		return fmt.Errorf("failed to commit squashed changes: %w: %s", err, output)
+	}
+	head, err := runGitIn(tempDir, "rev-parse", "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to read squashed commit: %w", err)
+	}
+	return advanceBranch(repoRoot, target, head)
+}
+
+// RebaseTask replays a finished task's commits on top of the current base branch and returns the base branch.
+// The rebase runs in the task's worktree, or in a temporary one if the worktree was already removed.
+// On a conflict the rebase is aborted, leaving the branch as it was.
+func RebaseTask(taskStore storage.TaskStorage, taskID string) (string, error) {
+	t, err := finishedTask(taskStore, taskID, task.Completed, task.AwaitingApproval)
+	if err != nil {
+		return "", err
+	}
+	target, err := mergeTarget(t)
+	if err != nil {
+		return "", err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return "", err
+	}
+
+	worktreePath, temporary, err := branchWorktree(t)
+	if err != nil {
+		return "", err
+	}
+	if temporary {
+		defer PruneWorktree(worktreePath)
+	}
+
+	_ = commitAll(cfg, worktreePath, CommitMessage(t))
+	if err := rebaseWorktree(cfg, worktreePath, t.BranchName, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// rebaseWorktree rebases the branch checked out in a worktree onto target.
+// On a conflict the rebase is aborted and a *MergeConflictError names the conflicting files.
+func rebaseWorktree(cfg *config.Config, worktreePath, branch, target string) error {
+	output, err := runGitCommitIn(cfg, worktreePath, "rebase", target)
+	if err == nil {
+		return nil
+	}
+	files := conflictedFiles(worktreePath)
+	_, _ = runGitIn(worktreePath, "rebase", "--abort")
+	if len(files) > 0 {
+		return &MergeConflictError{Branch: branch, Onto: target, Files: files}
+	}
+	return fmt.Errorf("failed to rebase onto %s: %w: %s", target, err, output)
+}
+
+// ResolveConflicts hands a finished task's conflicts with its base branch back to the AI.
+// The base branch is merged into the task branch in its worktree, leaving the conflict markers,
+// and the task resumes with a prompt asking the AI to resolve them and commit the merge.
+// Returns false if the merge had no conflicts, in which case it is committed and the task is unchanged.
+func ResolveConflicts(taskStore storage.TaskStorage, taskID string) (bool, error) {
+	t, err := finishedTask(taskStore, taskID, task.Completed, task.AwaitingApproval)
+	if err != nil {
+		return false, err
+	}
+	target, err := mergeTarget(t)
+	if err != nil {
+		return false, err
+	}
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return false, err
+	}
+
+	worktreePath, restored, err := branchWorktree(t)
+	if err != nil {
+		return false, err
+	}
+	_ = commitAll(cfg, worktreePath, CommitMessage(t))
+	files, err := mergeLeavingConflicts(cfg, worktreePath, target)
+	if len(files) == 0 {
+		if restored {
+			_ = PruneWorktree(worktreePath)
+		}
+		return false, err
+	}
+	return true, handConflictsToAgent(taskStore, taskID, worktreePath, target, files)
+}
+
+// mergeLeavingConflicts merges target into the branch checked out in a worktree.
+// A conflicting merge is left in progress with its conflict markers, and the conflicting files are returned.
+func mergeLeavingConflicts(cfg *config.Config, worktreePath, target string) ([]string, error) {
+	output, err := runGitCommitIn(cfg, worktreePath, "merge", "--no-edit", target)
+	if files := conflictedFiles(worktreePath); len(files) > 0 {
+		return files, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge %s: %w: %s", target, err, output)
+	}
+	return nil, nil
+}
+
+// handConflictsToAgent resumes a task whose worktree holds a conflicting merge of target,
+// with a prompt asking the AI to resolve the conflicts and commit the merge
+func handConflictsToAgent(taskStore storage.TaskStorage, taskID, worktreePath, target string, files []string) error {
+	question := task.ReviewQuestion{
+		ID:   "q1",
+		Kind: task.FreeText,
+		Text: fmt.Sprintf("Merging %s into the task branch stopped on conflicts. How should they be resolved?", target),
+	}
+	answer := fmt.Sprintf("Resolve the conflicts in %s, keeping the intent of both sides. Remove every conflict marker, then commit the merge.", strings.Join(files, ", "))
+	now := time.Now()
+	return modifyTask(taskStore, taskID, func(stored *task.Task) {
+		stored.Status = task.NeedsReview
+		stored.WorktreePath = worktreePath
+		stored.MergedAt = time.Time{}
+		stored.Review = &task.ReviewRequest{
+			Question:  question.Text,
+			CreatedAt: now,
+			Questions: []task.ReviewQuestion{question},
+			Manual:    true,
+		}
+		stored.ReviewResponse = &task.ReviewResponse{
+			Answers:     []task.ReviewAnswer{{QuestionID: question.ID, Text: answer}},
+			RespondedAt: now,
+		}
+	})
+}
+
+// branchWorktree returns a worktree with the task's branch checked out.
+// The task's own worktree is used if it still exists; otherwise it is restored, and temporary is true.
+func branchWorktree(t *task.Task) (path string, temporary bool, err error) {
+	if t.WorktreePath != "" && WorktreeExists(t.WorktreePath) {
+		return t.WorktreePath, false, nil
+	}
+	path, err = RestoreWorktree(t.BranchName, t.ID)
+	if err != nil {
+		return "", false, err
+	}
+	return path, true, nil
+}
+
+// advanceBranch fast-forwards branch to commit.
+// A branch checked out in the repository is advanced with git merge so the working tree follows it.
+func advanceBranch(repoRoot, branch, commit string) error {
+	if current, _ := getCurrentBranch(repoRoot); current == branch {
+		if output, err := runGitIn(repoRoot, "merge", "--ff-only", commit); err != nil {
+			return fmt.Errorf("failed to fast-forward %s: %w: %s", branch, err, output)
+		}
+		return nil
+	}
+	// Fetching from the repository itself refuses anything but a fast-forward
+	if output, err := runGitIn(repoRoot, "fetch", "-q", ".", commit+":refs/heads/"+branch); err != nil {
+		return fmt.Errorf("failed to fast-forward %s: %w: %s", branch, err, output)
+	}
+	return nil
+}
+
+// isAncestor reports whether ancestor is contained in the history of descendant
+func isAncestor(repoRoot, ancestor, descendant string) bool {
+	_, err := runGitIn(repoRoot, "merge-base", "--is-ancestor", ancestor, descendant)
+	return err == nil
+}
+
+// conflictedFiles lists the files with unresolved conflicts in a worktree
+func conflictedFiles(worktreePath string) []string {
+	output, err := runGitIn(worktreePath, "diff", "--name-only", "--diff-filter=U")
+	if err != nil || output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// runGitIn runs a git command in dir and returns its trimmed combined output
+func runGitIn(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
+
+// runGitCommitIn runs a git command that creates commits in dir as the identity configured in cfg, returning its trimmed combined output
+func runGitCommitIn(cfg *config.Config, dir string, args ...string) (string, error) {
+	output, err := gitCommitCommand(cfg, dir, args...).CombinedOutput()
+	return strings.TrimSpace(string(output)), err
+}
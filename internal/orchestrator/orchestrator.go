@@ -1,6 +1,8 @@
 package orchestrator
 
 import (
+	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -17,24 +19,57 @@ var (
 	wg                sync.WaitGroup
 	rateLimitMu       sync.Mutex
 	lastRequestTime   time.Time
-	semaphore         chan struct{} // Limits concurrent tasks to 3
+	rootCtx           context.Context
+	rootCancel        context.CancelCauseFunc // Aborts every running task when the orchestrator stops
+	taskCancelsMu     sync.Mutex
+	taskCancels       map[string]context.CancelCauseFunc // Running tasks by ID
+	lastRecovery      *RecoveryReport                    // Result of the reconciliation pass on the last Start
+	activeMu          sync.Mutex
+	activeTasks       map[string]bool     // Tasks dispatched to a worker and not yet finished
+	store             storage.TaskStorage // Task storage shared by the loop and its workers, closed by Stop
+)
+
+// Causes attached to a task's context when its run is interrupted
+var (
+	errStopped       = errors.New("orchestrator stopped")
+	errTaskCancelled = errors.New("task cancelled by user")
+	errTaskTimedOut  = errors.New("task timed out")
 )
 
 // Start launches the orchestrator loop in a goroutine.
-func Start() {
+// Returns an error without starting if there is no repository or base branch to create task worktrees from,
+// or if the task storage cannot be opened.
+func Start() error {
 	mu.Lock()
 	defer mu.Unlock()
 	if running {
-		return
+		return nil
+	}
+	if _, err := RepoRoot(); err != nil {
+		return err
+	}
+	if _, err := BaseBranch(); err != nil {
+		return err
+	}
+	cfg, _ := config.LoadConfig()
+	taskStore, err := storage.Open(cfg.TaskStorageBackend())
+	if err != nil {
+		return err
 	}
+	store = taskStore
 	running = true
 	stopCh = make(chan struct{})
-	semaphore = make(chan struct{}, 3) // Max 3 parallel tasks
+	rootCtx, rootCancel = context.WithCancelCause(context.Background())
+	taskCancels = make(map[string]context.CancelCauseFunc)
+	activeTasks = make(map[string]bool)
+	lastRecovery = reconcileOnStart(taskStore, cfg)
 	wg.Add(1)
-	go orchestratorLoop()
+	go orchestratorLoop(taskStore)
+	return nil
 }
 
-// Stop signals the orchestrator to stop and waits for it to finish.
+// Stop signals the orchestrator to stop, aborts any running AI requests and waits for it to finish.
+// Interrupted tasks have their partial work committed and are re-queued.
 func Stop() {
 	mu.Lock()
 	if !running {
@@ -42,13 +77,29 @@ func Stop() {
 		return
 	}
 	close(stopCh)
+	rootCancel(errStopped)
 	mu.Unlock()
 	wg.Wait()
 	mu.Lock()
 	running = false
+	_ = store.Close()
+	store = nil
 	mu.Unlock()
 }
 
+// LastRecovery returns what the reconciliation pass did on the most recent Start, or nil if it failed
+func LastRecovery() *RecoveryReport {
+	mu.Lock()
+	defer mu.Unlock()
+	return lastRecovery
+}
+
+// reconcileOnStart recovers tasks orphaned by a previous run before the loop starts polling
+func reconcileOnStart(taskStore storage.TaskStorage, cfg *config.Config) *RecoveryReport {
+	report, _ := Reconcile(taskStore, cfg, cfg != nil && cfg.PruneOrphanedWorktrees)
+	return report
+}
+
 // IsRunning returns true if the orchestrator is running.
 func IsRunning() bool {
 	mu.Lock()
@@ -56,365 +107,385 @@ func IsRunning() bool {
 	return running
 }
 
+// Cancel aborts the running AI request for a task.
+// Partial work is committed and the task moves to In Review so the user can decide how to continue.
+func Cancel(taskID string) error {
+	taskCancelsMu.Lock()
+	cancel, ok := taskCancels[taskID]
+	taskCancelsMu.Unlock()
+	if !ok {
+		return errors.New("task is not currently running")
+	}
+	cancel(errTaskCancelled)
+	return nil
+}
+
+// startTaskContext derives the context for a single task run from the orchestrator's root context.
+// The context is bounded by the configured task timeout and can be cancelled through Cancel.
+// The returned release function must be called once the run has finished.
+func startTaskContext(taskID string, cfg *config.Config) (context.Context, func()) {
+	ctx, cancel := context.WithCancelCause(rootCtx)
+	runCtx, cancelTimeout := ctx, context.CancelFunc(func() {})
+	if timeout := cfg.TaskTimeout(); timeout > 0 {
+		runCtx, cancelTimeout = context.WithTimeoutCause(ctx, timeout, errTaskTimedOut)
+	}
+
+	taskCancelsMu.Lock()
+	taskCancels[taskID] = cancel
+	taskCancelsMu.Unlock()
+
+	return runCtx, func() {
+		taskCancelsMu.Lock()
+		delete(taskCancels, taskID)
+		taskCancelsMu.Unlock()
+		cancelTimeout()
+		cancel(nil)
+	}
+}
+
 // orchestratorLoop polls for tasks and dispatches them to a worker pool.
-func orchestratorLoop() {
+func orchestratorLoop(taskStore storage.TaskStorage) {
 	defer wg.Done()
-	taskStore, err := storage.NewFileTaskStorage()
-	if err != nil {
-		// Silent failure - orchestrator runs in background
-		return
-	}
-	
 	// Load configuration (optional)
 	cfg, err := config.LoadConfig()
 	if err != nil {
 		// Config load failure is non-critical, continue without it
 	}
 
-	// Initialize AI client based on configuration
-	var aiClient clients.AIClient
-	if cfg != nil {
-		switch cfg.AIProvider {
-		case "ollama":
-			aiClient = clients.NewOllamaClient(cfg.OllamaBaseURL, cfg.OllamaModel)
-		case "copilot":
-			aiClient = clients.NewCopilotClient(cfg.CopilotModel)
-		default:
-			// Default to Gemini
-			aiClient = &clients.GeminiClient{}
-		}
-	} else {
-		// Default to Gemini if no config
-		aiClient = &clients.GeminiClient{}
-	}
-
 	for {
 		select {
 		case <-stopCh:
 			return
 		default:
-			// Get all tasks and dispatch available ones
-			tasks, err := taskStore.ListTasks()
+			// Reload the config so concurrency limits and provider settings apply without a restart
+			if latest, err := config.LoadConfig(); err == nil {
+				cfg = latest
+				slots.setConfig(cfg)
+			}
+
+			// Get the queued tasks and dispatch available ones
+			tasks, err := taskStore.ListTasksByStatus(task.NeedsReview, task.Pending)
 			if err != nil {
-				time.Sleep(2 * time.Second)
+				waitForNextPoll()
 				continue
 			}
 
 			foundWork := false
 
-			// First pass: process NeedsReview tasks with responses
+			// First pass: process NeedsReview tasks with responses, answering expired reviews with their defaults
 			for _, t := range tasks {
-				if t.Status == task.NeedsReview && t.ReviewResponse != nil {
-					// Try to acquire semaphore slot
-					select {
-					case semaphore <- struct{}{}:
-						foundWork = true
-						wg.Add(1)
-						go processResumeTask(taskStore, aiClient, cfg, t)
-					default:
-						// No available slots, continue to next task
-					}
+				if t.Status == task.NeedsReview && t.ReviewResponse == nil {
+					answerExpiredReview(taskStore, cfg, t)
+				}
+				if t.Status == task.NeedsReview && t.ReviewResponse != nil && readyForAttempt(t) {
+					foundWork = dispatch(taskStore, cfg, t, processResumeTask) || foundWork
 				}
 			}
 
-			// Second pass: process Pending tasks
+			// Second pass: process Pending tasks whose dependencies have completed
+			statuses := dependencyStatuses(taskStore, tasks)
 			for _, t := range tasks {
-				if t.Status == task.Pending {
-					// Try to acquire semaphore slot
-					select {
-					case semaphore <- struct{}{}:
-						foundWork = true
-						wg.Add(1)
-						go processNewTask(taskStore, aiClient, cfg, t)
-					default:
-						// No available slots, continue to next task
-					}
+				if t.Status == task.Pending && readyForAttempt(t) && len(task.BlockedBy(*t, statuses)) == 0 {
+					foundWork = dispatch(taskStore, cfg, t, processNewTask) || foundWork
 				}
 			}
 
 			if !foundWork {
-				time.Sleep(2 * time.Second) // No tasks available, wait before polling again
+				waitForNextPoll() // No tasks available, wait before polling again
 			}
 		}
 	}
 }
 
-// processResumeTask handles a NeedsReview task with a user response.
-func processResumeTask(taskStore *storage.FileTaskStorage, aiClient clients.AIClient, cfg *config.Config, t *task.Task) {
-	defer wg.Done()
-	defer func() { <-semaphore }() // Release semaphore slot
-
-	t.Status = task.InProgress
-	if err := taskStore.UpdateTask(t); err != nil {
-		return
+// dispatch starts a worker for a task if both a global slot and a slot for its provider are free.
+// Returns false if the task is already running or no slot is available.
+func dispatch(taskStore storage.TaskStorage, cfg *config.Config, t *task.Task, process func(storage.TaskStorage, clients.AIClient, *config.Config, *task.Task)) bool {
+	if !claimTask(t.ID) {
+		return false
 	}
-
-	optionLabels := make([]string, len(t.Review.Options))
-	for i, opt := range t.Review.Options {
-		optionLabels[i] = opt.Label
+	provider := taskProvider(cfg, t)
+	if !slots.acquire(provider) {
+		releaseTask(t.ID)
+		return false
 	}
-	prompt := BuildResumePrompt(t.Name, t.WorkInProgress, t.Review.Question, optionLabels, t.ReviewResponse.ChosenLabel, t.ReviewResponse.UserNotes)
 
-	// Apply rate limiting before request
-	applyRateLimit(cfg)
+	aiClient := newAIClient(cfg, provider)
+	// The worker gets its own copy, since the loop keeps reading the listed task while it runs
+	worker := *t
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		defer releaseTask(worker.ID)
+		defer slots.release(provider)
+		process(taskStore, aiClient, cfg, &worker)
+	}()
+	return true
+}
 
-	// Create response writer for streaming
-	respWriter, respPath, err := storage.NewResponseWriter(t.ID)
-	if err != nil {
-		t.Status = task.NeedsReview
-		_ = taskStore.UpdateTask(t)
-		return
+// claimTask marks a task as dispatched, returning false if a worker is already handling it.
+// This stops the next poll from dispatching a task again before its worker has updated its status.
+func claimTask(taskID string) bool {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	if activeTasks[taskID] {
+		return false
 	}
-	defer respWriter.Close()
+	activeTasks[taskID] = true
+	return true
+}
 
-	// Store response file path immediately so it's available during streaming
-	t.ResponseFile = respPath
-	if err := taskStore.UpdateTask(t); err != nil {
-		// Failure to save path is non-critical
-	}
+// releaseTask marks a task's worker as finished
+func releaseTask(taskID string) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	delete(activeTasks, taskID)
+}
 
-	_, err = aiClient.SendPromptWithDir(prompt, respWriter, t.WorktreePath)
-	if err != nil {
-		t.Status = task.NeedsReview
-		_ = taskStore.UpdateTask(t)
-		return
+// saveTask persists a task the orchestrator is working on.
+// If the stored copy changed since the task was read (e.g. the user answered its review from another
+// process), the two are merged with storage.MergeTask and the save is retried.
+func saveTask(taskStore storage.TaskStorage, t *task.Task) error {
+	for range 3 {
+		err := taskStore.UpdateTask(t)
+		if !errors.Is(err, storage.ErrStaleTask) {
+			return err
+		}
+		current, err := taskStore.GetTask(t.ID)
+		if err != nil {
+			return err
+		}
+		*t = *storage.MergeTask(current, t)
 	}
+	return storage.ErrStaleTask
+}
 
-	t.Status = task.Completed
-	// ResponseFile already set above when streaming started
-	_ = taskStore.UpdateTask(t)
-
-	// Commit any uncommitted work before removing worktree
-	if t.WorktreePath != "" {
-		_ = CommitAnyChanges(t.WorktreePath, t.ID)
-		_ = RemoveWorktree(t.WorktreePath)
-		t.WorktreePath = ""
-		_ = taskStore.UpdateTask(t)
+// waitForNextPoll sleeps between polls, returning early if the orchestrator is stopped
+func waitForNextPoll() {
+	select {
+	case <-stopCh:
+	case <-time.After(2 * time.Second):
 	}
 }
 
-// processNewTask handles a Pending task that needs initial processing.
-func processNewTask(taskStore *storage.FileTaskStorage, aiClient clients.AIClient, cfg *config.Config, t *task.Task) {
-	defer wg.Done()
-	defer func() { <-semaphore }() // Release semaphore slot
-
-	// Generate and create worktree for this task
-	branchName, err := GenerateBranchName(t.Name)
-	if err != nil {
+// processResumeTask handles a NeedsReview task with a user response.
+func processResumeTask(taskStore storage.TaskStorage, aiClient clients.AIClient, cfg *config.Config, t *task.Task) {
+	t.Status = task.InProgress
+	if err := saveTask(taskStore, t); err != nil {
 		return
 	}
 
-	worktreePath, err := CreateWorktree(branchName, t.ID)
-	if err != nil {
-		return
-	}
-	t.BranchName = branchName
-	t.WorktreePath = worktreePath
+	prompt := BuildReviewResumePrompt(t.Name, t.WorkInProgress, t.Review, t.ReviewResponse)
 
-	t.Status = task.InProgress
-	if err := taskStore.UpdateTask(t); err != nil {
+	// Bring the branch up to date with its base branch; conflicts may pause the task again
+	note, paused := updateBeforeResume(taskStore, cfg, t)
+	if paused {
 		return
 	}
+	if note != "" {
+		prompt += "\n\n" + note
+	}
+
+	ctx, release := startTaskContext(t.ID, cfg)
+	defer release()
+	beginAttempt(t, aiClient)
 
 	// Apply rate limiting before request
-	applyRateLimit(cfg)
+	if err := applyRateLimit(ctx, cfg); err != nil {
+		handleInterruptedTask(taskStore, cfg, t, err, "", task.NeedsReview)
+		return
+	}
 
 	// Create response writer for streaming
 	respWriter, respPath, err := storage.NewResponseWriter(t.ID)
 	if err != nil {
-		t.Status = task.Pending
-		_ = taskStore.UpdateTask(t)
+		handleFailedAttempt(taskStore, t, cfg, err, "", task.NeedsReview)
 		return
 	}
 	defer respWriter.Close()
 
 	// Store response file path immediately so it's available during streaming
 	t.ResponseFile = respPath
-	if err := taskStore.UpdateTask(t); err != nil {
+	currentAttempt(t).ResponseFile = respPath
+	if err := saveTask(taskStore, t); err != nil {
 		// Failure to save path is non-critical
 	}
 
-	response, err := aiClient.SendPromptWithDir(BuildTaskPrompt(t.Name), respWriter, t.WorktreePath)
+	// Stream typed events into the response file
+	logEvent := clients.NewEventLogger(respWriter)
+	response, err := aiClient.StreamPromptWithDir(ctx, prompt, t.WorktreePath, logEvent)
 	if err != nil {
-		t.Status = task.Pending
-		_ = taskStore.UpdateTask(t)
+		if ctx.Err() != nil {
+			handleInterruptedTask(taskStore, cfg, t, context.Cause(ctx), response, task.NeedsReview)
+			return
+		}
+		handleFailedAttempt(taskStore, t, cfg, err, response, task.NeedsReview)
 		return
 	}
 
-	// Check if response contains a review request
-	workInProgress, review, hasReview := parseReviewRequest(response)
-	if hasReview {
-		t.Status = task.NeedsReview
-		t.WorkInProgress = workInProgress
-		t.Review = review
-		// ResponseFile already set above when streaming started
-		_ = taskStore.UpdateTask(t)
+	// The AI may ask again, e.g. a follow-up question or a review block repeated in the correct format
+	if pauseForReview(taskStore, t, response) {
 		return
 	}
 
-	t.Status = task.Completed
-	// ResponseFile already set above when streaming started
-	_ = taskStore.UpdateTask(t)
-
-	// Commit any uncommitted work before removing worktree
-	if t.WorktreePath != "" {
-		_ = CommitAnyChanges(t.WorktreePath, t.ID)
-		_ = RemoveWorktree(t.WorktreePath)
-		t.WorktreePath = ""
-		_ = taskStore.UpdateTask(t)
-	}
+	t.Summary = commitSummary(response)
+	verifyAndComplete(ctx, taskStore, aiClient, cfg, t, logEvent, task.NeedsReview)
 }
 
-// parseReviewRequest extracts a review request and work-in-progress from the AI response
-// Returns (WorkInProgress, ReviewRequest, hasReview)
-func parseReviewRequest(response string) (string, *task.ReviewRequest, bool) {
-	// Look for NEEDS_REVIEW markers
-	reviewStart := -1
-	reviewEnd := -1
-	for i := 0; i < len(response)-len("---NEEDS_REVIEW---"); i++ {
-		if response[i:i+len("---NEEDS_REVIEW---")] == "---NEEDS_REVIEW---" {
-			reviewStart = i + len("---NEEDS_REVIEW---")
-			break
+// processNewTask handles a Pending task that needs initial processing.
+func processNewTask(taskStore storage.TaskStorage, aiClient clients.AIClient, cfg *config.Config, t *task.Task) {
+	beginAttempt(t, aiClient)
+
+	// A task interrupted by Stop, recovered after a crash or retried after a failure keeps its worktree,
+	// so continue working in it
+	prompt := BuildTaskPrompt(t.Name)
+	if t.WorktreePath != "" && WorktreeExists(t.WorktreePath) {
+		prompt = BuildRecoveryPrompt(t.Name, t.WorkInProgress)
+	} else {
+		// Generate and create worktree for this task
+		branchName, err := GenerateBranchName(t.Name)
+		if err != nil {
+			handleFailedAttempt(taskStore, t, cfg, err, "", task.Pending)
+			return
 		}
-	}
-	if reviewStart == -1 {
-		return "", nil, false
-	}
 
-	for i := reviewStart; i < len(response)-len("---END_REVIEW---"); i++ {
-		if response[i:i+len("---END_REVIEW---")] == "---END_REVIEW---" {
-			reviewEnd = i
-			break
+		worktreePath, err := createTaskWorktree(taskStore, cfg, t, branchName)
+		if err != nil {
+			handleFailedAttempt(taskStore, t, cfg, err, "", task.Pending)
+			return
 		}
-	}
-	if reviewEnd == -1 {
-		return "", nil, false
+		t.BranchName = branchName
+		t.WorktreePath = worktreePath
 	}
 
-	// Extract work-in-progress (everything before NEEDS_REVIEW marker)
-	workInProgress := trim(response[:reviewStart-len("---NEEDS_REVIEW---")])
+	t.Status = task.InProgress
+	if err := saveTask(taskStore, t); err != nil {
+		return
+	}
 
-	reviewBlock := response[reviewStart:reviewEnd]
-	review := parseReviewBlock(reviewBlock)
-	return workInProgress, review, review != nil
-}
+	ctx, release := startTaskContext(t.ID, cfg)
+	defer release()
 
-// parseReviewBlock parses the content between NEEDS_REVIEW markers
-func parseReviewBlock(block string) *task.ReviewRequest {
-	lines := split(block, "\n")
-	var question, context string
-	var options []task.ReviewOption
-
-	for _, line := range lines {
-		line = trim(line)
-		if hasPrefix(line, "Question:") {
-			question = trimPrefix(line, "Question:")
-			question = trim(question)
-		} else if hasPrefix(line, "Context:") {
-			context = trimPrefix(line, "Context:")
-			context = trim(context)
-		} else if hasPrefix(line, "- id:") {
-			opt := parseOption(line)
-			if opt != nil {
-				options = append(options, *opt)
-			}
-		}
+	// Apply rate limiting before request
+	if err := applyRateLimit(ctx, cfg); err != nil {
+		handleInterruptedTask(taskStore, cfg, t, err, "", task.Pending)
+		return
 	}
 
-	if question == "" {
-		return nil
+	// Create response writer for streaming
+	respWriter, respPath, err := storage.NewResponseWriter(t.ID)
+	if err != nil {
+		handleFailedAttempt(taskStore, t, cfg, err, "", task.Pending)
+		return
 	}
+	defer respWriter.Close()
 
-	return &task.ReviewRequest{
-		Question:  question,
-		Options:   options,
-		Context:   context,
-		CreatedAt: time.Now(),
+	// Store response file path immediately so it's available during streaming
+	t.ResponseFile = respPath
+	currentAttempt(t).ResponseFile = respPath
+	if err := saveTask(taskStore, t); err != nil {
+		// Failure to save path is non-critical
 	}
-}
 
-// parseOption extracts an option from "- id: x | label: y" format
-func parseOption(line string) *task.ReviewOption {
-	// Remove leading "- id: "
-	if !hasPrefix(line, "- id:") {
-		return nil
+	// Stream typed events into the response file; response is the normalized assistant text
+	logEvent := clients.NewEventLogger(respWriter)
+	response, err := aiClient.StreamPromptWithDir(ctx, prompt, t.WorktreePath, logEvent)
+	if err != nil {
+		if ctx.Err() != nil {
+			handleInterruptedTask(taskStore, cfg, t, context.Cause(ctx), response, task.Pending)
+			return
+		}
+		handleFailedAttempt(taskStore, t, cfg, err, response, task.Pending)
+		return
 	}
-	line = trimPrefix(line, "- id:")
-	line = trim(line)
 
-	// Split on "|"
-	parts := split(line, "|")
-	if len(parts) < 2 {
-		return nil
+	// Check if response contains a review request
+	if pauseForReview(taskStore, t, response) {
+		return
 	}
 
-	id := trim(parts[0])
-	labelPart := trim(parts[1])
+	t.Summary = commitSummary(response)
+	verifyAndComplete(ctx, taskStore, aiClient, cfg, t, logEvent, task.Pending)
+}
 
-	// Extract label from "label: y"
-	if !hasPrefix(labelPart, "label:") {
-		return nil
+// pauseForReview moves a task to In Review when the AI's response asks a question, reporting whether it did.
+// - The text before the review block becomes the work in progress
+// - A question asked while acting on an earlier answer keeps that answer in the work in progress for the next resume
+func pauseForReview(taskStore storage.TaskStorage, t *task.Task, response string) bool {
+	workInProgress, review, hasReview := parseReviewRequest(response)
+	if !hasReview {
+		return false
 	}
-	label := trimPrefix(labelPart, "label:")
-	label = trim(label)
-
-	return &task.ReviewOption{
-		ID:    id,
-		Label: label,
+	finishAttempt(t, nil)
+	if t.Review != nil && t.ReviewResponse != nil {
+		appendWorkInProgress(t, "The user answered these questions about the task:\n\n"+formatReviewAnswers(t.Review, t.ReviewResponse))
+		appendWorkInProgress(t, workInProgress)
+	} else {
+		t.WorkInProgress = workInProgress
 	}
+	t.Status = task.NeedsReview
+	t.Review = review
+	t.ReviewResponse = nil
+	// ResponseFile already set when streaming started
+	_ = saveTask(taskStore, t)
+	return true
 }
 
-// Helper string functions
-func split(s, sep string) []string {
-	var result []string
-	for {
-		index := indexOf(s, sep)
-		if index == -1 {
-			result = append(result, s)
-			break
-		}
-		result = append(result, s[:index])
-		s = s[index+len(sep):]
+// handleInterruptedTask moves a task whose AI run was aborted into a well-defined state.
+// - The attempt is recorded with the cause but does not count against the retry policy
+// - Any partial changes in the worktree are committed so no work is lost
+// - The partial output is appended to the task's work-in-progress
+// - If the orchestrator was stopped, the task returns to requeueStatus and resumes on the next start
+// - If the user cancelled it or it timed out, it moves to In Review
+func handleInterruptedTask(taskStore storage.TaskStorage, cfg *config.Config, t *task.Task, cause error, partial string, requeueStatus task.Status) {
+	finishAttempt(t, cause)
+	if t.WorktreePath != "" {
+		_ = CommitPartialChanges(cfg, t.WorktreePath, t.ID, cause.Error())
 	}
-	return result
-}
+	appendWorkInProgress(t, partial)
 
-func trim(s string) string {
-	start := 0
-	end := len(s)
-	for start < end && (s[start] == ' ' || s[start] == '\n' || s[start] == '\t' || s[start] == '\r') {
-		start++
-	}
-	for end > start && (s[end-1] == ' ' || s[end-1] == '\n' || s[end-1] == '\t' || s[end-1] == '\r') {
-		end--
+	if errors.Is(cause, errStopped) {
+		t.Status = requeueStatus
+		_ = saveTask(taskStore, t)
+		return
 	}
-	return s[start:end]
-}
 
-func hasPrefix(s, prefix string) bool {
-	return len(s) >= len(prefix) && s[:len(prefix)] == prefix
+	t.Status = task.NeedsReview
+	t.Review = interruptionReview(cause)
+	t.ReviewResponse = nil
+	_ = saveTask(taskStore, t)
 }
 
-func trimPrefix(s, prefix string) string {
-	if hasPrefix(s, prefix) {
-		return s[len(prefix):]
+// interruptionReview builds the review request shown for a cancelled or timed out task.
+// A cancelled task waits for the user; a timed out task may be wrapped up automatically under the review timeout policy.
+func interruptionReview(cause error) *task.ReviewRequest {
+	question := task.ReviewQuestion{
+		ID:   "q1",
+		Kind: task.SingleChoice,
+		Text: "The task was cancelled before it finished. How should Ludwig continue?",
+		Options: []task.ReviewOption{
+			{ID: "resume", Label: "Resume the task from where it left off"},
+			{ID: "wrap-up", Label: "Wrap up: verify and commit the partial work, then summarize what is left"},
+		},
+	}
+	if errors.Is(cause, errTaskTimedOut) {
+		question.Text = "The task timed out before it finished. How should Ludwig continue?"
+		question.Default = "wrap-up"
 	}
-	return s
-}
-
-func indexOf(s, sep string) int {
-	for i := 0; i <= len(s)-len(sep); i++ {
-		if s[i:i+len(sep)] == sep {
-			return i
-		}
+	return &task.ReviewRequest{
+		Question:  question.Text,
+		Context:   "Partial changes were committed to the task branch.",
+		Options:   question.Options,
+		CreatedAt: time.Now(),
+		Questions: []task.ReviewQuestion{question},
+		Manual:    !errors.Is(cause, errTaskTimedOut),
 	}
-	return -1
 }
 
 // applyRateLimit waits if necessary based on config rate limits (thread-safe).
-func applyRateLimit(cfg *config.Config) {
+// Returns the cancellation cause if ctx is done while waiting.
+func applyRateLimit(ctx context.Context, cfg *config.Config) error {
 	if cfg == nil || cfg.DelayMs <= 0 {
-		return // No rate limiting configured
+		return nil // No rate limiting configured
 	}
 
 	rateLimitMu.Lock()
@@ -426,8 +497,13 @@ func applyRateLimit(cfg *config.Config) {
 
 	if timeSinceLastRequest < delay {
 		waitTime := delay - timeSinceLastRequest
-		time.Sleep(waitTime)
+		select {
+		case <-time.After(waitTime):
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
 	}
 
 	lastRequestTime = time.Now()
+	return nil
 }
@@ -1,5 +1,13 @@
 package orchestrator
 
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"ludwig/internal/types/task"
+)
+
 const SystemPrompt = `You are an AI task executor working on a software project. Complete the requested tasks step by step.
 
 PROJECT CONTEXT:
@@ -89,6 +97,36 @@ Context: [Brief explanation of why you need this information]
 
 Replace the option IDs and labels with your actual options. You can have 2 or more options.
 
+You can ask several related questions in one review, and a question does not have to be a choice.
+Start each question with a Question: line, optionally followed by a Kind: line and a Default: line with the answer you suggest:
+- Kind: single - pick one of the options (the default when options are given)
+- Kind: multi - pick any number of the options (Default: is a comma-separated list of option ids)
+- Kind: text - type a value such as a port number or a file path (the default when no options are given)
+- Kind: yesno - answer yes or no (Default: yes or no)
+If the task can sensibly continue with your suggested answers when nobody replies, add a Timeout: line to the review
+(e.g. Timeout: 2h, or "timeout": "2h" in JSON) and give every question a Default:. Only do this for decisions that are easy to change later.
+
+---NEEDS_REVIEW---
+Context: [Brief explanation of why you need this information]
+Question: [Which of these approaches should be used?]
+- id: option1 | label: [First option description]
+- id: option2 | label: [Second option description]
+Default: option1
+Question: [Which port should the server listen on?]
+Kind: text
+Default: 8080
+---END_REVIEW---
+
+Instead of the lines, the review block may hold a JSON object, optionally in a ` + "```json" + ` code fence.
+Each question has a "question", an optional "id", "kind", "options" and "default" (a boolean for yesno, a list for multi):
+
+---NEEDS_REVIEW---
+{"context": "[Why you need this]", "questions": [
+  {"id": "approach", "question": "[Which approach?]", "options": [{"id": "a", "label": "[First]"}, {"id": "b", "label": "[Second]"}], "default": "a"},
+  {"id": "port", "kind": "text", "question": "[Which port?]", "default": "8080"}
+]}
+---END_REVIEW---
+
 Examples of when to ask for review:
 - Design decisions with multiple valid approaches
 - Missing technical requirements or constraints
@@ -96,7 +134,7 @@ Examples of when to ask for review:
 - Trade-offs between performance, cost, or features
 - Test failures that indicate unclear requirements
 
-After the human responds with their choice, you will receive the selected option and can continue with the task.`
+After the human responds, you will receive their answers and can continue with the task.`
 
 // BuildTaskPrompt combines the system prompt with a specific task
 func BuildTaskPrompt(taskName string) string {
@@ -133,3 +171,101 @@ User chose: ` + chosenLabel + notes + `
 
 Now continue and complete the task using the user's choice.`
 }
+
+// BuildReviewResumePrompt creates a prompt that resumes task execution with the user's answers to a review.
+// A single choice review answered without per-question answers uses BuildResumePrompt.
+// If the AI's review block could not be read, the prompt tells the AI what it wrote and why it was rejected.
+func BuildReviewResumePrompt(taskName string, workInProgress string, review *task.ReviewRequest, response *task.ReviewResponse) string {
+	if review.ParseError != "" {
+		workInProgress = strings.TrimSpace(workInProgress + "\n\nYour review block could not be read (" + review.ParseError + "), so Ludwig asked the user how to continue instead. You wrote:\n" + strings.TrimSpace(review.Raw))
+	}
+
+	if len(response.Answers) == 0 {
+		optionLabels := make([]string, len(review.Options))
+		for i, opt := range review.Options {
+			optionLabels[i] = opt.Label
+		}
+		return BuildResumePrompt(taskName, workInProgress, review.Question, optionLabels, response.ChosenLabel, response.UserNotes)
+	}
+
+	notes := ""
+	if response.UserNotes != "" {
+		notes = "User notes: " + response.UserNotes + "\n\n"
+	}
+
+	progress := ""
+	if workInProgress != "" {
+		progress = "\n\nHere's the work completed so far:\n" + workInProgress
+	}
+
+	return SystemPrompt + `
+
+Original task: ` + taskName + progress + `
+
+The user answered these questions about the task:
+
+` + formatReviewAnswers(review, response) + notes + `Now continue and complete the task using the user's answers.`
+}
+
+// formatReviewAnswers lists each question of a review with the options offered and the user's answer
+func formatReviewAnswers(review *task.ReviewRequest, response *task.ReviewResponse) string {
+	var answers strings.Builder
+	for i, question := range review.AllQuestions() {
+		answers.WriteString(fmt.Sprintf("Q%d: %s\n", i+1, question.Text))
+		if len(question.Options) > 0 && question.Kind != task.YesNo {
+			labels := make([]string, len(question.Options))
+			for j, opt := range question.Options {
+				labels[j] = opt.Label
+			}
+			answers.WriteString("Options were: " + strings.Join(labels, "; ") + "\n")
+		}
+		answer := "(not answered)"
+		if j := slices.IndexFunc(response.Answers, func(a task.ReviewAnswer) bool { return a.QuestionID == question.ID }); j != -1 {
+			answer = question.AnswerText(response.Answers[j])
+		} else if len(response.Answers) == 0 && i == 0 && response.ChosenLabel != "" {
+			answer = response.ChosenLabel
+		}
+		answers.WriteString("Answer: " + answer + "\n\n")
+	}
+	return answers.String()
+}
+
+// BuildConflictNote tells the AI that the base branch was merged into its worktree with conflicts to resolve first
+func BuildConflictNote(baseBranch string, files []string) string {
+	return baseBranch + ` moved on while you worked, and Ludwig merged it into your branch. The merge stopped on conflicts in:
+  ` + strings.Join(files, "\n  ") + `
+
+Before anything else, resolve these conflicts in your worktree: keep the intent of both sides, remove every conflict marker, and commit the merge.`
+}
+
+// BuildRecoveryPrompt creates a prompt that continues a task whose previous run was interrupted
+// (e.g. the orchestrator was stopped or crashed) in the same worktree
+func BuildRecoveryPrompt(taskName string, previousWork string) string {
+	progress := ""
+	if previousWork != "" {
+		progress = "\n\nHere's the output of the previous attempt:\n" + previousWork
+	}
+
+	return SystemPrompt + `
+
+Original task: ` + taskName + `
+
+A previous attempt at this task was interrupted before it finished. Any changes it made are already committed in this branch (check git log and git status).` + progress + `
+
+Review what has already been done, then continue from where it left off and complete the task.`
+}
+
+// BuildVerifyFixPrompt creates a follow-up prompt asking the AI to fix a failing verification command
+func BuildVerifyFixPrompt(taskName string, stepName string, command string, output string) string {
+	return SystemPrompt + `
+
+Original task: ` + taskName + `
+
+You finished working on this task, but the project's ` + stepName + ` check failed. Ludwig ran this command in your worktree:
+  ` + command + `
+
+Its output was:
+` + output + `
+
+Fix the problems so that the command passes, then run it yourself to confirm.`
+}
@@ -0,0 +1,85 @@
+package orchestrator
+
+import (
+	"errors"
+	"slices"
+
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// modifyTask applies change to the latest stored copy of a task.
+// If the task is saved by someone else in between, it is re-read and change is applied again,
+// so the user's edit is never merged away as a stale write.
+func modifyTask(taskStore storage.TaskStorage, taskID string, change func(t *task.Task)) error {
+	for range 3 {
+		t, err := taskStore.GetTask(taskID)
+		if err != nil {
+			return err
+		}
+		change(t)
+		if err := taskStore.UpdateTask(t); !errors.Is(err, storage.ErrStaleTask) {
+			return err
+		}
+	}
+	return storage.ErrStaleTask
+}
+
+// ChangePriority raises (delta > 0) or lowers (delta < 0) a task's priority.
+// Pending tasks with a higher priority are dispatched first.
+func ChangePriority(taskStore storage.TaskStorage, taskID string, delta int) (int, error) {
+	var priority int
+	err := modifyTask(taskStore, taskID, func(t *task.Task) {
+		t.Priority += delta
+		priority = t.Priority
+	})
+	return priority, err
+}
+
+// MoveTask places a task directly before another in the queue.
+// The moved task takes the target's priority, and the queue positions of that priority are renumbered.
+func MoveTask(taskStore storage.TaskStorage, taskID string, beforeID string) error {
+	if taskID == beforeID {
+		return nil
+	}
+	tasks, err := taskStore.ListTasks()
+	if err != nil {
+		return err
+	}
+	slices.SortFunc(tasks, task.QueueOrder)
+
+	targetIndex := slices.IndexFunc(tasks, func(t *task.Task) bool { return t.ID == beforeID })
+	movingIndex := slices.IndexFunc(tasks, func(t *task.Task) bool { return t.ID == taskID })
+	if targetIndex == -1 || movingIndex == -1 {
+		return storage.ErrTaskNotFound
+	}
+	priority := tasks[targetIndex].Priority
+
+	// Build the new order of the target's priority group with the moved task before the target
+	var group []*task.Task
+	for _, t := range tasks {
+		if t.ID == taskID {
+			continue
+		}
+		if t.ID == beforeID {
+			group = append(group, tasks[movingIndex])
+		}
+		if t.Priority == priority {
+			group = append(group, t)
+		}
+	}
+
+	for i, t := range group {
+		position := i + 1
+		if t.QueuePosition == position && t.Priority == priority {
+			continue
+		}
+		if err := modifyTask(taskStore, t.ID, func(stored *task.Task) {
+			stored.Priority = priority
+			stored.QueuePosition = position
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package orchestrator
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator/clients"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// RecoveryReport describes what the reconciliation pass on Start found and did
+type RecoveryReport struct {
+	Resumed           []string // Tasks re-queued to continue in their worktree with the previous output as context
+	Requeued          []string // Tasks re-queued to start over
+	OrphanedWorktrees []string // Worktree directories that no task refers to
+	PrunedWorktrees   []string // Orphaned worktree directories that were removed
+}
+
+// Empty reports whether reconciliation found nothing to do
+func (r *RecoveryReport) Empty() bool {
+	return r == nil || len(r.Resumed)+len(r.Requeued)+len(r.OrphanedWorktrees) == 0
+}
+
+// Summary returns a short human-readable description of the report
+func (r *RecoveryReport) Summary() string {
+	if r.Empty() {
+		return ""
+	}
+	var parts []string
+	if len(r.Resumed) > 0 {
+		parts = append(parts, fmt.Sprintf("resuming %d interrupted task(s)", len(r.Resumed)))
+	}
+	if len(r.Requeued) > 0 {
+		parts = append(parts, fmt.Sprintf("re-queued %d interrupted task(s)", len(r.Requeued)))
+	}
+	if len(r.PrunedWorktrees) > 0 {
+		parts = append(parts, fmt.Sprintf("pruned %d orphaned worktree(s)", len(r.PrunedWorktrees)))
+	}
+	if unpruned := len(r.OrphanedWorktrees) - len(r.PrunedWorktrees); unpruned > 0 {
+		parts = append(parts, fmt.Sprintf("found %d orphaned worktree(s) in .worktrees", unpruned))
+	}
+	return "Recovery: " + strings.Join(parts, ", ") + "."
+}
+
+// Reconcile repairs tasks left In Progress by a previous run that exited or crashed.
+// - Partial changes in a surviving worktree are committed
+// - Tasks with commits or previous output are resumed in their worktree with that output as context
+// - Tasks that were answering a review go back to In Review so the answer is replayed
+// - Tasks with nothing to show are re-queued to start over
+// Worktree directories that no task refers to are reported, and removed if prune is true.
+func Reconcile(taskStore storage.TaskStorage, cfg *config.Config, prune bool) (*RecoveryReport, error) {
+	tasks, err := taskStore.ListTasks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+
+	report := &RecoveryReport{}
+	for _, t := range tasks {
+		if t.Status != task.InProgress {
+			continue
+		}
+		if recoverTask(cfg, t) {
+			report.Resumed = append(report.Resumed, t.ID)
+		} else {
+			report.Requeued = append(report.Requeued, t.ID)
+		}
+		if err := saveTask(taskStore, t); err != nil {
+			return report, fmt.Errorf("failed to update task %s: %w", t.ID, err)
+		}
+	}
+
+	// Any .worktrees directory not owned by a task is left over from a crash or a deleted task
+	owned := make(map[string]bool)
+	for _, t := range tasks {
+		if t.WorktreePath != "" {
+			owned[filepath.Clean(t.WorktreePath)] = true
+		}
+	}
+	dirs, err := ListWorktreeDirs()
+	if err != nil {
+		return report, err
+	}
+	for _, dir := range dirs {
+		if owned[filepath.Clean(dir)] {
+			continue
+		}
+		report.OrphanedWorktrees = append(report.OrphanedWorktrees, dir)
+		if prune && PruneWorktree(dir) == nil {
+			report.PrunedWorktrees = append(report.PrunedWorktrees, dir)
+		}
+	}
+
+	return report, nil
+}
+
+// recoverTask moves a single orphaned In Progress task back into a runnable state.
+// Returns true if the task will resume with its previous work, false if it starts over.
+func recoverTask(cfg *config.Config, t *task.Task) bool {
+	finishAttempt(t, errAttemptAbandoned)
+
+	// The worktree directory may have been deleted while its branch survived
+	if t.WorktreePath != "" && !WorktreeExists(t.WorktreePath) && t.BranchName != "" {
+		if exists, _ := BranchExists(t.BranchName); exists {
+			if path, err := RestoreWorktree(t.BranchName, t.ID); err == nil {
+				t.WorktreePath = path
+			}
+		}
+	}
+
+	if t.WorktreePath == "" || !WorktreeExists(t.WorktreePath) {
+		// Nothing survived: start over with a fresh branch
+		t.Status = task.Pending
+		t.BranchName = ""
+		t.WorktreePath = ""
+		t.WorkInProgress = ""
+		t.Review = nil
+		t.ReviewResponse = nil
+		return false
+	}
+
+	_ = CommitPartialChanges(cfg, t.WorktreePath, t.ID, "orchestrator restarted")
+	commits, _ := BranchCommitCount(t.WorktreePath)
+
+	if t.ResponseFile != "" {
+		if content, err := storage.ReadResponse(t.ResponseFile); err == nil {
+			appendWorkInProgress(t, clients.ReplayText(content))
+		}
+	}
+
+	// The task crashed while acting on a review answer: replay the answer
+	if t.Review != nil && t.ReviewResponse != nil {
+		t.Status = task.NeedsReview
+		return true
+	}
+
+	t.Status = task.Pending
+	return commits > 0 || t.WorkInProgress != ""
+}
@@ -0,0 +1,132 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// AnswerReview saves the user's answer to a task's review request.
+// The orchestrator resumes the task with the answer on its next poll.
+// The answer is rejected if the task has left In Review or asked a different question since the review was opened.
+func AnswerReview(taskStore storage.TaskStorage, taskID string, review *task.ReviewRequest, response task.ReviewResponse) error {
+	t, err := taskStore.GetTask(taskID)
+	if err != nil {
+		return err
+	}
+	if review == nil {
+		review = t.Review
+	}
+	if err := canAnswerReview(t, review); err != nil {
+		return err
+	}
+
+	if response.RespondedAt.IsZero() {
+		response.RespondedAt = time.Now()
+	}
+	// The orchestrator may have resumed the task since it was read, so check again before saving
+	var rejected error
+	err = modifyTask(taskStore, taskID, func(stored *task.Task) {
+		if rejected = canAnswerReview(stored, review); rejected == nil {
+			stored.ReviewResponse = &response
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return rejected
+}
+
+// canAnswerReview returns an error if t is no longer waiting for an answer to review
+func canAnswerReview(t *task.Task, review *task.ReviewRequest) error {
+	if t.Status != task.NeedsReview || t.Review == nil {
+		return fmt.Errorf("task is not waiting for review (task is %s)", task.StatusString(*t))
+	}
+	if !t.Review.SameRequest(review) {
+		return fmt.Errorf("the task has asked a new question, open the review again")
+	}
+	return nil
+}
+
+// ReviewDeadline returns when an unanswered review is answered automatically with its default answers.
+// Returns false if the review waits for the user: it has no timeout, is manual, or a question has no default answer.
+func ReviewDeadline(cfg *config.Config, review *task.ReviewRequest) (time.Time, bool) {
+	timeout := review.Timeout
+	if timeout <= 0 {
+		timeout = cfg.ReviewTimeout()
+	}
+	if timeout <= 0 || review.Manual {
+		return time.Time{}, false
+	}
+	if _, ok := defaultReviewResponse(review, firstOptionAsDefault(cfg)); !ok {
+		return time.Time{}, false
+	}
+	return review.CreatedAt.Add(timeout), true
+}
+
+// firstOptionAsDefault reports whether the review policy answers choice questions without a default with their first option
+func firstOptionAsDefault(cfg *config.Config) bool {
+	return cfg != nil && cfg.Review.FirstOptionAsDefault
+}
+
+// defaultReviewResponse answers every question of a review with its default answer.
+// Returns false if a question has no default answer.
+func defaultReviewResponse(review *task.ReviewRequest, firstOption bool) (*task.ReviewResponse, bool) {
+	response := &task.ReviewResponse{Automatic: true}
+	for i, question := range review.AllQuestions() {
+		answer := task.ReviewAnswer{QuestionID: question.ID}
+		switch {
+		case question.Default != "" && question.Kind == task.FreeText:
+			answer.Text = question.Default
+		case question.Default != "" && question.Kind == task.MultiChoice:
+			answer.OptionIDs = strings.Split(question.Default, ",")
+		case question.Default != "":
+			answer.OptionIDs = []string{question.Default}
+		case firstOption && len(question.Options) > 0:
+			answer.OptionIDs = []string{question.Options[0].ID}
+		default:
+			return nil, false
+		}
+		response.Answers = append(response.Answers, answer)
+
+		if i == 0 && question.Kind == task.SingleChoice {
+			response.ChosenOptionID = answer.OptionIDs[0]
+			response.ChosenLabel = question.AnswerText(answer)
+		}
+	}
+	return response, true
+}
+
+// answerExpiredReview answers a review that waited past its deadline with its default answers,
+// so the orchestrator resumes the task. Returns true if the review was answered.
+func answerExpiredReview(taskStore storage.TaskStorage, cfg *config.Config, t *task.Task) bool {
+	if t.Review == nil {
+		return false
+	}
+	deadline, ok := ReviewDeadline(cfg, t.Review)
+	if !ok || time.Now().Before(deadline) {
+		return false
+	}
+	response, _ := defaultReviewResponse(t.Review, firstOptionAsDefault(cfg))
+	response.RespondedAt = time.Now()
+	response.UserNotes = fmt.Sprintf("Nobody answered within %s, so Ludwig answered automatically with the default answers. Make choices that are easy to change later.",
+		deadline.Sub(t.Review.CreatedAt).Round(time.Minute))
+
+	// The user may have answered in the meantime; their answer wins
+	err := modifyTask(taskStore, t.ID, func(stored *task.Task) {
+		if stored.Status == task.NeedsReview && stored.ReviewResponse == nil && stored.Review.SameRequest(t.Review) {
+			stored.ReviewResponse = response
+		}
+	})
+	if err != nil {
+		return false
+	}
+	if stored, err := taskStore.GetTask(t.ID); err == nil {
+		*t = *stored
+	}
+	return t.ReviewResponse != nil
+}
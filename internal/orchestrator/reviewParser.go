@@ -0,0 +1,387 @@
+package orchestrator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"ludwig/internal/types/task"
+)
+
+const (
+	reviewStartMarker = "---NEEDS_REVIEW---"
+	reviewEndMarker   = "---END_REVIEW---"
+)
+
+// parseReviewRequest extracts a review request and work-in-progress from the AI response
+// Returns (WorkInProgress, ReviewRequest, hasReview)
+// A review block that cannot be read still returns a review, with ParseError set, so the task waits for the user.
+func parseReviewRequest(response string) (string, *task.ReviewRequest, bool) {
+	before, rest, found := strings.Cut(response, reviewStartMarker)
+	if !found {
+		return "", nil, false
+	}
+	workInProgress := trimOpeningFence(strings.TrimSpace(before))
+
+	block, _, found := strings.Cut(rest, reviewEndMarker)
+	if !found {
+		return workInProgress, parseErrorReview(rest, errors.New("the review block has no "+reviewEndMarker+" marker")), true
+	}
+
+	review, err := parseReviewBlock(block)
+	if err != nil {
+		return workInProgress, parseErrorReview(block, err), true
+	}
+	review.Raw = block
+	return workInProgress, review, true
+}
+
+// parseReviewBlock parses the content between NEEDS_REVIEW markers.
+// The content is either a JSON object (optionally in a markdown code fence) or the line format.
+func parseReviewBlock(block string) (*task.ReviewRequest, error) {
+	content := unfence(block)
+	if strings.HasPrefix(content, "{") {
+		return parseReviewJSON(content)
+	}
+	return parseReviewLines(content)
+}
+
+// parseReviewLines parses the line format of a review block.
+// Each "Question:" line starts a new question; the Kind:, Default: and "- id:" option lines after it belong to that question.
+// Lines that follow a Context: line without a prefix of their own continue the context.
+func parseReviewLines(block string) (*task.ReviewRequest, error) {
+	var context []string
+	var questions []task.ReviewQuestion
+	var timeout time.Duration
+	inContext := false
+
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSpace(line)
+		current := len(questions) - 1
+		if text, ok := strings.CutPrefix(line, "Question:"); ok {
+			questions = append(questions, task.ReviewQuestion{
+				ID:   fmt.Sprintf("q%d", len(questions)+1),
+				Text: strings.TrimSpace(text),
+			})
+			inContext = false
+		} else if text, ok := strings.CutPrefix(line, "Context:"); ok {
+			context = append(context, strings.TrimSpace(text))
+			inContext = true
+		} else if text, ok := strings.CutPrefix(line, "Timeout:"); ok {
+			// An unreadable timeout is ignored, leaving the configured policy
+			if d, err := time.ParseDuration(strings.TrimSpace(text)); err == nil && d > 0 {
+				timeout = d
+			}
+			inContext = false
+		} else if current >= 0 && strings.HasPrefix(line, "Kind:") {
+			questions[current].Kind = task.QuestionKind(strings.TrimSpace(strings.TrimPrefix(line, "Kind:")))
+			inContext = false
+		} else if current >= 0 && strings.HasPrefix(line, "Default:") {
+			questions[current].Default = strings.TrimSpace(strings.TrimPrefix(line, "Default:"))
+			inContext = false
+		} else if current >= 0 && strings.HasPrefix(line, "- id:") {
+			if opt := parseOption(line); opt != nil {
+				questions[current].Options = append(questions[current].Options, *opt)
+			}
+			inContext = false
+		} else if inContext && line != "" {
+			context = append(context, line)
+		}
+	}
+
+	if len(questions) == 0 || questions[0].Text == "" {
+		return nil, errors.New("the review block has no Question: line")
+	}
+	for i := range questions {
+		normalizeQuestion(&questions[i])
+	}
+	review := newReview(strings.Join(context, "\n"), questions)
+	review.Timeout = timeout
+	return review, nil
+}
+
+// parseOption extracts an option from "- id: x | label: y" format.
+// Only the first "|" separates the ID from the label, so labels may contain "|".
+func parseOption(line string) *task.ReviewOption {
+	line, ok := strings.CutPrefix(line, "- id:")
+	if !ok {
+		return nil
+	}
+	id, labelPart, ok := strings.Cut(line, "|")
+	if !ok {
+		return nil
+	}
+	label, ok := strings.CutPrefix(strings.TrimSpace(labelPart), "label:")
+	if !ok {
+		return nil
+	}
+	return &task.ReviewOption{
+		ID:    strings.TrimSpace(id),
+		Label: strings.TrimSpace(label),
+	}
+}
+
+// normalizeQuestion settles a question's kind from the line format and drops a default that is not a valid answer.
+// A question without a kind is a single choice if it has options and free text otherwise.
+func normalizeQuestion(q *task.ReviewQuestion) {
+	switch q.Kind {
+	case task.YesNo:
+		q.Options = task.YesNoOptions()
+	case task.SingleChoice, task.MultiChoice:
+		if len(q.Options) == 0 {
+			q.Kind = task.FreeText
+		}
+	case task.FreeText:
+		q.Options = nil
+	default:
+		q.Kind = task.FreeText
+		if len(q.Options) > 0 {
+			q.Kind = task.SingleChoice
+		}
+	}
+
+	if q.Kind == task.FreeText || q.Default == "" {
+		return
+	}
+	defaults, err := checkDefault(q, strings.Split(q.Default, ","))
+	if err != nil {
+		q.Default = ""
+		return
+	}
+	q.Default = defaults
+}
+
+// checkDefault checks that the suggested answer of a choice question names its options,
+// and returns it as a comma-separated list of option IDs
+func checkDefault(q *task.ReviewQuestion, ids []string) (string, error) {
+	if q.Kind != task.MultiChoice && len(ids) > 1 {
+		return "", fmt.Errorf("a %s question can only suggest one answer", q.Kind)
+	}
+	for i, id := range ids {
+		ids[i] = strings.TrimSpace(id)
+		if !slices.ContainsFunc(q.Options, func(o task.ReviewOption) bool { return o.ID == ids[i] }) {
+			return "", fmt.Errorf("default %q is not one of its option ids", ids[i])
+		}
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// reviewJSON is the schema of a JSON review block.
+// A review with one question may put its fields at the top level instead of in questions.
+type reviewJSON struct {
+	Context   string               `json:"context"`
+	Timeout   string               `json:"timeout"` // A duration such as "30m" or "8h"
+	Questions []reviewQuestionJSON `json:"questions"`
+	reviewQuestionJSON
+}
+
+type reviewQuestionJSON struct {
+	ID       string              `json:"id"`
+	Kind     task.QuestionKind   `json:"kind"`
+	Question string              `json:"question"`
+	Options  []task.ReviewOption `json:"options"`
+	Default  defaultAnswer       `json:"default"`
+}
+
+// defaultAnswer is a suggested answer, written as a string, a list of option IDs or a boolean for yes/no questions
+type defaultAnswer []string
+
+func (d *defaultAnswer) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch v := value.(type) {
+	case nil:
+		*d = nil
+	case string:
+		*d = defaultAnswer{v}
+	case bool:
+		*d = defaultAnswer{"no"}
+		if v {
+			*d = defaultAnswer{"yes"}
+		}
+	case []any:
+		for _, item := range v {
+			id, ok := item.(string)
+			if !ok {
+				return errors.New("default must be a string, a list of strings or a boolean")
+			}
+			*d = append(*d, id)
+		}
+	default:
+		return errors.New("default must be a string, a list of strings or a boolean")
+	}
+	return nil
+}
+
+// parseReviewJSON parses and validates a JSON review block
+func parseReviewJSON(content string) (*task.ReviewRequest, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(content)))
+	decoder.DisallowUnknownFields()
+	var block reviewJSON
+	if err := decoder.Decode(&block); err != nil {
+		return nil, fmt.Errorf("invalid review JSON: %w", err)
+	}
+
+	asked := block.Questions
+	if block.Question != "" {
+		if len(asked) > 0 {
+			return nil, errors.New("invalid review JSON: use either question or questions, not both")
+		}
+		asked = []reviewQuestionJSON{block.reviewQuestionJSON}
+	}
+	if len(asked) == 0 {
+		return nil, errors.New("invalid review JSON: no questions")
+	}
+
+	questions := make([]task.ReviewQuestion, len(asked))
+	for i, q := range asked {
+		question, err := reviewQuestionFromJSON(q, i)
+		if err != nil {
+			return nil, fmt.Errorf("invalid review JSON: question %d: %w", i+1, err)
+		}
+		if slices.ContainsFunc(questions[:i], func(other task.ReviewQuestion) bool { return other.ID == question.ID }) {
+			return nil, fmt.Errorf("invalid review JSON: question %d: duplicate id %q", i+1, question.ID)
+		}
+		questions[i] = question
+	}
+	review := newReview(strings.TrimSpace(block.Context), questions)
+	if block.Timeout != "" {
+		timeout, err := time.ParseDuration(block.Timeout)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("invalid review JSON: timeout %q is not a positive duration such as \"30m\"", block.Timeout)
+		}
+		review.Timeout = timeout
+	}
+	return review, nil
+}
+
+// reviewQuestionFromJSON validates one question of a JSON review block
+func reviewQuestionFromJSON(q reviewQuestionJSON, index int) (task.ReviewQuestion, error) {
+	question := task.ReviewQuestion{
+		ID:      strings.TrimSpace(q.ID),
+		Kind:    q.Kind,
+		Text:    strings.TrimSpace(q.Question),
+		Options: q.Options,
+	}
+	if question.ID == "" {
+		question.ID = fmt.Sprintf("q%d", index+1)
+	}
+	if question.Text == "" {
+		return question, errors.New("question is empty")
+	}
+	if question.Kind == "" {
+		question.Kind = task.FreeText
+		if len(question.Options) > 0 {
+			question.Kind = task.SingleChoice
+		}
+	}
+
+	switch question.Kind {
+	case task.SingleChoice, task.MultiChoice:
+		if len(question.Options) == 0 {
+			return question, fmt.Errorf("a %s question needs options", question.Kind)
+		}
+		seen := map[string]bool{}
+		for _, option := range question.Options {
+			if option.ID == "" || option.Label == "" {
+				return question, errors.New("every option needs an id and a label")
+			}
+			if option.ID == task.OtherOptionID || seen[option.ID] {
+				return question, fmt.Errorf("option id %q is reserved or used twice", option.ID)
+			}
+			seen[option.ID] = true
+		}
+	case task.YesNo:
+		if len(question.Options) > 0 {
+			return question, errors.New("a yesno question cannot have options")
+		}
+		question.Options = task.YesNoOptions()
+	case task.FreeText:
+		if len(question.Options) > 0 {
+			return question, errors.New("a text question cannot have options")
+		}
+		if len(q.Default) > 1 {
+			return question, errors.New("a text question can only suggest one answer")
+		}
+		if len(q.Default) == 1 {
+			question.Default = q.Default[0]
+		}
+		return question, nil
+	default:
+		return question, fmt.Errorf("unknown kind %q (use single, multi, text or yesno)", question.Kind)
+	}
+
+	if len(q.Default) > 0 {
+		defaults, err := checkDefault(&question, q.Default)
+		if err != nil {
+			return question, err
+		}
+		question.Default = defaults
+	}
+	return question, nil
+}
+
+// newReview builds a review request from its parsed questions
+func newReview(context string, questions []task.ReviewQuestion) *task.ReviewRequest {
+	review := &task.ReviewRequest{
+		Question:  questions[0].Text,
+		Context:   context,
+		CreatedAt: time.Now(),
+		Questions: questions,
+	}
+	if questions[0].Kind == task.SingleChoice {
+		review.Options = questions[0].Options
+	}
+	return review
+}
+
+// parseErrorReview builds the review shown when the AI's review block could not be read.
+// Under the review timeout policy the AI is asked to repeat its question.
+func parseErrorReview(raw string, err error) *task.ReviewRequest {
+	question := task.ReviewQuestion{
+		ID:   "q1",
+		Kind: task.SingleChoice,
+		Text: "The AI asked for a review, but its review block could not be read. How should Ludwig continue?",
+		Options: []task.ReviewOption{
+			{ID: "ask-again", Label: "Ask the AI to repeat its question in the correct format"},
+			{ID: "continue", Label: "Continue the task without an answer"},
+		},
+		Default: "ask-again",
+	}
+	return &task.ReviewRequest{
+		Question:   question.Text,
+		Context:    "Parse error: " + err.Error(),
+		Options:    question.Options,
+		CreatedAt:  time.Now(),
+		Questions:  []task.ReviewQuestion{question},
+		Raw:        raw,
+		ParseError: err.Error(),
+	}
+}
+
+// unfence removes the whitespace and markdown code fence around a block
+func unfence(block string) string {
+	block = strings.TrimSpace(block)
+	if !strings.HasPrefix(block, "```") {
+		return block
+	}
+	// Drop the opening fence line, with its optional language tag, and the closing fence
+	_, block, _ = strings.Cut(block, "\n")
+	return strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(block), "```"))
+}
+
+// trimOpeningFence drops a code fence opened on the last line of s,
+// left behind when the AI wraps the whole review block in a fence
+func trimOpeningFence(s string) string {
+	lastLine := s[strings.LastIndex(s, "\n")+1:]
+	if strings.HasPrefix(strings.TrimSpace(lastLine), "```") {
+		return strings.TrimSpace(strings.TrimSuffix(s, lastLine))
+	}
+	return s
+}
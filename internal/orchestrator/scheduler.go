@@ -0,0 +1,197 @@
+package orchestrator
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator/clients"
+	"ludwig/internal/types/task"
+)
+
+// knownProviders are the AI providers a task can be assigned to
+var knownProviders = []string{"gemini", "ollama", "openai", "copilot", "claude", "codex", "aider", "command"}
+
+// IsKnownProvider reports whether name is an AI provider the orchestrator can run
+func IsKnownProvider(name string) bool {
+	return slices.Contains(knownProviders, name)
+}
+
+// taskProvider returns the AI provider a task runs on: its own choice, or the configured default
+func taskProvider(cfg *config.Config, t *task.Task) string {
+	if t.Provider != "" {
+		return t.Provider
+	}
+	return cfg.Provider()
+}
+
+// newAIClient creates the client for a provider using the provider settings from cfg
+func newAIClient(cfg *config.Config, provider string) clients.AIClient {
+	switch provider {
+	case "ollama":
+		var baseURL, model string
+		if cfg != nil {
+			baseURL, model = cfg.OllamaBaseURL, cfg.OllamaModel
+		}
+		client := clients.NewOllamaClient(baseURL, model)
+		if cfg != nil && cfg.OllamaMaxSteps > 0 {
+			client.MaxSteps = cfg.OllamaMaxSteps
+		}
+		client.CommitEnv = cfg.CommitEnv()
+		return client
+	case "openai":
+		var baseURL, model string
+		if cfg != nil {
+			baseURL, model = cfg.OpenAIBaseURL, cfg.OpenAIModel
+		}
+		client := clients.NewOpenAIClient(baseURL, model, cfg.OpenAIAPIKey())
+		if cfg != nil && cfg.OpenAIMaxSteps > 0 {
+			client.MaxSteps = cfg.OpenAIMaxSteps
+		}
+		client.CommitEnv = cfg.CommitEnv()
+		return client
+	case "copilot":
+		var model string
+		if cfg != nil {
+			model = cfg.CopilotModel
+		}
+		return clients.NewCopilotClient(model)
+	case "claude":
+		cli := cfg.AgentCLI(provider)
+		return clients.NewClaudeClient(cli.Binary, cli.Model, cli.Args)
+	case "codex":
+		cli := cfg.AgentCLI(provider)
+		return clients.NewCodexClient(cli.Binary, cli.Model, cli.Args)
+	case "aider":
+		cli := cfg.AgentCLI(provider)
+		return clients.NewAiderClient(cli.Binary, cli.Model, cli.Args)
+	case "command":
+		if cfg == nil {
+			return clients.NewCommandClient("", nil)
+		}
+		command := cfg.Command
+		client := clients.NewCommandClient(command.Binary, command.Args)
+		client.Model = command.Model
+		client.PromptOnStdin = command.PromptVia == "stdin"
+		client.RateLimitExitCodes = command.RateLimitExitCodes
+		if command.Output != "" {
+			client.Output = command.Output
+		}
+		if command.TextField != "" {
+			client.TextField = command.TextField
+		}
+		return client
+	default:
+		// Default to Gemini
+		return &clients.GeminiClient{}
+	}
+}
+
+// scheduler hands out worker slots, bounded by a global limit and an optional limit per provider.
+// Limits come from the config and can change while tasks run; lowering a limit never interrupts
+// a running task, it only holds back new ones until usage drops below it.
+type scheduler struct {
+	mu      sync.Mutex
+	cfg     *config.Config
+	total   int
+	running map[string]int // Running tasks by provider
+}
+
+// slots is the orchestrator's scheduler
+var slots = &scheduler{running: make(map[string]int)}
+
+// setConfig applies the limits from a freshly loaded config
+func (s *scheduler) setConfig(cfg *config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg = cfg
+}
+
+// acquire takes a slot for a task on provider, returning false if either limit is reached
+func (s *scheduler) acquire(provider string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.total >= s.cfg.ParallelTasks() {
+		return false
+	}
+	if limit := s.cfg.ProviderLimit(provider); limit > 0 && s.running[provider] >= limit {
+		return false
+	}
+	s.total++
+	s.running[provider]++
+	return true
+}
+
+// release frees a slot taken by acquire
+func (s *scheduler) release(provider string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.total--
+	if s.running[provider]--; s.running[provider] <= 0 {
+		delete(s.running, provider)
+	}
+}
+
+// ProviderSlots is the slot usage of a single provider
+type ProviderSlots struct {
+	Provider string
+	Running  int
+	Limit    int // 0 if only the global limit applies
+}
+
+// SlotUsage is a snapshot of how many worker slots are in use
+type SlotUsage struct {
+	Running   int
+	Limit     int
+	Providers []ProviderSlots // Providers that are limited or have running tasks, by name
+}
+
+// String formats the usage for the kanban footer, e.g. "Slots 2/3 · copilot 1 · ollama 1/1"
+func (u SlotUsage) String() string {
+	parts := []string{fmt.Sprintf("Slots %d/%d", u.Running, u.Limit)}
+	for _, p := range u.Providers {
+		if p.Limit > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d/%d", p.Provider, p.Running, p.Limit))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %d", p.Provider, p.Running))
+		}
+	}
+	return strings.Join(parts, " · ")
+}
+
+// usage returns a snapshot of the scheduler's slot usage
+func (s *scheduler) usage() SlotUsage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	providers := make(map[string]bool)
+	for provider := range s.running {
+		providers[provider] = true
+	}
+	for provider := range config.DefaultProviderConcurrency {
+		providers[provider] = true
+	}
+	if s.cfg != nil {
+		for provider := range s.cfg.ProviderConcurrency {
+			providers[provider] = true
+		}
+	}
+
+	usage := SlotUsage{Running: s.total, Limit: s.cfg.ParallelTasks()}
+	for _, provider := range slices.Sorted(maps.Keys(providers)) {
+		limit := s.cfg.ProviderLimit(provider)
+		if limit == 0 && s.running[provider] == 0 {
+			continue
+		}
+		usage.Providers = append(usage.Providers, ProviderSlots{Provider: provider, Running: s.running[provider], Limit: limit})
+	}
+	return usage
+}
+
+// Slots returns the current worker slot usage and limits
+func Slots() SlotUsage {
+	return slots.usage()
+}
@@ -0,0 +1,144 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator/clients"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// verifyOutputLimit caps how much of a verification command's output is logged and sent back to the AI
+const verifyOutputLimit = 8000
+
+// verifyFailure is returned by runVerification when a verification command fails
+type verifyFailure struct {
+	step   config.VerifyStep
+	output string
+	err    error
+}
+
+func (f *verifyFailure) Error() string {
+	return fmt.Sprintf("%s check failed (%s): %v", f.step.Name, f.step.Command, f.err)
+}
+
+// runVerification runs the verification steps in the worktree in order, stopping at the first failure.
+// Each command and its output are logged as a tool call and result, so they appear in the response log.
+// Returns a *verifyFailure if a command failed, or the cancellation cause if ctx was cancelled.
+func runVerification(ctx context.Context, worktreePath string, steps []config.VerifyStep, onEvent clients.EventHandler) error {
+	for i, step := range steps {
+		toolID := fmt.Sprintf("verify-%d-%d", time.Now().UnixNano(), i)
+		onEvent(clients.Event{
+			Type:       clients.EventToolCall,
+			Timestamp:  time.Now(),
+			ToolID:     toolID,
+			ToolName:   "verify " + step.Name,
+			Parameters: map[string]any{"command": step.Command},
+		})
+
+		output, err := clients.NewShellCommand(ctx, worktreePath, step.Command).CombinedOutput()
+		result := clients.Event{
+			Type:      clients.EventToolResult,
+			Timestamp: time.Now(),
+			ToolID:    toolID,
+			Status:    "success",
+			Output:    tail(string(output), verifyOutputLimit),
+		}
+		if err != nil {
+			result.Status = "error"
+		}
+		onEvent(result)
+
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		if err != nil {
+			return &verifyFailure{step: step, output: result.Output, err: err}
+		}
+	}
+	return nil
+}
+
+// verifyAndComplete finishes a task whose AI run succeeded.
+// - The configured verification commands run in the task's worktree
+// - While a command fails and rounds remain, its output is sent back to the AI to fix
+// - If the AI asks a question while fixing, the task moves to In Review instead
+// - The task is then Completed and its worktree removed, or Failed if verification still fails
+// - If approval is required, the task waits in Awaiting Approval with its worktree kept instead of completing
+func verifyAndComplete(ctx context.Context, taskStore storage.TaskStorage, aiClient clients.AIClient, cfg *config.Config, t *task.Task, onEvent clients.EventHandler, requeueStatus task.Status) {
+	steps := cfg.VerifySteps()
+	for round := 0; len(steps) > 0 && t.WorktreePath != ""; round++ {
+		err := runVerification(ctx, t.WorktreePath, steps, onEvent)
+		if err == nil {
+			break
+		}
+		var failure *verifyFailure
+		if !errors.As(err, &failure) {
+			handleInterruptedTask(taskStore, cfg, t, err, "", requeueStatus)
+			return
+		}
+		if round >= cfg.VerifyRounds() {
+			failVerification(taskStore, cfg, t, failure)
+			return
+		}
+
+		prompt := BuildVerifyFixPrompt(t.Name, failure.step.Name, failure.step.Command, failure.output)
+		response, err := aiClient.StreamPromptWithDir(ctx, prompt, t.WorktreePath, onEvent)
+		if err != nil {
+			if ctx.Err() != nil {
+				handleInterruptedTask(taskStore, cfg, t, context.Cause(ctx), response, requeueStatus)
+				return
+			}
+			handleFailedAttempt(taskStore, t, cfg, err, response, requeueStatus)
+			return
+		}
+		if pauseForReview(taskStore, t, response) {
+			return
+		}
+	}
+	finishAttempt(t, nil)
+
+	if cfg != nil && cfg.RequireApproval && t.WorktreePath != "" {
+		// Squashing waits for approval, since rejected work continues on the branch
+		_ = CommitTaskChanges(cfg, t)
+		t.Status = task.AwaitingApproval
+		_ = saveTask(taskStore, t)
+		return
+	}
+
+	// Commit any uncommitted work before removing worktree, so the branch is final once the task is Completed
+	if t.WorktreePath != "" {
+		_ = finishTaskCommits(cfg, t)
+		_ = RemoveWorktree(t.WorktreePath)
+		t.WorktreePath = ""
+	}
+
+	t.Status = task.Completed
+	// ResponseFile already set when streaming started
+	_ = saveTask(taskStore, t)
+}
+
+// failVerification marks a task Failed after its verification rounds ran out.
+// The worktree is kept, and the failing output is kept as work-in-progress for a manual retry.
+func failVerification(taskStore storage.TaskStorage, cfg *config.Config, t *task.Task, failure *verifyFailure) {
+	finishAttempt(t, failure)
+	_ = CommitPartialChanges(cfg, t.WorktreePath, t.ID, "verification failed")
+	appendWorkInProgress(t, fmt.Sprintf("The %s check (%s) still failed:\n%s", failure.step.Name, failure.step.Command, failure.output))
+
+	t.FailureCount++
+	t.Status = task.Failed
+	t.NextAttemptAt = time.Time{}
+	_ = saveTask(taskStore, t)
+}
+
+// tail returns at most limit bytes from the end of s
+func tail(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	return "...\n" + s[len(s)-limit:]
+}
@@ -1,25 +1,39 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
+
+	"ludwig/internal/utils"
 )
 
 const ludwigDir = ".ludwig"
 
-// getLudwigDirPath returns the path to the .ludwig directory within the current working directory.
+// getLudwigDirPath returns the path to the .ludwig directory at the repository root,
+// or in the current working directory outside a repository.
 func getLudwigDirPath() (string, error) {
-	cwd, err := os.Getwd()
+	projectDir, err := utils.ProjectDir()
 	if err != nil {
 		return "", fmt.Errorf("failed to get current working directory: %w", err)
 	}
-	ludwigPath := filepath.Join(cwd, ludwigDir)
+	ludwigPath := filepath.Join(projectDir, ludwigDir)
 	return ludwigPath, nil
 }
 
+// ResponseFilePath returns the full path of a response file stored relative to .ludwig
+func ResponseFilePath(filePath string) string {
+	ludwigPath, err := getLudwigDirPath()
+	if err != nil {
+		return filepath.Join(ludwigDir, filePath)
+	}
+	return filepath.Join(ludwigPath, filePath)
+}
+
 // ResponseWriter streams AI responses to a file
 type ResponseWriter struct {
 	mu       sync.Mutex
@@ -152,3 +166,61 @@ func ReadResponse(filePath string) (string, error) {
 
 	return string(content), nil
 }
+
+// ListResponseFiles returns every response file in .ludwig/responses, as paths relative to .ludwig
+func ListResponseFiles() ([]string, error) {
+	ludwigPath, err := getLudwigDirPath()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(filepath.Join(ludwigPath, "responses"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .ludwig/responses directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".md") {
+			files = append(files, filepath.Join("responses", entry.Name()))
+		}
+	}
+	return files, nil
+}
+
+// ResponseTaskID returns the ID of the task a response file was written for, taken from its name
+func ResponseTaskID(filePath string) string {
+	name := strings.TrimSuffix(filepath.Base(filePath), ".md")
+	// Names end with the "-20060102-150405" timestamp added by NewResponseWriter
+	const timestampLength = len("-20060102-150405")
+	if len(name) <= timestampLength {
+		return name
+	}
+	return name[:len(name)-timestampLength]
+}
+
+// ArchiveResponse moves a response file into .ludwig/responses/archive
+func ArchiveResponse(filePath string) error {
+	ludwigPath, err := getLudwigDirPath()
+	if err != nil {
+		return err
+	}
+
+	archiveDir := filepath.Join(ludwigPath, "responses", "archive")
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return fmt.Errorf("failed to create .ludwig/responses/archive directory: %w", err)
+	}
+	return os.Rename(filepath.Join(ludwigPath, filePath), filepath.Join(archiveDir, filepath.Base(filePath)))
+}
+
+// DeleteResponse removes a response file
+func DeleteResponse(filePath string) error {
+	ludwigPath, err := getLudwigDirPath()
+	if err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(ludwigPath, filePath))
+}
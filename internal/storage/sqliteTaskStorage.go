@@ -0,0 +1,307 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"ludwig/internal/types/task"
+
+	_ "modernc.org/sqlite" // Pure-Go SQLite driver, registered as "sqlite"
+)
+
+// SQLiteTaskStorage stores tasks in an embedded SQLite database, .ludwig/tasks.db.
+// Each task is kept as JSON next to the indexed columns used for queries,
+// so new task fields do not need a schema migration.
+type SQLiteTaskStorage struct {
+	db *sql.DB
+}
+
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id         TEXT PRIMARY KEY,
+	status     INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	version    INTEGER NOT NULL,
+	data       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_tasks_status ON tasks(status, created_at);
+`
+
+// sqliteMigrations upgrade the schema in order; PRAGMA user_version records how many have been applied
+var sqliteMigrations = []string{
+	// Queue order
+	`ALTER TABLE tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE tasks ADD COLUMN queue_position INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX IF NOT EXISTS idx_tasks_queue ON tasks(priority DESC, queue_position, created_at);`,
+}
+
+// NewSQLiteTaskStorage opens (creating if needed) the task database.
+// On first use, tasks from an existing .ludwig/tasks.json are imported.
+func NewSQLiteTaskStorage() (*SQLiteTaskStorage, error) {
+	ludwigPath, err := getLudwigDirPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(ludwigPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create .ludwig directory: %w", err)
+	}
+
+	// WAL lets the TUI read while the orchestrator writes; immediate transactions take the
+	// write lock up front so concurrent read-modify-write cycles queue instead of failing
+	dsn := filepath.Join(ludwigPath, "tasks.db") + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open task database: %w", err)
+	}
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create task database schema: %w", err)
+	}
+
+	s := &SQLiteTaskStorage{db: db}
+	if err := s.migrateSchema(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade task database schema: %w", err)
+	}
+	if err := s.migrateFromJSON(filepath.Join(ludwigPath, "tasks.json")); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database
+func (s *SQLiteTaskStorage) Close() error {
+	return s.db.Close()
+}
+
+// migrateSchema applies the schema migrations the database has not seen yet
+func (s *SQLiteTaskStorage) migrateSchema() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var applied int
+	if err := tx.QueryRow("PRAGMA user_version").Scan(&applied); err != nil {
+		return err
+	}
+	for i := applied; i < len(sqliteMigrations); i++ {
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			return fmt.Errorf("migration %d: %w", i+1, err)
+		}
+	}
+	if applied < len(sqliteMigrations) {
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", len(sqliteMigrations))); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// migrateFromJSON imports the tasks from a tasks.json written by FileTaskStorage.
+// The file is renamed to tasks.json.migrated afterwards so it is only imported once.
+func (s *SQLiteTaskStorage) migrateFromJSON(jsonPath string) error {
+	data, err := os.ReadFile(jsonPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s for migration: %w", jsonPath, err)
+	}
+
+	tasks := make(map[string]*task.Task)
+	if len(strings.TrimSpace(string(data))) > 0 {
+		if err := json.Unmarshal(data, &tasks); err != nil {
+			return fmt.Errorf("failed to parse %s for migration: %w", jsonPath, err)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, t := range tasks {
+		if t.Version == 0 {
+			t.Version = 1
+		}
+		// Another process may have migrated the same file concurrently
+		if err := writeTask(tx, "INSERT OR IGNORE", t); err != nil {
+			return fmt.Errorf("failed to migrate task %s: %w", t.ID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(jsonPath, jsonPath+".migrated"); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to rename %s after migration: %w", jsonPath, err)
+	}
+	return nil
+}
+
+// writeTask inserts a task row using the given INSERT verb (e.g. "INSERT OR REPLACE")
+func writeTask(tx *sql.Tx, verb string, t *task.Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(verb+" INTO tasks (id, status, created_at, version, priority, queue_position, data) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		t.ID, int(t.Status), t.CreatedAt.UnixMicro(), t.Version, t.Priority, t.QueuePosition, string(data))
+	return err
+}
+
+// storedVersion returns the saved version of a task, or ErrTaskNotFound
+func storedVersion(tx *sql.Tx, id string) (int, error) {
+	var version int
+	err := tx.QueryRow("SELECT version FROM tasks WHERE id = ?", id).Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrTaskNotFound
+	}
+	return version, err
+}
+
+// queryTasks runs a query selecting the data column and decodes each row
+func (s *SQLiteTaskStorage) queryTasks(query string, args ...any) ([]*task.Task, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []*task.Task{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var t task.Task
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			return nil, fmt.Errorf("failed to decode stored task: %w", err)
+		}
+		tasks = append(tasks, &t)
+	}
+	return tasks, rows.Err()
+}
+
+// AddTask adds a new task to storage.
+func (s *SQLiteTaskStorage) AddTask(t *task.Task) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stored := *t
+	stored.Version = 1
+	if version, err := storedVersion(tx, t.ID); err == nil {
+		stored.Version = version + 1
+	} else if !errors.Is(err, ErrTaskNotFound) {
+		return err
+	}
+	if stored.QueuePosition == 0 {
+		if err := tx.QueryRow("SELECT COALESCE(MAX(queue_position), 0) + 1 FROM tasks").Scan(&stored.QueuePosition); err != nil {
+			return err
+		}
+	}
+	if err := writeTask(tx, "INSERT OR REPLACE", &stored); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	t.Version = stored.Version
+	t.QueuePosition = stored.QueuePosition
+	return nil
+}
+
+// GetTask retrieves a task by ID.
+func (s *SQLiteTaskStorage) GetTask(id string) (*task.Task, error) {
+	tasks, err := s.queryTasks("SELECT data FROM tasks WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(tasks) == 0 {
+		return nil, ErrTaskNotFound
+	}
+	return tasks[0], nil
+}
+
+// ListTasks returns all tasks in queue order.
+func (s *SQLiteTaskStorage) ListTasks() ([]*task.Task, error) {
+	return s.queryQueue("SELECT data FROM tasks")
+}
+
+// ListTasksByStatus returns the tasks whose status is one of statuses, in queue order.
+// The query uses the status index, so polling for work stays fast with a long task history.
+func (s *SQLiteTaskStorage) ListTasksByStatus(statuses ...task.Status) ([]*task.Task, error) {
+	if len(statuses) == 0 {
+		return []*task.Task{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(statuses)), ", ")
+	args := make([]any, len(statuses))
+	for i, status := range statuses {
+		args[i] = int(status)
+	}
+	return s.queryQueue("SELECT data FROM tasks WHERE status IN ("+placeholders+")", args...)
+}
+
+// queryQueue runs a task query and sorts the result with task.QueueOrder,
+// so both backends list tasks in exactly the same order
+func (s *SQLiteTaskStorage) queryQueue(query string, args ...any) ([]*task.Task, error) {
+	tasks, err := s.queryTasks(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(tasks, task.QueueOrder)
+	return tasks, nil
+}
+
+// UpdateTask updates an existing task in a single transaction.
+// The version check behaves exactly like FileTaskStorage.UpdateTask.
+func (s *SQLiteTaskStorage) UpdateTask(t *task.Task) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	version, err := storedVersion(tx, t.ID)
+	if err != nil {
+		return err
+	}
+	if t.Version != 0 && t.Version != version {
+		return fmt.Errorf("%w: task %s is at version %d, update was based on version %d", ErrStaleTask, t.ID, version, t.Version)
+	}
+
+	stored := *t
+	stored.Version = version + 1
+	if err := writeTask(tx, "INSERT OR REPLACE", &stored); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	t.Version = stored.Version
+	return nil
+}
+
+// DeleteTask removes a task from storage by ID.
+func (s *SQLiteTaskStorage) DeleteTask(id string) error {
+	result, err := s.db.Exec("DELETE FROM tasks WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
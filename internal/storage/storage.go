@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"ludwig/internal/types/task"
+)
+
+// TaskStorage persists tasks for the TUI and the orchestrator.
+// Implementations must be safe for concurrent use, including from separate processes
+// sharing the same .ludwig directory, and must enforce the optimistic version check
+// described on FileTaskStorage.UpdateTask.
+type TaskStorage interface {
+	AddTask(t *task.Task) error
+	GetTask(id string) (*task.Task, error)
+	ListTasks() ([]*task.Task, error)
+	ListTasksByStatus(statuses ...task.Status) ([]*task.Task, error)
+	UpdateTask(t *task.Task) error
+	DeleteTask(id string) error
+	Close() error
+}
+
+// ErrTaskNotFound is returned when a task ID does not exist in storage
+var ErrTaskNotFound = errors.New("task not found")
+
+// Storage backends selectable with the storageBackend config option
+const (
+	BackendJSON   = "json"   // .ludwig/tasks.json (default)
+	BackendSQLite = "sqlite" // .ludwig/tasks.db
+)
+
+// Open returns the task storage for the given backend name.
+// An empty name selects the JSON backend.
+func Open(backend string) (TaskStorage, error) {
+	switch backend {
+	case "", BackendJSON:
+		return NewFileTaskStorage()
+	case BackendSQLite:
+		return NewSQLiteTaskStorage()
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q (expected %q or %q)", backend, BackendJSON, BackendSQLite)
+	}
+}
+
+// filterByStatus returns the tasks whose status is one of statuses
+func filterByStatus(tasks []*task.Task, statuses []task.Status) []*task.Task {
+	filtered := make([]*task.Task, 0, len(tasks))
+	for _, t := range tasks {
+		for _, status := range statuses {
+			if t.Status == status {
+				filtered = append(filtered, t)
+				break
+			}
+		}
+	}
+	return filtered
+}
@@ -6,11 +6,16 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"ludwig/internal/types/task"
 )
 
+// ErrStaleTask is returned by UpdateTask when the task was changed by someone else since it was read
+var ErrStaleTask = errors.New("task was modified since it was read")
+
+// FileTaskStorage stores every task in a single JSON file, .ludwig/tasks.json
 type FileTaskStorage struct {
 	mu       sync.Mutex
 	filePath string
@@ -56,61 +61,81 @@ func (s *FileTaskStorage) load() error {
 	return nil
 }
 
-// save writes the in-memory tasks to the JSON file with file locking for atomicity.
+// save writes the in-memory tasks to a temp file and renames it over the JSON file,
+// so readers never see a partially written file. Callers must hold the file lock and s.mu.
 func (s *FileTaskStorage) save() error {
 	dir := filepath.Dir(s.filePath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
-	// Use a lock file for atomic write
-	lockPath := s.filePath + ".lock"
-	lockFile, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0666)
+	tmp, err := os.CreateTemp(dir, "tasks-*.json.tmp")
 	if err != nil {
 		return err
 	}
-	defer func() {
-		lockFile.Close()
-		os.Remove(lockPath)
-	}()
-	if err := lockFileLock(lockFile); err != nil {
+	defer os.Remove(tmp.Name()) // No-op once the rename succeeded
+
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s.tasks); err != nil {
+		tmp.Close()
 		return err
 	}
-	file, err := os.Create(s.filePath)
-	if err != nil {
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
 		return err
 	}
-	defer file.Close()
-	enc := json.NewEncoder(file)
-	enc.SetIndent("", "  ")
-	err = enc.Encode(s.tasks)
-	lockFileUnlock(lockFile)
-	return err
-}
-
-// lockFileLock acquires an exclusive lock on the file (Unix only)
-func lockFileLock(f *os.File) error {
-	// Use syscall for file locking
-	// #nosec G307
-	return nil // Implement with syscall.Flock if needed
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.filePath)
 }
 
-// lockFileUnlock releases the lock
-func lockFileUnlock(f *os.File) error {
-	return nil // Implement with syscall.Flock if needed
-}
+// mutate runs a load-modify-save cycle while holding an exclusive lock on tasks.json.lock.
+// The lock is shared with every other process using the same .ludwig directory,
+// so concurrent writers never lose each other's updates.
+func (s *FileTaskStorage) mutate(modify func(tasks map[string]*task.Task) error) error {
+	lockFile, err := os.OpenFile(s.filePath+".lock", os.O_CREATE|os.O_RDWR, 0666)
+	if err != nil {
+		return err
+	}
+	defer lockFile.Close()
+	if err := lockFileLock(lockFile); err != nil {
+		return fmt.Errorf("failed to lock task storage: %w", err)
+	}
+	defer lockFileUnlock(lockFile)
 
-// AddTask adds a new task to storage and saves it.
-func (s *FileTaskStorage) AddTask(task *task.Task) error {
-	// Reload from disk before adding
 	if err := s.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
 	s.mu.Lock()
-	s.tasks[task.ID] = task
-	s.mu.Unlock()
+	defer s.mu.Unlock()
+	if err := modify(s.tasks); err != nil {
+		return err
+	}
 	return s.save()
 }
 
+// AddTask adds a new task to storage and saves it.
+func (s *FileTaskStorage) AddTask(t *task.Task) error {
+	return s.mutate(func(tasks map[string]*task.Task) error {
+		stored := *t
+		stored.Version = 1
+		if existing, ok := tasks[t.ID]; ok {
+			stored.Version = existing.Version + 1
+		}
+		if stored.QueuePosition == 0 {
+			for _, other := range tasks {
+				stored.QueuePosition = max(stored.QueuePosition, other.QueuePosition)
+			}
+			stored.QueuePosition++
+		}
+		tasks[t.ID] = &stored
+		t.Version = stored.Version
+		t.QueuePosition = stored.QueuePosition
+		return nil
+	})
+}
+
 // GetTask retrieves a task by ID.
 func (s *FileTaskStorage) GetTask(id string) (*task.Task, error) {
 	if err := s.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
@@ -120,12 +145,12 @@ func (s *FileTaskStorage) GetTask(id string) (*task.Task, error) {
 	defer s.mu.Unlock()
 	task, ok := s.tasks[id]
 	if !ok {
-		return nil, errors.New("task not found")
+		return nil, ErrTaskNotFound
 	}
 	return task, nil
 }
 
-// ListTasks returns all tasks from storage.
+// ListTasks returns all tasks from storage in queue order.
 func (s *FileTaskStorage) ListTasks() ([]*task.Task, error) {
 	if err := s.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
 		return nil, err
@@ -136,35 +161,75 @@ func (s *FileTaskStorage) ListTasks() ([]*task.Task, error) {
 	for _, t := range s.tasks {
 		tasks = append(tasks, t)
 	}
+	slices.SortFunc(tasks, task.QueueOrder)
 	return tasks, nil
 }
 
-// UpdateTask updates an existing task in storage and saves it.
-func (s *FileTaskStorage) UpdateTask(task *task.Task) error {
-	if err := s.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
-		return err
-	}
-	s.mu.Lock()
-	if _, ok := s.tasks[task.ID]; !ok {
-		s.mu.Unlock()
-		return errors.New("task not found")
+// ListTasksByStatus returns the tasks whose status is one of statuses, in queue order.
+func (s *FileTaskStorage) ListTasksByStatus(statuses ...task.Status) ([]*task.Task, error) {
+	tasks, err := s.ListTasks()
+	if err != nil {
+		return nil, err
 	}
-	s.tasks[task.ID] = task
-	s.mu.Unlock()
-	return s.save()
+	return filterByStatus(tasks, statuses), nil
+}
+
+// UpdateTask updates an existing task in storage and saves it.
+// If t.Version is set and no longer matches the stored version, the task was changed
+// since it was read and ErrStaleTask is returned; use MergeTask to reconcile the two.
+// A Version of 0 is a blind overwrite. On success t.Version is advanced to the saved version.
+func (s *FileTaskStorage) UpdateTask(t *task.Task) error {
+	return s.mutate(func(tasks map[string]*task.Task) error {
+		current, ok := tasks[t.ID]
+		if !ok {
+			return ErrTaskNotFound
+		}
+		if t.Version != 0 && t.Version != current.Version {
+			return fmt.Errorf("%w: task %s is at version %d, update was based on version %d", ErrStaleTask, t.ID, current.Version, t.Version)
+		}
+		stored := *t
+		stored.Version = current.Version + 1
+		tasks[t.ID] = &stored
+		t.Version = stored.Version
+		return nil
+	})
 }
 
 // DeleteTask removes a task from storage by ID and saves the change.
 func (s *FileTaskStorage) DeleteTask(id string) error {
-	if err := s.load(); err != nil && !errors.Is(err, os.ErrNotExist) {
-		return err
+	return s.mutate(func(tasks map[string]*task.Task) error {
+		if _, ok := tasks[id]; !ok {
+			return ErrTaskNotFound
+		}
+		delete(tasks, id)
+		return nil
+	})
+}
+
+// Close releases the storage; the JSON file is not held open between operations, so there is nothing to release
+func (s *FileTaskStorage) Close() error {
+	return nil
+}
+
+// MergeTask reconciles a stale write with the stored copy of a task.
+// The writer's changes win, except for input the user made since the writer read the task:
+// - A rename of the task
+// - A change to its priority, queue position or dependencies
+// - An answer to the review the writer's copy is still waiting on
+// The result carries the stored version, so it can be passed straight back to UpdateTask.
+func MergeTask(stored *task.Task, stale *task.Task) *task.Task {
+	merged := *stale
+	merged.Version = stored.Version
+
+	if stored.Name != "" {
+		merged.Name = stored.Name
 	}
-	s.mu.Lock()
-	if _, ok := s.tasks[id]; !ok {
-		s.mu.Unlock()
-		return errors.New("task not found")
+	// The queue order and dependencies are only changed by the user
+	merged.Priority = stored.Priority
+	merged.QueuePosition = stored.QueuePosition
+	merged.DependsOn = stored.DependsOn
+	if merged.ReviewResponse == nil && stored.ReviewResponse != nil && stored.Review.SameRequest(merged.Review) {
+		merged.ReviewResponse = stored.ReviewResponse
 	}
-	delete(s.tasks, id)
-	s.mu.Unlock()
-	return s.save()
+	return &merged
 }
@@ -1,11 +1,16 @@
 package model
 
 import (
+	"ludwig/internal/components/approvalView"
+	"ludwig/internal/components/diffView"
+	"ludwig/internal/components/reviewForm"
+	"ludwig/internal/config"
 	"ludwig/internal/utils"
 	"ludwig/internal/storage"
 	"ludwig/internal/types/task"
 	"ludwig/internal/orchestrator"
 
+	"errors"
 	"strings"
 	"time"
 	"strconv"
@@ -15,22 +20,45 @@ import (
 	"github.com/charmbracelet/bubbles/table"
 )
 
-func PalleteCommands(taskStore *storage.FileTaskStorage) []Command {
+func PalleteCommands(taskStore storage.TaskStorage) []Command {
 	actions := []Command {
 		{
 			Text: "add",
 			Action: func(text string, m *Model) string {
 				parts := strings.Fields(text)
-				if !checkArgumentsCountMin(2, parts, true) {
-					return "Usage: add <task description> - Add a new task. Tasks can be multiple words. No quotation marks needed."
+				flags, words := parseFlags(parts[1:])
+				if len(words) == 0 {
+					return "Usage: add [--provider=<name>] [--depends=<ref>,...] [--base=<branch>] <task description> - Add a new task. Tasks can be multiple words. No quotation marks needed."
+				}
+				provider := flags["provider"]
+				if provider != "" && !orchestrator.IsKnownProvider(provider) {
+					return "Unknown provider: " + provider
+				}
+				base := flags["base"]
+				if base != "" {
+					if exists, _ := orchestrator.BranchExists(base); !exists {
+						return "Unknown base branch: " + base
+					}
 				}
 
-				// skip the first part which is the command itself
 				newTask := &task.Task{
-					Name: strings.Join(parts[1:], " "),
+					Name: strings.Join(words, " "),
 					Status: task.Pending,
 					ID: uuid.New().String(),
 					CreatedAt: time.Now(),
+					Provider: provider,
+					BaseBranch: base,
+				}
+
+				if refs := flags["depends"]; refs != "" {
+					dependsOn, errMsg := taskIDsFromRefs(taskStore, strings.Split(refs, ","))
+					if errMsg != "" {
+						return errMsg
+					}
+					if err := orchestrator.ValidateDependencies(taskStore, newTask.ID, dependsOn); err != nil {
+						return "Invalid dependencies: " + err.Error()
+					}
+					newTask.DependsOn = dependsOn
 				}
 
 				if err := taskStore.AddTask(newTask); err != nil {
@@ -39,7 +67,7 @@ func PalleteCommands(taskStore *storage.FileTaskStorage) []Command {
 				}
 				return "Added new task: " + newTask.Name
 			},
-			Description: "add <task description> - Add a new task. Tasks can be multiple words. No quotation marks needed.",
+			Description: "add [--provider=<name>] [--depends=<ref>,...] [--base=<branch>] <task description> - Add a new task. Tasks can be multiple words. No quotation marks needed. --provider runs it on a specific AI provider instead of the configured default. --depends waits for the given tasks to complete and starts from their branches. --base starts the task from another branch than the configured base branch and merges it back there.",
 		},
 		{
 			Text: "delete",
@@ -78,7 +106,12 @@ func PalleteCommands(taskStore *storage.FileTaskStorage) []Command {
 				if !checkArgumentsCount(1, parts) {
 					return "Usage: start method takes no arguments"
 				}
-				orchestrator.Start()
+				if err := orchestrator.Start(); err != nil {
+					return "Error starting orchestrator: " + err.Error()
+				}
+				if summary := orchestrator.LastRecovery().Summary(); summary != "" {
+					return "AI Orchestrator started. " + summary
+				}
 				return "AI Orchestrator started."
 			},
 			Description: "start - Start the AI Orchestrator",
@@ -97,6 +130,312 @@ func PalleteCommands(taskStore *storage.FileTaskStorage) []Command {
 			},
 			Description: "stop - Stop the AI Orchestrator",
 		},
+		{
+			Text: "cancel",
+			Description: "cancel <task ref> - Cancel a running task. Partial work is committed and the task moves to In Review.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: cancel <task ref> - Cancel a running task by it's ref."
+				}
+				taskToCancel, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToCancel == nil {
+					return errMsg
+				}
+				if err := orchestrator.Cancel(taskToCancel.ID); err != nil {
+					return "Error cancelling task: " + err.Error()
+				}
+				return "Cancelling task: " + taskToCancel.Name
+			},
+		},
+		{
+			Text: "retry",
+			Description: "retry <task ref> - Re-queue a failed task with a fresh retry budget. It continues in its existing worktree.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: retry <task ref> - Re-queue a failed task by it's ref."
+				}
+				taskToRetry, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToRetry == nil {
+					return errMsg
+				}
+				if err := orchestrator.RetryTask(taskStore, taskToRetry.ID); err != nil {
+					return "Error retrying task: " + err.Error()
+				}
+				return "Retrying task: " + taskToRetry.Name
+			},
+		},
+		{
+			Text: "review",
+			Description: "review <task ref> - Answer the question of a task that is In Review. Pick one of its options, or Other, and add notes for the AI.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: review <task ref> - Answer the question of a task that is In Review."
+				}
+				taskToReview, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToReview == nil {
+					return errMsg
+				}
+				if taskToReview.Status != task.NeedsReview || taskToReview.Review == nil {
+					return taskToReview.Name + " is not waiting for review."
+				}
+				m.reviewForm = reviewForm.NewModel(taskToReview)
+				cfg, _ := config.LoadConfig()
+				if deadline, ok := orchestrator.ReviewDeadline(cfg, taskToReview.Review); ok {
+					m.reviewForm.AutoAnswerAt = deadline
+				}
+				return ""
+			},
+		},
+		{
+			Text: "diff",
+			Description: "diff <task ref> - Browse the commits and changes of a task's branch against its base branch.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: diff <task ref> - Browse the changes of a task's branch."
+				}
+				taskToDiff, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToDiff == nil {
+					return errMsg
+				}
+				diff, err := orchestrator.TaskDiff(taskToDiff)
+				if err != nil {
+					return "Error loading diff: " + err.Error()
+				}
+				m.diffView = diffView.NewModel(taskToDiff, diff)
+				return ""
+			},
+		},
+		{
+			Text: "approve",
+			Description: "approve <task ref> - Show the changes of a task Awaiting Approval against its base branch, then approve, reject with feedback, or discard them.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: approve <task ref> - Review the changes of a task Awaiting Approval."
+				}
+				taskToApprove, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToApprove == nil {
+					return errMsg
+				}
+				if taskToApprove.Status != task.AwaitingApproval {
+					return taskToApprove.Name + " is not awaiting approval."
+				}
+				diff, err := orchestrator.TaskDiff(taskToApprove)
+				if err != nil {
+					return "Error loading diff: " + err.Error()
+				}
+				m.approvalView = approvalView.NewModel(taskToApprove, diff)
+				return ""
+			},
+		},
+		{
+			Text: "merge",
+			Description: "merge [--strategy=ff|squash] <task ref> - Merge a completed task's branch into its base branch. Defaults to merge.strategy in the config (ff).",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				flags, args := parseFlags(parts[1:])
+				if len(args) != 1 {
+					return "Usage: merge [--strategy=ff|squash] <task ref> - Merge a completed task's branch into its base branch."
+				}
+				taskToMerge, errMsg := taskFromRef(taskStore, args[0])
+				if taskToMerge == nil {
+					return errMsg
+				}
+				target, err := orchestrator.MergeTask(taskStore, taskToMerge.ID, flags["strategy"])
+				if err != nil {
+					return branchErrorMessage("Error merging", err, args[0])
+				}
+				return "Merged " + taskToMerge.BranchName + " into " + target
+			},
+		},
+		{
+			Text: "rebase",
+			Description: "rebase <task ref> - Rebase a finished task's branch onto the current state of its base branch.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: rebase <task ref> - Rebase a finished task's branch onto its base branch."
+				}
+				taskToRebase, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToRebase == nil {
+					return errMsg
+				}
+				target, err := orchestrator.RebaseTask(taskStore, taskToRebase.ID)
+				if err != nil {
+					return branchErrorMessage("Error rebasing", err, parts[1])
+				}
+				return "Rebased " + taskToRebase.BranchName + " onto " + target
+			},
+		},
+		{
+			Text: "resolve",
+			Description: "resolve <task ref> - Merge the base branch into a finished task's branch and have the AI resolve the conflicts.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: resolve <task ref> - Hand a task's merge conflicts back to the AI."
+				}
+				taskToResolve, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToResolve == nil {
+					return errMsg
+				}
+				conflicted, err := orchestrator.ResolveConflicts(taskStore, taskToResolve.ID)
+				if err != nil {
+					return "Error resolving conflicts: " + err.Error()
+				}
+				if !conflicted {
+					return "The base branch merged into " + taskToResolve.BranchName + " without conflicts."
+				}
+				return "Handed the conflicts back to the AI: " + taskToResolve.Name
+			},
+		},
+		{
+			Text: "discard",
+			Description: "discard <task ref> - Delete a finished task's branch and worktree, and archive the task.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(2, parts) {
+					return "Usage: discard <task ref> - Delete a finished task's branch and archive the task."
+				}
+				taskToDiscard, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToDiscard == nil {
+					return errMsg
+				}
+				if err := orchestrator.DiscardTask(taskStore, taskToDiscard.ID); err != nil {
+					return "Error discarding task: " + err.Error()
+				}
+				return "Discarded task: " + taskToDiscard.Name
+			},
+		},
+		{
+			Text: "gc",
+			Description: "gc [--prune] - List merged, orphaned and old Ludwig branches and worktrees, and the response files of deleted tasks. --prune removes them according to the gc settings in the config.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				flags, args := parseFlags(parts[1:])
+				_, prune := flags["prune"]
+				if len(args) != 0 || len(flags) > 1 || (len(flags) == 1 && !prune) {
+					return "Usage: gc [--prune] - Report, or with --prune remove, branches and worktrees that are no longer needed."
+				}
+				cfg, err := config.LoadConfig()
+				if err != nil {
+					return "Error loading config: " + err.Error()
+				}
+				report, err := orchestrator.CollectGarbage(taskStore, cfg, prune)
+				if err != nil {
+					return "Error collecting garbage: " + err.Error()
+				}
+				return report.String()
+			},
+		},
+		{
+			Text: "bump",
+			Description: "bump <task ref> - Raise a task's priority. Higher priority tasks are started first.",
+			Action: func(text string, m *Model) string {
+				return changePriorityCommand(taskStore, text, 1)
+			},
+		},
+		{
+			Text: "lower",
+			Description: "lower <task ref> - Lower a task's priority.",
+			Action: func(text string, m *Model) string {
+				return changePriorityCommand(taskStore, text, -1)
+			},
+		},
+		{
+			Text: "move",
+			Description: "move <task ref> <before ref> - Move a task directly before another in the queue. It takes that task's priority.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCount(3, parts) {
+					return "Usage: move <task ref> <before ref> - Move a task directly before another in the queue."
+				}
+				taskToMove, errMsg := taskFromRef(taskStore, parts[1])
+				if taskToMove == nil {
+					return errMsg
+				}
+				target, errMsg := taskFromRef(taskStore, parts[2])
+				if target == nil {
+					return errMsg
+				}
+				if err := orchestrator.MoveTask(taskStore, taskToMove.ID, target.ID); err != nil {
+					return "Error moving task: " + err.Error()
+				}
+				return "Moved " + taskToMove.Name + " before " + target.Name
+			},
+		},
+		{
+			Text: "depend",
+			Description: "depend <task ref> <dependency ref>... - Make a pending task wait for other tasks to complete. It starts from their branches.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if !checkArgumentsCountMin(3, parts, true) {
+					return "Usage: depend <task ref> <dependency ref>... - Make a pending task wait for other tasks."
+				}
+				dependent, errMsg := taskFromRef(taskStore, parts[1])
+				if dependent == nil {
+					return errMsg
+				}
+				dependsOn, errMsg := taskIDsFromRefs(taskStore, parts[2:])
+				if errMsg != "" {
+					return errMsg
+				}
+				if err := orchestrator.AddDependencies(taskStore, dependent.ID, dependsOn); err != nil {
+					return "Error adding dependencies: " + err.Error()
+				}
+				return dependent.Name + " now waits for " + strconv.Itoa(len(dependsOn)) + " more task(s)"
+			},
+		},
+		{
+			Text: "slots",
+			Description: "slots [<provider>] [<count>] - Show worker slot usage, or set the global or a provider's concurrency limit. Changes apply without restarting the orchestrator.",
+			Action: func(text string, m *Model) string {
+				parts := strings.Fields(text)
+				if len(parts) == 1 {
+					return orchestrator.Slots().String()
+				}
+				if len(parts) > 3 {
+					return "Usage: slots [<provider>] [<count>]"
+				}
+				limit, err := strconv.Atoi(parts[len(parts)-1])
+				if err != nil || limit < 0 {
+					return "Invalid slot count. Must be a number of 0 or more."
+				}
+				cfg, err := config.LoadConfig()
+				if err != nil {
+					return "Error loading config: " + err.Error()
+				}
+				if cfg == nil {
+					cfg = &config.Config{}
+				}
+
+				message := "Maximum parallel tasks set to " + strconv.Itoa(limit)
+				if len(parts) == 2 {
+					cfg.MaxParallelTasks = limit
+				} else {
+					provider := parts[1]
+					if !orchestrator.IsKnownProvider(provider) {
+						return "Unknown provider: " + provider
+					}
+					if cfg.ProviderConcurrency == nil {
+						cfg.ProviderConcurrency = map[string]int{}
+					}
+					cfg.ProviderConcurrency[provider] = limit
+					message = "Maximum parallel " + provider + " tasks set to " + strconv.Itoa(limit)
+					if limit == 0 {
+						message = provider + " tasks are now limited only by the global slot count"
+					}
+				}
+				if err := config.SaveConfig(cfg); err != nil {
+					return "Error saving config: " + err.Error()
+				}
+				return message
+			},
+		},
 		{
 			Text: "clear",
 			Description: "clear - Clear the command line so that only the kanban board is visible",
@@ -147,7 +486,7 @@ func PalleteCommands(taskStore *storage.FileTaskStorage) []Command {
 					return "Task ref out of range."
 				}
 				taskToView := tasks[taskIndex]
-				filePath := "./.ludwig/" + taskToView.ResponseFile
+				filePath := storage.ResponseFilePath(taskToView.ResponseFile)
 
 				m.viewingViewport = true
 				m.taskViewport = *m.taskViewport.SetViewingTask(&taskToView, filePath)
@@ -171,6 +510,78 @@ func PalleteCommands(taskStore *storage.FileTaskStorage) []Command {
 	})
 }
 
+// taskFromRef resolves a kanban ref (without the # symbol) to a task.
+// Returns nil and a message for the user if the ref is invalid.
+func taskFromRef(taskStore storage.TaskStorage, ref string) (*task.Task, string) {
+	taskIndex, err := strconv.Atoi(ref)
+	if err != nil {
+		return nil, "Invalid task ref. Must be a number."
+	}
+
+	tasksPointers, err := taskStore.ListTasks()
+	if err != nil {
+		return nil, "Error retrieving tasks: " + err.Error()
+	}
+
+	tasks := utils.PointerSliceToValueSlice(tasksPointers)
+
+	if taskIndex < 0 || taskIndex >= len(tasks) {
+		return nil, "Task ref out of range."
+	}
+	return &tasks[taskIndex], ""
+}
+
+// branchErrorMessage describes a failed merge or rebase, pointing to the resolve command on a conflict
+func branchErrorMessage(prefix string, err error, ref string) string {
+	var conflict *orchestrator.MergeConflictError
+	if errors.As(err, &conflict) {
+		return "Conflicts with " + conflict.Onto + " in " + strings.Join(conflict.Files, ", ") + ". Run 'resolve " + ref + "' to hand them to the AI, or resolve them yourself."
+	}
+	return prefix + ": " + err.Error()
+}
+
+// changePriorityCommand runs the bump and lower commands
+func changePriorityCommand(taskStore storage.TaskStorage, text string, delta int) string {
+	parts := strings.Fields(text)
+	if !checkArgumentsCount(2, parts) {
+		return "Usage: " + parts[0] + " <task ref>"
+	}
+	t, errMsg := taskFromRef(taskStore, parts[1])
+	if t == nil {
+		return errMsg
+	}
+	priority, err := orchestrator.ChangePriority(taskStore, t.ID, delta)
+	if err != nil {
+		return "Error changing priority: " + err.Error()
+	}
+	return "Priority of " + t.Name + " is now " + strconv.Itoa(priority)
+}
+
+// parseFlags splits leading --name=value arguments from the rest of a command's arguments
+func parseFlags(args []string) (map[string]string, []string) {
+	flags := map[string]string{}
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		name, value, _ := strings.Cut(strings.TrimPrefix(args[0], "--"), "=")
+		flags[name] = value
+		args = args[1:]
+	}
+	return flags, args
+}
+
+// taskIDsFromRefs resolves several kanban refs to task IDs
+// Returns a message for the user if any ref is invalid.
+func taskIDsFromRefs(taskStore storage.TaskStorage, refs []string) ([]string, string) {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		t, errMsg := taskFromRef(taskStore, strings.TrimPrefix(strings.TrimSpace(ref), "#"))
+		if t == nil {
+			return nil, errMsg
+		}
+		ids = append(ids, t.ID)
+	}
+	return ids, ""
+}
+
 func checkArgumentsCount(expected int, parts []string) bool {
 	return checkArgumentsCountMin(expected, parts, false)
 }
@@ -1,10 +1,14 @@
 package model
 
 import (
+	"ludwig/internal/components/approvalView"
 	"ludwig/internal/components/commandInput"
+	"ludwig/internal/components/diffView"
 	"ludwig/internal/components/outputViewport"
 	"ludwig/internal/components/orchestratorIndicator"
+	"ludwig/internal/components/reviewForm"
 	"ludwig/internal/kanban"
+	"ludwig/internal/orchestrator"
 	"ludwig/internal/storage"
 	"ludwig/internal/types/task"
 	"ludwig/internal/updater"
@@ -20,7 +24,7 @@ import (
 )
 
 type Model struct {
-	taskStore       *storage.FileTaskStorage
+	taskStore       storage.TaskStorage
 	tasks           []task.Task
 	textInput       textarea.Model
 	commandInput    commandInput.Model
@@ -29,7 +33,11 @@ type Model struct {
 	message         string
 	taskViewport    outputViewport.Model
 	viewingViewport bool
+	reviewForm      *reviewForm.Model // Set while the user is answering a review
+	approvalView    *approvalView.Model // Set while the user is looking at a diff awaiting approval
+	diffView        *diffView.Model // Set while the user is browsing a task branch's diff
 	orchestratorIndicator *orchestratorIndicator.Model
+	repoErr         error // Why tasks cannot run from the current directory, shown until Ludwig is restarted
 }
 
 type Command struct {
@@ -42,8 +50,10 @@ type Command struct {
 type tickMsg time.Time
 
 var loadingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("62"))
+var slotsStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+var repoErrStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("203"))
 
-func NewModel(taskStore *storage.FileTaskStorage, version string) *Model {
+func NewModel(taskStore storage.TaskStorage, version string) *Model {
 	ti := textarea.New()
 	ti.Placeholder = "...Enter command (e.g., 'add <task>', 'exit', 'help')"
 
@@ -75,6 +85,7 @@ func NewModel(taskStore *storage.FileTaskStorage, version string) *Model {
 		orchestratorIndicator: orchestratorIndicator.NewModel(),
 	}
 	m.commands = PalleteCommands(taskStore)
+	_, m.repoErr = orchestrator.RepoRoot()
 
 	m.checkForUpdate(version)
 
@@ -106,7 +117,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	//var cmd tea.Cmd
 	var cmds []tea.Cmd
 
-	if !m.viewingViewport {
+	if _, isKey := msg.(tea.KeyMsg); isKey && m.reviewForm != nil {
+		return m, m.updateReview(msg)
+	}
+	if _, isKey := msg.(tea.KeyMsg); isKey && m.approvalView != nil {
+		return m, m.updateApproval(msg)
+	}
+	if _, isKey := msg.(tea.KeyMsg); isKey && m.diffView != nil {
+		view, cmd := m.diffView.Update(msg)
+		if view.Closed {
+			m.diffView = nil
+		}
+		return m, cmd
+	}
+
+	if !m.viewingViewport && m.reviewForm == nil && m.approvalView == nil && m.diffView == nil {
 		var inputCmd tea.Cmd
 		m.commandInput, inputCmd = m.commandInput.Update(msg)
 		if inputCmd != nil {
@@ -206,8 +231,23 @@ func (m *Model) View() string {
 	if m.viewingViewport {
 		return m.taskViewport.View()
 	}
+	if m.reviewForm != nil {
+		return m.reviewForm.View()
+	}
+	if m.approvalView != nil {
+		return m.approvalView.View()
+	}
+	if m.diffView != nil {
+		return m.diffView.View()
+	}
 	// Render the Kanban board.
 	s.WriteString(kanban.RenderKanban(m.tasks))
+	if orchestrator.IsRunning() {
+		s.WriteString("\n" + slotsStyle.Render(" "+orchestrator.Slots().String()))
+	}
+	if m.repoErr != nil {
+		s.WriteString("\n" + repoErrStyle.Render(" Error: "+m.repoErr.Error()))
+	}
 
 	linesCount := strings.Count(s.String(), "\n")
 
@@ -240,6 +280,57 @@ func (m *Model) View() string {
 	return s.String()
 }
 
+// updateReview passes a key press to the review form, saving the answer once it is submitted
+func (m *Model) updateReview(msg tea.Msg) tea.Cmd {
+	form, cmd := m.reviewForm.Update(msg)
+	switch {
+	case form.Cancelled:
+		m.reviewForm = nil
+		m.message = "Review cancelled."
+	case form.Submitted:
+		m.reviewForm = nil
+		if err := orchestrator.AnswerReview(m.taskStore, form.Task.ID, form.Task.Review, form.Response()); err != nil {
+			m.message = "Error saving review answer: " + err.Error()
+		} else if orchestrator.IsRunning() {
+			m.message = "Answer saved. Resuming task: " + form.Task.Name
+		} else {
+			m.message = "Answer saved. The task resumes when the orchestrator is started."
+		}
+		m.UpdateTasks()
+	}
+	return cmd
+}
+
+// updateApproval passes a key press to the approval view, applying the user's decision once it is made
+func (m *Model) updateApproval(msg tea.Msg) tea.Cmd {
+	view, cmd := m.approvalView.Update(msg)
+	if view.Closed {
+		m.approvalView = nil
+		return cmd
+	}
+
+	var err error
+	switch view.Decision {
+	case approvalView.Undecided:
+		return cmd
+	case approvalView.Approve:
+		err = orchestrator.ApproveTask(m.taskStore, view.Task.ID)
+		m.message = "Approved task: " + view.Task.Name
+	case approvalView.Reject:
+		err = orchestrator.RejectTask(m.taskStore, view.Task.ID, view.Feedback)
+		m.message = "Sent your feedback to the AI: " + view.Task.Name
+	case approvalView.Discard:
+		err = orchestrator.DiscardTask(m.taskStore, view.Task.ID)
+		m.message = "Discarded task: " + view.Task.Name
+	}
+	if err != nil {
+		m.message = "Error: " + err.Error()
+	}
+	m.approvalView = nil
+	m.UpdateTasks()
+	return cmd
+}
+
 func (m *Model) UpdateTasks() {
 	tasks, err := m.taskStore.ListTasks()
 	if err != nil {
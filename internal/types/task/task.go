@@ -1,7 +1,9 @@
 package task
 
 import (
+	"cmp"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,6 +14,9 @@ const (
 	InProgress
 	NeedsReview
 	Completed
+	Failed           // Gave up after exhausting the retry policy, or hit a non-retryable error
+	AwaitingApproval // Finished, waiting for the user to approve the branch diff (worktree kept)
+	Archived         // Branch discarded by the user; kept for the record but hidden from the board
 )
 
 type Task struct {
@@ -19,20 +24,69 @@ type Task struct {
 	Name      string
 	Status    Status
 	CreatedAt time.Time
+	Version   int // Incremented on every save; storage rejects updates based on an older version
 
-	BranchName     string // Git branch created for this task
-	WorktreePath   string // Path to the git worktree directory for this task
-	WorkInProgress string // Stores intermediate work before requesting review
+	Priority      int // Higher priorities are dispatched first (default 0)
+	QueuePosition int // Order among tasks of the same priority, assigned by storage when the task is added
+
+	DependsOn  []string // IDs of tasks that must be Completed (or Archived) before this task starts
+	BaseBranch string   // Branch the task starts from and is merged into (empty: the configured base branch)
+	StartRef   string   // Dependency branch the task's worktree was created from instead of its base branch (empty: none)
+
+	BranchName     string    // Git branch created for this task
+	MergedAt       time.Time // When the branch was last merged into its base branch (zero: not merged)
+	WorktreePath   string    // Path to the git worktree directory for this task
+	WorkInProgress string    // Stores intermediate work before requesting review
+	Summary        string    // The AI's closing summary of its work, used in the task's commit messages
 	Review         *ReviewRequest
 	ReviewResponse *ReviewResponse
 	ResponseFile   string // Path to file containing AI response stream
+	Provider       string // AI provider chosen when the task was added (empty: the configured default)
+
+	Attempts      []Attempt // History of every AI run for this task, oldest first
+	FailureCount  int       // Consecutive failed attempts since the last success or manual retry
+	NextAttemptAt time.Time // Earliest time the orchestrator may retry a failed attempt
+}
+
+// Attempt records a single AI run for a task
+type Attempt struct {
+	StartedAt    time.Time
+	EndedAt      time.Time
+	Provider     string // AI provider that handled the run (e.g. "gemini")
+	Model        string // Model requested from the provider, if known
+	Error        string // Error text if the run failed or was interrupted, empty on success
+	ResponseFile string // Response file the run streamed into
 }
 
 type ReviewRequest struct {
-	Question  string
-	Options   []ReviewOption
-	Context   string
-	CreatedAt time.Time
+	Question   string // The question, or the first of several
+	Options    []ReviewOption
+	Context    string
+	CreatedAt  time.Time
+	Questions  []ReviewQuestion // Every question asked; empty for a single choice review that only uses Question and Options
+	Raw        string           // The review block exactly as the AI wrote it, for debugging
+	ParseError string           // Why the AI's review block could not be read; the review then asks how to continue
+	Timeout    time.Duration    // How long the AI is willing to wait before its suggested answers are used (0: the configured policy)
+	Manual     bool             // Never answered automatically, e.g. after the user cancelled the task
+	Conflicts  []string         // Files conflicting with the base branch, for a review asking how to bring the branch up to date
+}
+
+// QuestionKind is how a review question is answered
+type QuestionKind string
+
+const (
+	SingleChoice QuestionKind = "single" // Pick one option
+	MultiChoice  QuestionKind = "multi"  // Pick any number of options
+	FreeText     QuestionKind = "text"   // Type a value, such as a port number or a file path
+	YesNo        QuestionKind = "yesno"  // Answer yes or no
+)
+
+type ReviewQuestion struct {
+	ID      string
+	Kind    QuestionKind
+	Text    string
+	Options []ReviewOption // Choices of a single choice, multi choice or yes/no question
+	Default string         // Suggested answer: an option ID (comma-separated for multi choice) or text
 }
 
 type ReviewOption struct {
@@ -40,11 +94,65 @@ type ReviewOption struct {
 	Label string
 }
 
+// OtherOptionID is the option ID of an answer written by the user when none of the options fit
+const OtherOptionID = "other"
+
 type ReviewResponse struct {
-	ChosenOptionID string
+	ChosenOptionID string // Answer to the first question, if it is a single choice
 	ChosenLabel    string
 	UserNotes      string
 	RespondedAt    time.Time
+	Answers        []ReviewAnswer // Answers to every question, in the order of AllQuestions
+	Automatic      bool           // Answered by Ludwig with the default answers because the review timed out
+}
+
+type ReviewAnswer struct {
+	QuestionID string
+	OptionIDs  []string // Chosen options; OtherOptionID if the user wrote their own answer in Text
+	Text       string   // Free-text answer, or the user's own answer to a choice question
+}
+
+// YesNoOptions are the options of a yes/no question
+func YesNoOptions() []ReviewOption {
+	return []ReviewOption{{ID: "yes", Label: "Yes"}, {ID: "no", Label: "No"}}
+}
+
+// AllQuestions returns every question of a review.
+// A review that only sets Question and Options is returned as a single choice question.
+func (r *ReviewRequest) AllQuestions() []ReviewQuestion {
+	if len(r.Questions) > 0 {
+		return r.Questions
+	}
+	return []ReviewQuestion{{ID: "q1", Kind: SingleChoice, Text: r.Question, Options: r.Options}}
+}
+
+// SameRequest reports whether r and other are the same question asked at the same time.
+// A nil review is never the same as another.
+func (r *ReviewRequest) SameRequest(other *ReviewRequest) bool {
+	return r != nil && other != nil && r.Question == other.Question && r.CreatedAt.Equal(other.CreatedAt)
+}
+
+// AnswerText describes an answer to the question in words: the chosen option labels, or the text written by the user
+func (q ReviewQuestion) AnswerText(a ReviewAnswer) string {
+	if q.Kind == FreeText {
+		return a.Text
+	}
+	var labels []string
+	for _, id := range a.OptionIDs {
+		if id == OtherOptionID {
+			labels = append(labels, a.Text)
+			continue
+		}
+		for _, option := range q.Options {
+			if option.ID == id {
+				labels = append(labels, option.Label)
+			}
+		}
+	}
+	if len(labels) == 0 {
+		return "(none)"
+	}
+	return strings.Join(labels, ", ")
 }
 
 func StatusString(task Task) string {
@@ -57,11 +165,57 @@ func StatusString(task Task) string {
 		return "In Review"
 	case Completed:
 		return "Completed"
+	case Failed:
+		return "Failed"
+	case AwaitingApproval:
+		return "Awaiting Approval"
+	case Archived:
+		return "Archived"
 	default:
 		return "Unknown"
 	}
 }
 
+// BlockedBy returns the IDs of a task's dependencies that have not completed yet.
+// Dependencies missing from statuses (e.g. deleted tasks) or Archived do not block the task.
+func BlockedBy(t Task, statuses map[string]Status) []string {
+	var blocking []string
+	for _, id := range t.DependsOn {
+		if status, ok := statuses[id]; ok && status != Completed && status != Archived {
+			blocking = append(blocking, id)
+		}
+	}
+	return blocking
+}
+
+// FailedDependencies returns the IDs of a task's dependencies that have failed.
+// The task keeps waiting until they are retried and complete, so the user needs to be told.
+func FailedDependencies(t Task, statuses map[string]Status) []string {
+	var failed []string
+	for _, id := range t.DependsOn {
+		if statuses[id] == Failed {
+			failed = append(failed, id)
+		}
+	}
+	return failed
+}
+
+// QueueOrder compares two tasks by the order the orchestrator dispatches them:
+// higher priority first, then queue position, then creation time.
+// Ties are broken by ID so the order (and the kanban refs) is stable.
+func QueueOrder(a, b *Task) int {
+	if a.Priority != b.Priority {
+		return cmp.Compare(b.Priority, a.Priority)
+	}
+	if a.QueuePosition != b.QueuePosition {
+		return cmp.Compare(a.QueuePosition, b.QueuePosition)
+	}
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.ID, b.ID)
+}
+
 func PrintTasks(tasks []Task) {
 	for _, task := range tasks {
 		fmt.Println("Task: " + task.Name + ", Status: " + StatusString(task))
@@ -30,11 +30,5 @@ func TaskComparator(a, b *task.Task) bool {
 	if a == nil || b == nil {
 		return false
 	}
-	if a.CreatedAt != b.CreatedAt {
-		return a.CreatedAt.Before(b.CreatedAt)
-	}
-	if len(a.Name) != len(b.Name) {
-		return len(a.Name) < len(b.Name)
-	}
-	return a.ID < b.ID
+	return task.QueueOrder(a, b) < 0
 }
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"fmt"
 	"strings"
 	"encoding/json"
 	"time"
@@ -155,31 +156,36 @@ func OutputLine(line string) string {
 		return ""
 	}
 	if object["type"] == "message" {
-		timestamp := object["timestamp"].(string)
-		styledTimestamp := FormatTimestamp(timestamp)
-		builder.WriteString(styledTimestamp)
-		output := strings.Builder{}
-		output.WriteString(object["content"].(string))
-		builder.WriteString(OUTPUT_STYLE.Render(output.String()))
+		// Legacy gemini stream-json line; the echoed prompt is not worth showing
+		if object["role"] == "user" {
+			return ""
+		}
+		builder.WriteString(FormatTimestamp(stringField(object, "timestamp")))
+		builder.WriteString(OUTPUT_STYLE.Render(stringField(object, "content")))
 		return builder.String()
 	}
-	if object["type"] == "tool_use" {
-		timestamp := object["timestamp"].(string)
-		timestamp = FormatTimestamp(timestamp)
+	if object["type"] == "text" {
+		builder.WriteString(FormatTimestamp(stringField(object, "timestamp")))
+		builder.WriteString(OUTPUT_STYLE.Render(stringField(object, "text")))
+		return builder.String()
+	}
+	if object["type"] == "tool_use" || object["type"] == "tool_call" {
+		timestamp := FormatTimestamp(stringField(object, "timestamp"))
 		builder.WriteString(timestamp)
 
 		output := strings.Builder{}
-		toolName := object["tool_name"].(string)
-		params := object["parameters"].(map[string]any)
+		toolName := stringField(object, "tool_name")
 		output.WriteString("Using tool: " + toolName + "\n")
-		writeParams(&output, params)
+		if params, ok := object["parameters"].(map[string]any); ok && len(params) > 0 {
+			writeParams(&output, params)
+		}
 		builder.WriteString(OUTPUT_STYLE.Render(output.String()))
 
 		return builder.String()
 	}
 	if object["type"] == "tool_result" {
 		output := strings.Builder{}
-		result := object["status"].(string)
+		result := stringField(object, "status")
 		output.WriteString("Tool result: ")
 		output.WriteString(result)
 		output.WriteString("\n")
@@ -187,6 +193,21 @@ func OutputLine(line string) string {
 
 		return builder.String()
 	}
+	if object["type"] == "usage" {
+		usage, ok := object["usage"].(map[string]any)
+		if !ok {
+			return ""
+		}
+		tokens := fmt.Sprintf("Tokens: %v in, %v out", usage["input_tokens"], usage["output_tokens"])
+		return USAGE_STYLE.Render(tokens)
+	}
+	if object["type"] == "error" {
+		text := stringField(object, "text")
+		if text == "" {
+			text = stringField(object, "message")
+		}
+		return ERROR_STYLE.Render(text)
+	}
 	if object["type"] == "result" {
 		return builder.String()
 	}
@@ -194,10 +215,41 @@ func OutputLine(line string) string {
 	return line
 }
 
+// textDelta reports whether a line is an assistant text event that should be
+// merged with its neighbours, returning the delta and its timestamp
+func textDelta(line string) (string, string, bool) {
+	if len(line) < 2 || line[0] != '{' {
+		return "", "", false
+	}
+	var object map[string]any
+	if err := json.Unmarshal([]byte(line), &object); err != nil {
+		return "", "", false
+	}
+	switch object["type"] {
+	case "text":
+		return stringField(object, "text"), stringField(object, "timestamp"), true
+	case "message":
+		if object["role"] == "user" {
+			return "", "", false
+		}
+		return stringField(object, "content"), stringField(object, "timestamp"), true
+	}
+	return "", "", false
+}
+
+// stringField returns a JSON field as a string, or "" if missing or not a string
+func stringField(object map[string]any, key string) string {
+	value, _ := object[key].(string)
+	return value
+}
+
+var USAGE_STYLE = lipgloss.NewStyle().Faint(true)
+var ERROR_STYLE = lipgloss.NewStyle().Foreground(lipgloss.Color("#cc3333"))
+
 func writeParams(builder *strings.Builder, params map[string]any) {
 	builder.WriteString("With parameters:\n")
 	for key, value := range params {
-		paramLine := "  - " + key + ": " + value.(string) + "\n"
+		paramLine := "  - " + key + ": " + fmt.Sprint(value) + "\n"
 		builder.WriteString(paramLine)
 	}
 }
@@ -233,7 +285,20 @@ func OutputLines(lines []string) string {
 		return "no output"
 	}
 	started := false
-	linesToSkip := 2
+
+	// Consecutive assistant text deltas are merged into a single block
+	text := strings.Builder{}
+	textTimestamp := ""
+	flushText := func() {
+		if text.Len() == 0 {
+			return
+		}
+		output.WriteString(FormatTimestamp(textTimestamp))
+		output.WriteString(OUTPUT_STYLE.Render(text.String()))
+		output.WriteString("\n")
+		text.Reset()
+	}
+
 	for _, line := range lines {
 		if line == "---" && started {
 			break
@@ -248,13 +313,22 @@ func OutputLines(lines []string) string {
 		if line == "" {
 			continue
 		}
-		if linesToSkip > 0 {
-			linesToSkip--
+		if delta, timestamp, ok := textDelta(line); ok {
+			if text.Len() == 0 {
+				textTimestamp = timestamp
+			}
+			text.WriteString(delta)
+			continue
+		}
+		flushText()
+		rendered := OutputLine(line)
+		if rendered == "" {
 			continue
 		}
-		output.WriteString(OutputLine(line))
+		output.WriteString(rendered)
 		output.WriteString("\n")
 	}
+	flushText()
 	outputStr := colouredUnorderedLists(output.String())
 	outputStr = colouredStrings(outputStr)
 	return colouredOrderedLists(outputStr)
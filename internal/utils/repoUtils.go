@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// Errors returned by FindRepoRoot when Ludwig is not run from a usable repository
+var (
+	ErrNotRepository  = errors.New("not a git repository")
+	ErrBareRepository = errors.New("bare git repository")
+)
+
+// FindRepoRoot returns the top-level directory of the git working tree containing dir.
+// Task worktrees are created from a working tree, so a bare repository is an error.
+func FindRepoRoot(dir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--is-bare-repository")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "", fmt.Errorf("git is not installed or not on PATH")
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %s is not inside a git repository; run ludwig from a repository, or create one with git init", ErrNotRepository, dir)
+	}
+	if strings.TrimSpace(string(output)) == "true" {
+		return "", fmt.Errorf("%w: %s has no working tree; run ludwig from a clone with a working tree", ErrBareRepository, dir)
+	}
+
+	cmd = exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	output, err = cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("%w: %s is inside the repository's .git directory; run ludwig from the working tree", ErrNotRepository, dir)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// RepoRoot returns the top-level directory of the git working tree containing the current directory
+func RepoRoot() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return FindRepoRoot(cwd)
+}
+
+// projectDirs caches ProjectDir for each working directory, since it is looked up on every poll and refresh
+var (
+	projectDirsMu sync.Mutex
+	projectDirs   = make(map[string]string)
+)
+
+// ProjectDir returns the directory holding .ludwig and .worktrees: the repository root,
+// or the current directory if it is not inside a repository.
+// git is only run the first time for each working directory.
+func ProjectDir() (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	projectDirsMu.Lock()
+	defer projectDirsMu.Unlock()
+	if dir, ok := projectDirs[cwd]; ok {
+		return dir, nil
+	}
+	dir := cwd
+	if root, err := FindRepoRoot(cwd); err == nil {
+		dir = root
+	}
+	projectDirs[cwd] = dir
+	return dir, nil
+}
@@ -3,6 +3,7 @@ package cli_test
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"ludwig/internal/cli"
 	"ludwig/internal/types/task"
@@ -82,7 +83,7 @@ func TestKanbanTaskNameUnicode(t *testing.T) {
 
 func TestKanbanTaskNameWithDifferentStatuses(t *testing.T) {
 	name := "Test Task"
-	statuses := []task.Status{task.Pending, task.InProgress, task.NeedsReview, task.Completed}
+	statuses := []task.Status{task.Pending, task.InProgress, task.NeedsReview, task.Completed, task.Failed}
 
 	for _, status := range statuses {
 		result := cli.KanbanTaskName(name, status)
@@ -96,3 +97,88 @@ func TestKanbanTaskNameWithDifferentStatuses(t *testing.T) {
 		}
 	}
 }
+
+func TestRenderKanbanFailedColumn(t *testing.T) {
+	tasks := []task.Task{
+		{ID: "1", Name: "Working task", Status: task.InProgress},
+		{ID: "2", Name: "Broken task", Status: task.Failed},
+	}
+	result := cli.RenderKanban(tasks)
+
+	if !strings.Contains(result, "Failed") {
+		t.Errorf("expected a Failed column header, got %q", result)
+	}
+	if !strings.Contains(result, "#1 Broken task") {
+		t.Errorf("expected failed task with its ref, got %q", result)
+	}
+}
+
+func TestRenderKanbanPriorityMarker(t *testing.T) {
+	tasks := []task.Task{
+		{ID: "1", Name: "Urgent task", Status: task.Pending, Priority: 2},
+		{ID: "2", Name: "Someday task", Status: task.Pending, Priority: -1},
+		{ID: "3", Name: "Normal task", Status: task.Pending},
+	}
+	result := cli.RenderKanban(tasks)
+
+	if !strings.Contains(result, "#0 [+2] Urgent task") {
+		t.Errorf("expected raised priority marker, got %q", result)
+	}
+	if !strings.Contains(result, "#1 [-1] Someday task") {
+		t.Errorf("expected lowered priority marker, got %q", result)
+	}
+	if !strings.Contains(result, "#2 Normal task") {
+		t.Errorf("expected no marker for default priority, got %q", result)
+	}
+}
+
+func TestRenderKanbanBlockedMarker(t *testing.T) {
+	tasks := []task.Task{
+		{ID: "1", Name: "Add schema", Status: task.InProgress},
+		{ID: "2", Name: "Build API", Status: task.Pending, DependsOn: []string{"1"}},
+	}
+	result := cli.RenderKanban(tasks)
+	if !strings.Contains(result, "#1 [blocked] Build API") {
+		t.Errorf("expected blocked marker on dependent task, got %q", result)
+	}
+
+	tasks[0].Status = task.Failed
+	result = cli.RenderKanban(tasks)
+	if !strings.Contains(result, "#1 [dep failed] Build API") {
+		t.Errorf("expected failed dependency marker on dependent task, got %q", result)
+	}
+
+	tasks[0].Status = task.Completed
+	result = cli.RenderKanban(tasks)
+	if strings.Contains(result, "[blocked]") {
+		t.Errorf("expected no blocked marker once the dependency completed, got %q", result)
+	}
+}
+
+func TestRenderKanbanApprovalMarker(t *testing.T) {
+	tasks := []task.Task{
+		{ID: "1", Name: "Add footer", Status: task.AwaitingApproval},
+		{ID: "2", Name: "Old idea", Status: task.Archived},
+	}
+	result := cli.RenderKanban(tasks)
+	if !strings.Contains(result, "[approve] Add footer") {
+		t.Errorf("expected approval marker, got %q", result)
+	}
+	if strings.Contains(result, "Old idea") {
+		t.Errorf("expected archived tasks to be hidden, got %q", result)
+	}
+}
+
+func TestRenderKanbanMergedMarker(t *testing.T) {
+	tasks := []task.Task{
+		{ID: "1", Name: "Add footer", Status: task.Completed, MergedAt: time.Now()},
+		{ID: "2", Name: "Add header", Status: task.Completed},
+	}
+	result := cli.RenderKanban(tasks)
+	if !strings.Contains(result, "#0 [merged] Add footer") {
+		t.Errorf("expected merged marker, got %q", result)
+	}
+	if !strings.Contains(result, "#1 Add header") {
+		t.Errorf("expected no marker on an unmerged task, got %q", result)
+	}
+}
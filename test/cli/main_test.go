@@ -0,0 +1,21 @@
+package cli_test
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests in a temporary directory outside any git repository,
+// so .ludwig is created there instead of at the root of the Ludwig checkout
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "ludwig-cli-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
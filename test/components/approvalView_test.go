@@ -0,0 +1,52 @@
+package components_test
+
+import (
+	"testing"
+
+	"ludwig/internal/components/approvalView"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/types/task"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func approvalTask() (*task.Task, *orchestrator.BranchDiff) {
+	return &task.Task{ID: "a", Name: "Add a page", Status: task.AwaitingApproval},
+		&orchestrator.BranchDiff{Base: "main", Stat: " page.txt | 1 +", Patch: "+page"}
+}
+
+func runes(text string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)}
+}
+
+// TestApprovalViewRejectRequiresFeedback tests that rejecting needs feedback before it is decided
+func TestApprovalViewRejectRequiresFeedback(t *testing.T) {
+	view := approvalView.NewModel(approvalTask())
+	view.Update(runes("r"))
+	view.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view.Decision != approvalView.Undecided {
+		t.Fatal("expected rejecting without feedback to be refused")
+	}
+
+	view.Update(runes("Add a footer"))
+	view.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if view.Decision != approvalView.Reject || view.Feedback != "Add a footer" {
+		t.Errorf("expected a rejection with feedback, got %v %q", view.Decision, view.Feedback)
+	}
+}
+
+// TestApprovalViewDiscardNeedsConfirmation tests that discarding is only decided after confirming
+func TestApprovalViewDiscardNeedsConfirmation(t *testing.T) {
+	view := approvalView.NewModel(approvalTask())
+	view.Update(runes("d"))
+	view.Update(runes("n"))
+	if view.Decision != approvalView.Undecided {
+		t.Fatal("expected discard to be cancelled")
+	}
+
+	view.Update(runes("d"))
+	view.Update(runes("y"))
+	if view.Decision != approvalView.Discard {
+		t.Errorf("expected discard after confirming, got %v", view.Decision)
+	}
+}
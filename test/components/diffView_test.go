@@ -0,0 +1,95 @@
+package components_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"ludwig/internal/components/diffView"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/types/task"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// filePatch builds the diff of a new file with the given number of lines
+func filePatch(name string, lines int) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n@@ -0,0 +1,%d @@\n", name, name, name, lines)
+	for i := 0; i < lines; i++ {
+		fmt.Fprintf(&s, "+%s line %d\n", name, i)
+	}
+	return s.String()
+}
+
+func diffTask() (*task.Task, *orchestrator.BranchDiff) {
+	return &task.Task{ID: "d", Name: "Add pages", BranchName: "ludwig/add-pages"},
+		&orchestrator.BranchDiff{
+			Base:    "main",
+			Patch:   filePatch("a.txt", 30) + filePatch("b.txt", 30) + filePatch("c.txt", 30),
+			Commits: []orchestrator.BranchCommit{{Hash: "abc1234", Author: "Ludwig", Subject: "Add pages"}},
+		}
+}
+
+// TestDiffViewShowsCommitsAndFiles tests that the branch's commits and first file are shown
+func TestDiffViewShowsCommitsAndFiles(t *testing.T) {
+	view := diffView.NewModel(diffTask())
+	out := view.View()
+	for _, want := range []string{"abc1234", "Add pages", "a.txt +30 -0", "+a.txt line 0", "file 1/3"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected view to contain %q, got %q", want, out)
+		}
+	}
+}
+
+// TestDiffViewFileNavigationAndCollapse tests jumping between files and collapsing them
+func TestDiffViewFileNavigationAndCollapse(t *testing.T) {
+	view := diffView.NewModel(diffTask())
+	view.Update(tea.KeyMsg{Type: tea.KeyTab})
+	view.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if out := view.View(); !strings.Contains(out, "file 2/3: b.txt") || !strings.Contains(out, "+b.txt line 0") {
+		t.Fatalf("expected the second file at the top, got %q", out)
+	}
+
+	view.Update(tea.KeyMsg{Type: tea.KeySpace})
+	if out := view.View(); !strings.Contains(out, "▸ b.txt") || strings.Contains(out, "+b.txt line 0") {
+		t.Errorf("expected b.txt to be collapsed, got %q", out)
+	}
+
+	view.Update(tea.KeyMsg{Type: tea.KeyShiftTab})
+	if out := view.View(); !strings.Contains(out, "file 1/3: a.txt") {
+		t.Errorf("expected to move back to the first file, got %q", out)
+	}
+
+	view.Update(runes("c"))
+	out := view.View()
+	for _, want := range []string{"▸ a.txt", "▸ b.txt", "▸ c.txt"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected every file to be collapsed, got %q", out)
+		}
+	}
+}
+
+// TestDiffViewHunkNavigation tests that n jumps to the next hunk
+func TestDiffViewHunkNavigation(t *testing.T) {
+	view := diffView.NewModel(diffTask())
+	view.Update(runes("n"))
+	view.Update(runes("n"))
+	if out := view.View(); !strings.Contains(out, "file 2/3: b.txt") || !strings.Contains(out, "@@ -0,0 +1,30 @@") {
+		t.Errorf("expected the second hunk at the top, got %q", out)
+	}
+
+	view.Update(runes("p"))
+	if out := view.View(); !strings.Contains(out, "file 1/3: a.txt") {
+		t.Errorf("expected to move back to the first hunk, got %q", out)
+	}
+}
+
+// TestDiffViewClosesOnEsc tests that Esc leaves the view
+func TestDiffViewClosesOnEsc(t *testing.T) {
+	view := diffView.NewModel(diffTask())
+	view.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if !view.Closed {
+		t.Error("expected the view to close")
+	}
+}
@@ -0,0 +1,139 @@
+package components_test
+
+import (
+	"testing"
+
+	"ludwig/internal/components/reviewForm"
+	"ludwig/internal/types/task"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func reviewTask() *task.Task {
+	return &task.Task{
+		ID:   "r",
+		Name: "Pick a database",
+		Review: &task.ReviewRequest{
+			Question: "Which database?",
+			Options: []task.ReviewOption{
+				{ID: "pg", Label: "PostgreSQL"},
+				{ID: "sqlite", Label: "SQLite"},
+			},
+		},
+	}
+}
+
+func press(form *reviewForm.Model, keys ...tea.KeyMsg) {
+	for _, key := range keys {
+		form.Update(key)
+	}
+}
+
+func typeText(form *reviewForm.Model, text string) {
+	press(form, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+}
+
+// TestReviewFormChoosesOption tests choosing an option with the arrow keys and adding notes
+func TestReviewFormChoosesOption(t *testing.T) {
+	form := reviewForm.NewModel(reviewTask())
+	press(form, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyTab})
+	typeText(form, "keep it simple")
+	press(form, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if !form.Submitted {
+		t.Fatal("expected the form to be submitted")
+	}
+	response := form.Response()
+	if response.ChosenOptionID != "sqlite" || response.ChosenLabel != "SQLite" {
+		t.Errorf("expected SQLite to be chosen, got %+v", response)
+	}
+	if response.UserNotes != "keep it simple" {
+		t.Errorf("expected notes to be kept, got %q", response.UserNotes)
+	}
+}
+
+// TestReviewFormOtherRequiresAnswer tests that the other choice needs the user's own answer before it is submitted
+func TestReviewFormOtherRequiresAnswer(t *testing.T) {
+	form := reviewForm.NewModel(reviewTask())
+	press(form, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyEnter})
+	if form.Submitted {
+		t.Fatal("expected other without an answer not to be submitted")
+	}
+
+	// While other is highlighted, typing goes straight into the user's own answer
+	typeText(form, "MySQL")
+	press(form, tea.KeyMsg{Type: tea.KeyEnter})
+	if !form.Submitted {
+		t.Fatal("expected the form to be submitted once an answer was written")
+	}
+	response := form.Response()
+	if response.ChosenOptionID != task.OtherOptionID || response.ChosenLabel != "MySQL" {
+		t.Errorf("expected other answer, got %+v", response)
+	}
+	if len(response.Answers) != 1 || response.Answers[0].Text != "MySQL" {
+		t.Errorf("expected the own answer in Answers, got %+v", response.Answers)
+	}
+}
+
+// TestReviewFormMultipleQuestions tests answering several questions of different kinds, starting from their defaults
+func TestReviewFormMultipleQuestions(t *testing.T) {
+	reviewed := reviewTask()
+	reviewed.Review.Questions = []task.ReviewQuestion{
+		{ID: "q1", Kind: task.SingleChoice, Text: "Which database?", Options: reviewed.Review.Options, Default: "sqlite"},
+		{ID: "q2", Kind: task.MultiChoice, Text: "Which platforms?", Default: "linux", Options: []task.ReviewOption{
+			{ID: "linux", Label: "Linux"}, {ID: "mac", Label: "macOS"}, {ID: "windows", Label: "Windows"},
+		}},
+		{ID: "q3", Kind: task.FreeText, Text: "Which port?", Default: "8080"},
+		{ID: "q4", Kind: task.YesNo, Text: "Add migrations?", Options: task.YesNoOptions()},
+	}
+	form := reviewForm.NewModel(reviewed)
+
+	// Keep the default database, add macOS, change the port and answer no
+	press(form, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeySpace})
+	press(form, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyBackspace})
+	typeText(form, "1")
+	press(form, tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if !form.Submitted {
+		t.Fatal("expected the form to be submitted")
+	}
+	response := form.Response()
+	if len(response.Answers) != 4 {
+		t.Fatalf("expected 4 answers, got %+v", response.Answers)
+	}
+	want := []string{"SQLite", "Linux, macOS", "8081", "No"}
+	for i, question := range reviewed.Review.AllQuestions() {
+		if got := question.AnswerText(response.Answers[i]); got != want[i] {
+			t.Errorf("answer %d: expected %q, got %q", i+1, want[i], got)
+		}
+	}
+	if response.ChosenOptionID != "sqlite" {
+		t.Errorf("expected the first answer in ChosenOptionID, got %q", response.ChosenOptionID)
+	}
+}
+
+// TestReviewFormRequiresFreeTextAnswer tests that an empty free-text question blocks submitting
+func TestReviewFormRequiresFreeTextAnswer(t *testing.T) {
+	reviewed := reviewTask()
+	reviewed.Review.Questions = []task.ReviewQuestion{{ID: "q1", Kind: task.FreeText, Text: "Which port?"}}
+	form := reviewForm.NewModel(reviewed)
+
+	press(form, tea.KeyMsg{Type: tea.KeyEnter})
+	if form.Submitted {
+		t.Fatal("expected an empty answer not to be submitted")
+	}
+	typeText(form, "9090")
+	press(form, tea.KeyMsg{Type: tea.KeyEnter})
+	if !form.Submitted || form.Response().Answers[0].Text != "9090" {
+		t.Errorf("expected the typed answer to be submitted, got %+v", form.Response())
+	}
+}
+
+// TestReviewFormCancel tests that Esc leaves the form without an answer
+func TestReviewFormCancel(t *testing.T) {
+	form := reviewForm.NewModel(reviewTask())
+	press(form, tea.KeyMsg{Type: tea.KeyEsc})
+	if !form.Cancelled || form.Submitted {
+		t.Error("expected the form to be cancelled")
+	}
+}
@@ -2,9 +2,12 @@ package config_test
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"ludwig/internal/config"
 )
@@ -136,3 +139,168 @@ func TestSaveConfig(t *testing.T) {
 		t.Errorf("expected OllamaModel 'mistral', got %s", loadedCfg.OllamaModel)
 	}
 }
+
+func TestRetryPolicyDefaults(t *testing.T) {
+	var cfg *config.Config
+	policy := cfg.RetryPolicy()
+
+	if policy.MaxAttempts != config.DefaultMaxAttempts {
+		t.Errorf("expected default max attempts %d, got %d", config.DefaultMaxAttempts, policy.MaxAttempts)
+	}
+	if policy.Backoff(1) != config.DefaultBackoffSeconds*time.Second {
+		t.Errorf("expected default backoff, got %v", policy.Backoff(1))
+	}
+	if !policy.IsRetryable(errors.New("anything")) {
+		t.Errorf("expected every error to be retryable by default")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := config.RetryPolicy{BackoffSeconds: 10, MaxBackoffSeconds: 35}
+
+	expected := []time.Duration{10 * time.Second, 20 * time.Second, 35 * time.Second, 35 * time.Second}
+	for i, want := range expected {
+		if got := policy.Backoff(i + 1); got != want {
+			t.Errorf("failure %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+func TestRetryPolicyRetryableErrors(t *testing.T) {
+	policy := config.RetryPolicy{RetryableErrors: []string{"Rate Limit", "timeout"}}
+
+	if !policy.IsRetryable(errors.New("429: rate limit exceeded")) {
+		t.Errorf("expected case-insensitive match to be retryable")
+	}
+	if policy.IsRetryable(errors.New("executable file not found")) {
+		t.Errorf("expected unlisted error not to be retryable")
+	}
+}
+
+func TestConcurrencyLimits(t *testing.T) {
+	var unset *config.Config
+	if unset.ParallelTasks() != config.DefaultMaxParallelTasks {
+		t.Errorf("expected default parallel tasks %d, got %d", config.DefaultMaxParallelTasks, unset.ParallelTasks())
+	}
+	if unset.ProviderLimit("ollama") != 1 || unset.ProviderLimit("copilot") != 0 {
+		t.Errorf("expected ollama limited to 1 and copilot unlimited by default")
+	}
+
+	cfg := &config.Config{MaxParallelTasks: 5, ProviderConcurrency: map[string]int{"ollama": 0, "copilot": 4}}
+	if cfg.ParallelTasks() != 5 {
+		t.Errorf("expected 5 parallel tasks, got %d", cfg.ParallelTasks())
+	}
+	if cfg.ProviderLimit("ollama") != 0 {
+		t.Errorf("expected an explicit 0 to lift the default ollama limit")
+	}
+	if cfg.ProviderLimit("copilot") != 4 {
+		t.Errorf("expected copilot limit 4, got %d", cfg.ProviderLimit("copilot"))
+	}
+}
+
+func TestVerifySteps(t *testing.T) {
+	var unset *config.Config
+	if len(unset.VerifySteps()) != 0 {
+		t.Errorf("expected no verification steps without a config")
+	}
+	if unset.VerifyRounds() != config.DefaultVerifyRounds {
+		t.Errorf("expected default verify rounds %d, got %d", config.DefaultVerifyRounds, unset.VerifyRounds())
+	}
+
+	cfg := &config.Config{Verify: config.VerifyConfig{Lint: "go vet ./...", Build: "go build ./...", MaxRounds: 4}}
+	steps := cfg.VerifySteps()
+	if len(steps) != 2 || steps[0].Name != "build" || steps[1].Name != "lint" {
+		t.Errorf("expected build then lint, got %+v", steps)
+	}
+	if cfg.VerifyRounds() != 4 {
+		t.Errorf("expected 4 verify rounds, got %d", cfg.VerifyRounds())
+	}
+}
+
+func TestReviewTimeout(t *testing.T) {
+	var unset *config.Config
+	if unset.ReviewTimeout() != 0 {
+		t.Errorf("expected reviews to wait indefinitely without a config")
+	}
+	cfg := &config.Config{Review: config.ReviewPolicy{TimeoutMinutes: 90}}
+	if cfg.ReviewTimeout() != 90*time.Minute {
+		t.Errorf("expected a 90 minute review timeout, got %v", cfg.ReviewTimeout())
+	}
+}
+
+func TestMergeStrategy(t *testing.T) {
+	var unset *config.Config
+	if unset.MergeStrategy() != config.MergeFastForward {
+		t.Errorf("expected fast-forward merges without a config, got %q", unset.MergeStrategy())
+	}
+	cfg := &config.Config{Merge: config.MergeConfig{Strategy: config.MergeSquash}}
+	if cfg.MergeStrategy() != config.MergeSquash {
+		t.Errorf("expected the configured squash strategy, got %q", cfg.MergeStrategy())
+	}
+}
+
+func TestRebasePolicy(t *testing.T) {
+	var unset *config.Config
+	if unset.AutoRebase() || unset.RebaseConflictPolicy() != config.ConflictReview {
+		t.Errorf("expected no automatic rebase and review on conflict without a config")
+	}
+	cfg := &config.Config{Rebase: config.RebasePolicy{Auto: true, OnConflict: config.ConflictAgent}}
+	if !cfg.AutoRebase() || cfg.RebaseConflictPolicy() != config.ConflictAgent {
+		t.Errorf("expected the configured rebase policy, got %+v", cfg.Rebase)
+	}
+}
+
+func TestCommitConfig(t *testing.T) {
+	var unset *config.Config
+	if name, email := unset.CommitIdentity(); unset.SquashCommits() || name != "" || email != "" {
+		t.Errorf("expected no squashing and the user's identity without a config")
+	}
+	cfg := &config.Config{Commit: config.CommitConfig{Squash: true, AuthorName: "Ludwig", AuthorEmail: "ludwig@example.com"}}
+	if name, email := cfg.CommitIdentity(); !cfg.SquashCommits() || name != "Ludwig" || email != "ludwig@example.com" {
+		t.Errorf("expected the configured commit settings, got %+v", cfg.Commit)
+	}
+	if env := strings.Join(cfg.CommitEnv(), " "); env != "GIT_AUTHOR_NAME=Ludwig GIT_COMMITTER_NAME=Ludwig GIT_AUTHOR_EMAIL=ludwig@example.com GIT_COMMITTER_EMAIL=ludwig@example.com" {
+		t.Errorf("expected the identity variables, got %q", env)
+	}
+	if env := unset.CommitEnv(); env != nil {
+		t.Errorf("expected no identity variables without a config, got %q", env)
+	}
+}
+
+func TestGCPolicy(t *testing.T) {
+	var unset *config.Config
+	if policy := unset.GCPolicy(); policy != (config.GCPolicy{}) {
+		t.Errorf("expected the default gc policy without a config, got %+v", policy)
+	}
+	cfg := &config.Config{GC: config.GCPolicy{MergedBranchDays: 14, KeepOrphanedBranches: true}}
+	if policy := cfg.GCPolicy(); policy.MergedBranchDays != 14 || !policy.KeepOrphanedBranches {
+		t.Errorf("expected the configured gc policy, got %+v", policy)
+	}
+}
+
+func TestOpenAIAPIKey(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "default-key")
+	t.Setenv("LOCAL_LLM_KEY", "custom-key")
+	var unset *config.Config
+	if key := unset.OpenAIAPIKey(); key != "default-key" {
+		t.Errorf("expected the key from OPENAI_API_KEY, got %q", key)
+	}
+	cfg := &config.Config{OpenAIAPIKeyEnv: "LOCAL_LLM_KEY"}
+	if key := cfg.OpenAIAPIKey(); key != "custom-key" {
+		t.Errorf("expected the key from the configured variable, got %q", key)
+	}
+}
+
+func TestAgentCLI(t *testing.T) {
+	var unset *config.Config
+	if cli := unset.AgentCLI("claude"); cli.Binary != "" || cli.Model != "" || len(cli.Args) != 0 {
+		t.Errorf("expected empty CLI settings without a config, got %+v", cli)
+	}
+	cfg := &config.Config{Codex: config.AgentCLIConfig{Binary: "/opt/codex", Model: "gpt-5-codex", Args: []string{"--oss"}}}
+	if cli := cfg.AgentCLI("codex"); cli.Binary != "/opt/codex" || cli.Model != "gpt-5-codex" || len(cli.Args) != 1 {
+		t.Errorf("expected the codex settings, got %+v", cli)
+	}
+	if cli := cfg.AgentCLI("gemini"); cli.Binary != "" {
+		t.Errorf("expected no CLI settings for gemini, got %+v", cli)
+	}
+}
@@ -0,0 +1,190 @@
+package orchestrator_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/orchestrator/clients"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// fakeAgentScript returns a fake CLI script that records its arguments, one per line, in argsFile and prints output
+func fakeAgentScript(argsFile string, output string) string {
+	return "for arg in \"$@\"; do echo \"$arg\" >> '" + argsFile + "'; done\ncat <<'EOF'\n" + output + "EOF\n"
+}
+
+// readArgs returns the arguments recorded by a fake CLI
+func readArgs(t *testing.T, argsFile string) []string {
+	t.Helper()
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("failed to read recorded arguments: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// eventsOfType returns the events of one type
+func eventsOfType(events []clients.Event, eventType clients.EventType) []clients.Event {
+	var matching []clients.Event
+	for _, ev := range events {
+		if ev.Type == eventType {
+			matching = append(matching, ev)
+		}
+	}
+	return matching
+}
+
+// TestClaudeClientStreamJSON tests that claude's stream-json output is decoded into text, tool and usage events
+func TestClaudeClientStreamJSON(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	installFakeCLI(t, "claude", fakeAgentScript(argsFile, `{"type":"system","subtype":"init","session_id":"s1"}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Creating the file."},{"type":"tool_use","id":"toolu_1","name":"Write","input":{"file_path":"a.txt","content":"a"}}]}}
+{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"toolu_1","content":[{"type":"text","text":"File created"}]}]}}
+{"type":"assistant","message":{"content":[{"type":"text","text":"Done."}]}}
+{"type":"result","subtype":"success","is_error":false,"result":"Done.","usage":{"input_tokens":20,"output_tokens":7}}
+`))
+
+	client := clients.NewClaudeClient("", "sonnet", []string{"--max-turns", "5"})
+	var events []clients.Event
+	final, err := client.StreamPromptWithDir(context.Background(), "Add a.txt", t.TempDir(), collectEvents(&events))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Creating the file.\nDone.\n" {
+		t.Errorf("expected the assistant's text blocks, got %q", final)
+	}
+
+	calls := eventsOfType(events, clients.EventToolCall)
+	if len(calls) != 1 || calls[0].ToolName != "Write" || calls[0].ToolID != "toolu_1" || calls[0].Parameters["file_path"] != "a.txt" {
+		t.Errorf("expected the Write tool call, got %+v", calls)
+	}
+	results := eventsOfType(events, clients.EventToolResult)
+	if len(results) != 1 || results[0].ToolID != "toolu_1" || results[0].Status != "success" || results[0].Output != "File created" {
+		t.Errorf("expected the Write tool result, got %+v", results)
+	}
+	usage := eventsOfType(events, clients.EventUsage)
+	if len(usage) != 1 || usage[0].Usage.TotalTokens != 27 {
+		t.Errorf("expected the result's token usage, got %+v", usage)
+	}
+
+	args := strings.Join(readArgs(t, argsFile), " ")
+	if !strings.HasPrefix(args, "-p Add a.txt --output-format stream-json --verbose") || !strings.HasSuffix(args, "--model sonnet --max-turns 5") {
+		t.Errorf("unexpected claude arguments %q", args)
+	}
+}
+
+// TestCodexClientJSONEvents tests that codex exec's JSON events are decoded into text, tool and usage events
+func TestCodexClientJSONEvents(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	installFakeCLI(t, "codex", fakeAgentScript(argsFile, `{"type":"thread.started","thread_id":"t1"}
+{"type":"item.completed","item":{"id":"item_0","type":"reasoning","text":"Thinking"}}
+{"type":"item.started","item":{"id":"item_1","type":"command_execution","command":"ls","aggregated_output":"","status":"in_progress"}}
+{"type":"item.completed","item":{"id":"item_1","type":"command_execution","command":"ls","aggregated_output":"a.txt\n","exit_code":0,"status":"completed"}}
+{"type":"item.completed","item":{"id":"item_2","type":"file_change","changes":[{"path":"b.txt","kind":"add"}],"status":"completed"}}
+{"type":"item.completed","item":{"id":"item_3","type":"agent_message","text":"Added b.txt."}}
+{"type":"turn.completed","usage":{"input_tokens":30,"cached_input_tokens":10,"output_tokens":4}}
+`))
+
+	client := clients.NewCodexClient("", "", []string{"--skip-git-repo-check"})
+	var events []clients.Event
+	final, err := client.StreamPromptWithDir(context.Background(), "-v is a prompt", t.TempDir(), collectEvents(&events))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Added b.txt.\n" {
+		t.Errorf("expected the agent message, got %q", final)
+	}
+
+	calls := eventsOfType(events, clients.EventToolCall)
+	if len(calls) != 2 || calls[0].ToolName != "shell" || calls[0].Parameters["command"] != "ls" || calls[1].Parameters["b.txt"] != "add" {
+		t.Errorf("expected the command and the file change as tool calls, got %+v", calls)
+	}
+	results := eventsOfType(events, clients.EventToolResult)
+	if len(results) != 2 || results[0].ToolID != "item_1" || results[0].Output != "a.txt\n" || results[1].Status != "success" {
+		t.Errorf("expected the command and file change results, got %+v", results)
+	}
+	if usage := eventsOfType(events, clients.EventUsage); len(usage) != 1 || usage[0].Usage.TotalTokens != 34 {
+		t.Errorf("expected the turn's token usage, got %+v", usage)
+	}
+
+	args := readArgs(t, argsFile)
+	if strings.Join(args, " ") != "exec --json --full-auto --skip-git-repo-check -- -v is a prompt" {
+		t.Errorf("unexpected codex arguments %q", args)
+	}
+}
+
+// TestCodexClientFailedTurn tests that a failed turn and a failing command are reported as errors
+func TestCodexClientFailedTurn(t *testing.T) {
+	installFakeCLI(t, "codex", `cat <<'EOF'
+{"type":"item.completed","item":{"id":"item_1","type":"command_execution","command":"make","aggregated_output":"no rule","exit_code":2,"status":"failed"}}
+{"type":"turn.failed","error":{"message":"stream disconnected"}}
+EOF
+exit 1
+`)
+	var events []clients.Event
+	_, err := clients.NewCodexClient("", "", nil).StreamPromptWithDir(context.Background(), "Build", t.TempDir(), collectEvents(&events))
+	if err == nil {
+		t.Fatal("expected the failed run to return an error")
+	}
+	if results := eventsOfType(events, clients.EventToolResult); len(results) != 1 || results[0].Status != "error" {
+		t.Errorf("expected the failing command's result to be an error, got %+v", results)
+	}
+	if errs := eventsOfType(events, clients.EventError); len(errs) == 0 || errs[0].Text != "stream disconnected" {
+		t.Errorf("expected the turn failure as an error event, got %+v", errs)
+	}
+}
+
+// TestAiderClientPlainText tests that aider's output is streamed as text and its arguments are passed
+func TestAiderClientPlainText(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	installFakeCLI(t, "aider", fakeAgentScript(argsFile, "Applied edit to a.txt\nCommit 1a2b3c4 Add a.txt\n"))
+
+	client := clients.NewAiderClient("", "sonnet", nil)
+	final, err := client.StreamPromptWithDir(context.Background(), "Add a.txt", t.TempDir(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Applied edit to a.txt\nCommit 1a2b3c4 Add a.txt\n" {
+		t.Errorf("expected aider's output as text, got %q", final)
+	}
+	if args := strings.Join(readArgs(t, argsFile), " "); args != "--message Add a.txt --yes-always --no-pretty --no-check-update --model sonnet" {
+		t.Errorf("unexpected aider arguments %q", args)
+	}
+}
+
+// TestAgentCLIProviderRunsTask tests that a task assigned to a CLI provider runs the configured binary in its worktree
+func TestAgentCLIProviderRunsTask(t *testing.T) {
+	setupTempRepo(t)
+	binary := filepath.Join(t.TempDir(), "my-claude")
+	script := "#!/bin/sh\necho done > claude.txt\n" +
+		`echo '{"type":"assistant","message":{"content":[{"type":"text","text":"Wrote claude.txt."}]}}'` + "\n"
+	if err := os.WriteFile(binary, []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake claude: %v", err)
+	}
+	writeTestConfig(t, config.Config{AIProvider: "copilot", Claude: config.AgentCLIConfig{Binary: binary}})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "cl", Name: "Write a file", Status: task.Pending, Provider: "claude"})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "cl", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed {
+		t.Fatalf("expected the task to complete, got %s", task.StatusString(*done))
+	}
+	if len(done.Attempts) == 0 || done.Attempts[0].Provider != "claude" {
+		t.Errorf("expected the attempt to record the claude provider, got %+v", done.Attempts)
+	}
+	if done.Summary != "Wrote claude.txt." {
+		t.Errorf("expected the assistant text as the summary, got %q", done.Summary)
+	}
+}
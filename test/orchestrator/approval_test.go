@@ -0,0 +1,93 @@
+package orchestrator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestApprovalGate tests that a finished task waits for approval, can be sent back with feedback, and is completed once approved
+func TestApprovalGate(t *testing.T) {
+	repo := setupTempRepo(t)
+	// The fake agent writes a file, and a second one once it is given feedback
+	installFakeCLI(t, "copilot", "case \"$*\" in *\"Add a footer\"*) echo footer > footer.txt;; *) echo page > page.txt;; esac\necho done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot", RequireApproval: true})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "gated", Name: "Add a page", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	waiting := waitForTask(t, taskStore, "gated", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.AwaitingApproval || tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if waiting.Status != task.AwaitingApproval {
+		t.Fatalf("expected task to await approval, got %s", task.StatusString(*waiting))
+	}
+	if waiting.WorktreePath == "" || !orchestrator.WorktreeExists(waiting.WorktreePath) {
+		t.Fatal("expected the worktree to be kept while awaiting approval")
+	}
+
+	diff, err := orchestrator.TaskDiff(waiting)
+	if err != nil {
+		t.Fatalf("failed to load diff: %v", err)
+	}
+	if !strings.Contains(diff.Stat, "page.txt") || !strings.Contains(diff.Patch, "+page") {
+		t.Errorf("expected the diff to show page.txt, got %+v", diff)
+	}
+
+	if err := orchestrator.RejectTask(taskStore, "gated", ""); err == nil {
+		t.Error("expected rejecting without feedback to fail")
+	}
+	if err := orchestrator.RejectTask(taskStore, "gated", "Add a footer"); err != nil {
+		t.Fatalf("failed to reject task: %v", err)
+	}
+	waiting = waitForTask(t, taskStore, "gated", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.AwaitingApproval && len(tk.Attempts) == 2
+	})
+	if files := runGit(t, repo, "ls-tree", "-r", "--name-only", waiting.BranchName); !strings.Contains(files, "footer.txt") {
+		t.Errorf("expected the feedback to be worked on in the same branch, got files %q", files)
+	}
+
+	if err := orchestrator.ApproveTask(taskStore, "gated"); err != nil {
+		t.Fatalf("failed to approve task: %v", err)
+	}
+	approved, _ := taskStore.GetTask("gated")
+	if approved.Status != task.Completed || approved.WorktreePath != "" {
+		t.Errorf("expected the task to be completed without a worktree, got %s %q", task.StatusString(*approved), approved.WorktreePath)
+	}
+	if orchestrator.WorktreeExists(waiting.WorktreePath) {
+		t.Error("expected the worktree to be removed after approval")
+	}
+	if err := orchestrator.ApproveTask(taskStore, "gated"); err == nil {
+		t.Error("expected approving a completed task to fail")
+	}
+}
+
+// TestDiscardTask tests that discarding a finished task deletes its branch and archives it
+func TestDiscardTask(t *testing.T) {
+	repo := setupTempRepo(t)
+	runGit(t, repo, "branch", "ludwig/discard-me")
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "d", Name: "Discard me", Status: task.AwaitingApproval, BranchName: "ludwig/discard-me"})
+	taskStore.AddTask(&task.Task{ID: "p", Name: "Pending", Status: task.Pending})
+
+	if err := orchestrator.DiscardTask(taskStore, "p"); err == nil {
+		t.Error("expected discarding a pending task to fail")
+	}
+	if err := orchestrator.DiscardTask(taskStore, "d"); err != nil {
+		t.Fatalf("failed to discard task: %v", err)
+	}
+
+	if exists, _ := orchestrator.BranchExists("ludwig/discard-me"); exists {
+		t.Error("expected the branch to be deleted")
+	}
+	if stored, _ := taskStore.GetTask("d"); stored.Status != task.Archived {
+		t.Errorf("expected the task to be archived, got %s", task.StatusString(*stored))
+	}
+}
@@ -0,0 +1,104 @@
+package orchestrator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// setupTaskWorktree creates ludwig/feature changing name, checked out in a worktree of a task with the given status
+func setupTaskWorktree(t *testing.T, repo, name, content string, status task.Status) storage.TaskStorage {
+	t.Helper()
+	taskStore := setupTaskBranch(t, repo, name, content)
+	worktreePath, err := orchestrator.RestoreWorktree("ludwig/feature", "m")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	stored, _ := taskStore.GetTask("m")
+	stored.Status = status
+	stored.WorktreePath = worktreePath
+	taskStore.UpdateTask(stored)
+	return taskStore
+}
+
+// TestAutoRebaseBeforeApproval tests that approving a stale task rebases its branch onto the base branch first
+func TestAutoRebaseBeforeApproval(t *testing.T) {
+	repo := setupTempRepo(t)
+	writeTestConfig(t, config.Config{Rebase: config.RebasePolicy{Auto: true}})
+	taskStore := setupTaskWorktree(t, repo, "feature.txt", "feature\n", task.AwaitingApproval)
+	commitFile(t, repo, "other.txt", "other\n", "Unrelated change")
+
+	if err := orchestrator.ApproveTask(taskStore, "m"); err != nil {
+		t.Fatalf("failed to approve task: %v", err)
+	}
+	if log := runGit(t, repo, "log", "--format=%s", "ludwig/feature"); !strings.HasPrefix(log, "Change feature.txt\nUnrelated change") {
+		t.Errorf("expected the task commit on top of main, got %q", log)
+	}
+	if _, err := orchestrator.MergeTask(taskStore, "m", config.MergeFastForward); err != nil {
+		t.Errorf("expected the rebased branch to fast-forward main: %v", err)
+	}
+}
+
+// TestAutoRebaseConflictPausesTask tests that a conflicting rebase leaves the branch alone and asks the user how to continue
+func TestAutoRebaseConflictPausesTask(t *testing.T) {
+	repo := setupTempRepo(t)
+	writeTestConfig(t, config.Config{Rebase: config.RebasePolicy{Auto: true}})
+	taskStore := setupTaskWorktree(t, repo, "README.md", "# from the task\n", task.AwaitingApproval)
+	commitFile(t, repo, "README.md", "# from main\n", "Change README on main")
+	before := runGit(t, repo, "rev-parse", "ludwig/feature")
+
+	if err := orchestrator.ApproveTask(taskStore, "m"); err == nil || !strings.Contains(err.Error(), "README.md") {
+		t.Fatalf("expected approval to stop on the conflict, got %v", err)
+	}
+	paused, _ := taskStore.GetTask("m")
+	if paused.Status != task.NeedsReview || paused.Review == nil || len(paused.Review.Conflicts) != 1 || paused.Review.Conflicts[0] != "README.md" {
+		t.Fatalf("expected a review naming the conflicting file, got %s %+v", task.StatusString(*paused), paused.Review)
+	}
+	if paused.ReviewResponse != nil {
+		t.Error("expected the review to wait for the user")
+	}
+	if after := runGit(t, repo, "rev-parse", "ludwig/feature"); after != before {
+		t.Error("expected the branch to be unchanged")
+	}
+	if !orchestrator.WorktreeExists(paused.WorktreePath) {
+		t.Error("expected the worktree to be kept")
+	}
+}
+
+// TestAutoRebaseConflictHandedToAgent tests that with the agent policy a resuming task merges its base branch and resolves the conflicts
+func TestAutoRebaseConflictHandedToAgent(t *testing.T) {
+	repo := setupTempRepo(t)
+	// The fake agent resolves the conflict it is told about by writing both lines and committing the merge
+	installFakeCLI(t, "copilot", "case \"$*\" in *\"merge stopped on conflicts\"*) printf '# from main\\n# from the task\\n' > README.md; git add -A; git commit -qm resolved;; esac\necho done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot", Rebase: config.RebasePolicy{Auto: true, OnConflict: config.ConflictAgent}})
+	taskStore := setupTaskWorktree(t, repo, "README.md", "# from the task\n", task.NeedsReview)
+	commitFile(t, repo, "README.md", "# from main\n", "Change README on main")
+
+	stored, _ := taskStore.GetTask("m")
+	stored.Review = &task.ReviewRequest{Question: "Which heading?", Options: task.YesNoOptions(), CreatedAt: time.Now()}
+	stored.ReviewResponse = &task.ReviewResponse{ChosenOptionID: "yes", ChosenLabel: "Yes", RespondedAt: time.Now()}
+	taskStore.UpdateTask(stored)
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "m", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed {
+		t.Fatalf("expected the task to complete, got %s", task.StatusString(*done))
+	}
+	if _, err := orchestrator.MergeTask(taskStore, "m", config.MergeFastForward); err != nil {
+		t.Fatalf("expected the resolved branch to fast-forward main: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(repo, "README.md"))
+	if string(content) != "# from main\n# from the task\n" {
+		t.Errorf("expected the resolved README on main, got %q", content)
+	}
+}
@@ -0,0 +1,146 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/orchestrator/clients"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestCopilotClientCancelKillsProcessGroup tests that cancelling the context kills the CLI and its children
+func TestCopilotClientCancelKillsProcessGroup(t *testing.T) {
+	// The child sleep keeps stdout open, so the call only returns early if the whole group is killed
+	installFakeCLI(t, "copilot", "echo started\nsleep 30 &\nsleep 30\n")
+
+	cause := errors.New("stop requested")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	time.AfterFunc(200*time.Millisecond, func() { cancel(cause) })
+
+	client := clients.NewCopilotClient("")
+	start := time.Now()
+	final, err := client.StreamPromptWithDir(ctx, "prompt", t.TempDir(), nil)
+
+	if err == nil {
+		t.Fatalf("expected an error after cancellation")
+	}
+	if !errors.Is(err, cause) {
+		t.Errorf("expected error to wrap the cancellation cause, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected cancellation to return promptly, took %v", elapsed)
+	}
+	if final != "started\n" {
+		t.Errorf("expected partial output to be kept, got %q", final)
+	}
+}
+
+// TestGeminiClientCancelSkipsFallback tests that a cancelled request does not fall back to other models
+func TestGeminiClientCancelSkipsFallback(t *testing.T) {
+	installFakeCLI(t, "gemini", "sleep 30\n")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	client := &clients.GeminiClient{}
+	start := time.Now()
+	_, err := client.SendPromptWithDir(ctx, "prompt", nil, t.TempDir())
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected a single cancelled attempt, took %v", elapsed)
+	}
+}
+
+// TestOllamaClientCancelAbortsRequest tests that cancelling the context aborts the HTTP request
+func TestOllamaClientCancelAbortsRequest(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":{"content":"partial"}}` + "\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+	final, err := client.StreamPrompt(ctx, "prompt", nil)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	if final != "partial" {
+		t.Errorf("expected partial text before cancellation, got %q", final)
+	}
+}
+
+// TestCancelUnknownTask tests that cancelling a task that is not running returns an error
+func TestCancelUnknownTask(t *testing.T) {
+	orchestrator.Start()
+	defer orchestrator.Stop()
+
+	if err := orchestrator.Cancel("no-such-task"); err == nil {
+		t.Errorf("expected error when cancelling a task that is not running")
+	}
+}
+
+// TestStopRequeuesRunningTask tests that Stop kills the provider, commits partial work and re-queues the task
+func TestStopRequeuesRunningTask(t *testing.T) {
+	repo := setupTempRepo(t)
+	installFakeCLI(t, "copilot", "echo partial > partial.txt\necho working\nsleep 30\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	taskStore, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	if err := taskStore.AddTask(&task.Task{ID: "stop-task", Name: "Write partial file", Status: task.Pending}); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	orchestrator.Start()
+	running := waitForTask(t, taskStore, "stop-task", 10*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.InProgress && tk.ResponseFile != ""
+	})
+	time.Sleep(300 * time.Millisecond) // Let the fake CLI write its file
+
+	start := time.Now()
+	orchestrator.Stop()
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected Stop to return promptly, took %v", elapsed)
+	}
+
+	stopped, err := taskStore.GetTask("stop-task")
+	if err != nil {
+		t.Fatalf("failed to get task: %v", err)
+	}
+	if stopped.Status != task.Pending {
+		t.Errorf("expected interrupted task to be re-queued as Pending, got %s", task.StatusString(*stopped))
+	}
+	if stopped.WorktreePath == "" || !orchestrator.WorktreeExists(stopped.WorktreePath) {
+		t.Fatalf("expected worktree to be kept, got %q", stopped.WorktreePath)
+	}
+	if status := runGit(t, stopped.WorktreePath, "status", "--porcelain"); status != "" {
+		t.Errorf("expected partial changes to be committed, got status %q", status)
+	}
+	if log := runGit(t, repo, "log", "--oneline", running.BranchName); !strings.Contains(log, "Task interrupted") {
+		t.Errorf("expected partial commit on task branch, got log %q", log)
+	}
+}
@@ -1,6 +1,7 @@
 package orchestrator_test
 
 import (
+	"context"
 	"bytes"
 	"io"
 	"net/http"
@@ -47,22 +48,22 @@ func TestOllamaClientSendPrompt(t *testing.T) {
 			t.Errorf("expected POST request, got %s", r.Method)
 		}
 		
-		if !strings.Contains(r.URL.Path, "/api/generate") {
-			t.Errorf("expected /api/generate endpoint, got %s", r.URL.Path)
+		if !strings.Contains(r.URL.Path, "/api/chat") {
+			t.Errorf("expected /api/chat endpoint, got %s", r.URL.Path)
 		}
 		
 		// Return a simple streaming response
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"model":"mistral","created_at":"2024-01-01T00:00:00Z","response":"Hello"}`))
-		w.Write([]byte(`{"model":"mistral","created_at":"2024-01-01T00:00:01Z","response":" world"}`))
+		w.Write([]byte(`{"model":"mistral","created_at":"2024-01-01T00:00:00Z","message":{"role":"assistant","content":"Hello"}}`))
+		w.Write([]byte(`{"model":"mistral","created_at":"2024-01-01T00:00:01Z","message":{"role":"assistant","content":" world"},"done":true}`))
 	}))
 	defer server.Close()
 	
 	client := clients.NewOllamaClient(server.URL, "mistral")
 	
 	var output bytes.Buffer
-	response, err := client.SendPrompt("test prompt", &output)
+	response, err := client.SendPrompt(context.Background(), "test prompt", &output)
 	
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -82,13 +83,13 @@ func TestOllamaClientSendPromptWithDir(t *testing.T) {
 		
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"model":"mistral","response":"ok"}`))
+		w.Write([]byte(`{"model":"mistral","message":{"role":"assistant","content":"ok"},"done":true}`))
 	}))
 	defer server.Close()
 	
 	client := clients.NewOllamaClient(server.URL, "mistral")
 	
-	_, err := client.SendPromptWithDir("test prompt", nil, "/tmp/workdir")
+	_, err := client.SendPromptWithDir(context.Background(), "test prompt", nil, "/tmp/workdir")
 	
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -105,7 +106,7 @@ func TestOllamaClientConnectionError(t *testing.T) {
 	// Create a client pointing to a non-existent server
 	client := clients.NewOllamaClient("http://localhost:9999", "mistral")
 	
-	_, err := client.SendPrompt("test prompt", nil)
+	_, err := client.SendPrompt(context.Background(), "test prompt", nil)
 	
 	if err == nil {
 		t.Errorf("expected error when connecting to non-existent Ollama server")
@@ -126,7 +127,7 @@ func TestOllamaClientHTTPError(t *testing.T) {
 	
 	client := clients.NewOllamaClient(server.URL, "mistral")
 	
-	_, err := client.SendPrompt("test prompt", nil)
+	_, err := client.SendPrompt(context.Background(), "test prompt", nil)
 	
 	if err == nil {
 		t.Errorf("expected error for HTTP 500")
@@ -142,16 +143,16 @@ func TestOllamaClientStreaming(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("Response "))
-		w.Write([]byte("chunk 1 "))
-		w.Write([]byte("chunk 2"))
+		w.Write([]byte(`{"message":{"content":"Response "}}` + "\n"))
+		w.Write([]byte(`{"message":{"content":"chunk 1 "}}` + "\n"))
+		w.Write([]byte(`{"message":{"content":"chunk 2"},"done":true}` + "\n"))
 	}))
 	defer server.Close()
 	
 	client := clients.NewOllamaClient(server.URL, "mistral")
 	
 	var output bytes.Buffer
-	response, err := client.SendPrompt("test prompt", &output)
+	response, err := client.SendPrompt(context.Background(), "test prompt", &output)
 	
 	if err != nil {
 		t.Errorf("unexpected error: %v", err)
@@ -172,7 +173,7 @@ func TestOllamaClientStreaming(t *testing.T) {
 func TestOllamaClientAIClientInterface(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"response":"ok"}`))
+		w.Write([]byte(`{"message":{"content":"ok"},"done":true}`))
 	}))
 	defer server.Close()
 	
@@ -180,12 +181,12 @@ func TestOllamaClientAIClientInterface(t *testing.T) {
 	var aiClient clients.AIClient = client
 	
 	// Should compile and work without errors
-	_, err := aiClient.SendPrompt("test", nil)
+	_, err := aiClient.SendPrompt(context.Background(), "test", nil)
 	if err != nil {
 		t.Errorf("AIClient interface method failed: %v", err)
 	}
 	
-	_, err = aiClient.SendPromptWithDir("test", nil, "/tmp")
+	_, err = aiClient.SendPromptWithDir(context.Background(), "test", nil, "/tmp")
 	if err != nil {
 		t.Errorf("AIClient interface method with dir failed: %v", err)
 	}
@@ -0,0 +1,123 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/orchestrator/clients"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestCommandClientTemplate tests that the placeholders of the argument template are filled in
+func TestCommandClientTemplate(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	installFakeCLI(t, "my-agent", fakeAgentScript(argsFile, "Working\nDone\n"))
+	workDir := t.TempDir()
+
+	client := clients.NewCommandClient("my-agent", []string{"run", "--model={model}", "--cwd", "{workdir}", "--task", "{prompt}"})
+	client.Model = "local-7b"
+	final, err := client.StreamPromptWithDir(context.Background(), "Fix the bug", workDir, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Working\nDone\n" {
+		t.Errorf("expected the plain output as text, got %q", final)
+	}
+	if args := strings.Join(readArgs(t, argsFile), "|"); args != "run|--model=local-7b|--cwd|"+workDir+"|--task|Fix the bug" {
+		t.Errorf("unexpected arguments %q", args)
+	}
+}
+
+// TestCommandClientPromptPlacement tests that the prompt is appended without {prompt}, and left out when it goes on stdin
+func TestCommandClientPromptPlacement(t *testing.T) {
+	argsFile := filepath.Join(t.TempDir(), "args")
+	installFakeCLI(t, "my-agent", fakeAgentScript(argsFile, ""))
+
+	if _, err := clients.NewCommandClient("my-agent", []string{"--quiet"}).SendPrompt(context.Background(), "Fix the bug", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if args := strings.Join(readArgs(t, argsFile), "|"); args != "--quiet|Fix the bug" {
+		t.Errorf("expected the prompt as the last argument, got %q", args)
+	}
+
+	installFakeCLI(t, "stdin-agent", "echo \"got: $(cat)\"\necho \"args: $#\"\n")
+	client := clients.NewCommandClient("stdin-agent", nil)
+	client.PromptOnStdin = true
+	final, err := client.StreamPrompt(context.Background(), "Fix the bug", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "got: Fix the bug\nargs: 0\n" {
+		t.Errorf("expected the prompt on stdin only, got %q", final)
+	}
+}
+
+// TestCommandClientNDJSON tests that the configured text field is taken from NDJSON output
+func TestCommandClientNDJSON(t *testing.T) {
+	installFakeCLI(t, "json-agent", `cat <<'EOF'
+starting up
+{"event":"step","message":{"content":"Reading files. "}}
+{"event":"tool","name":"edit"}
+{"event":"step","message":{"content":"Done."}}
+EOF
+`)
+	client := clients.NewCommandClient("json-agent", nil)
+	client.Output = clients.CommandOutputNDJSON
+	client.TextField = "message.content"
+	final, err := client.StreamPrompt(context.Background(), "Fix the bug", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "starting up\nReading files. Done." {
+		t.Errorf("expected the text fields and non-JSON lines, got %q", final)
+	}
+}
+
+// TestCommandClientRateLimitExitCode tests that a configured exit code is reported as a rate limit
+func TestCommandClientRateLimitExitCode(t *testing.T) {
+	installFakeCLI(t, "my-agent", "echo 'quota exceeded' >&2\nexit 75\n")
+	client := clients.NewCommandClient("my-agent", nil)
+	client.RateLimitExitCodes = []int{75}
+	_, err := client.SendPrompt(context.Background(), "Fix the bug", nil)
+	if !errors.Is(err, clients.ErrRateLimited) || !strings.Contains(err.Error(), "exit code 75") {
+		t.Errorf("expected a rate limit error, got %v", err)
+	}
+
+	client.RateLimitExitCodes = []int{42}
+	if _, err := client.SendPrompt(context.Background(), "Fix the bug", nil); err == nil || errors.Is(err, clients.ErrRateLimited) {
+		t.Errorf("expected an unlisted exit code to be an ordinary error, got %v", err)
+	}
+}
+
+// TestCommandProviderRateLimitIsRetried tests that a rate-limited run is retried even when its error matches no retryable pattern
+func TestCommandProviderRateLimitIsRetried(t *testing.T) {
+	setupTempRepo(t)
+	installFakeCLI(t, "my-agent", "exit 75\n")
+	writeTestConfig(t, config.Config{
+		AIProvider: "command",
+		Command:    config.CommandProviderConfig{Binary: "my-agent", Model: "local-7b", RateLimitExitCodes: []int{75}},
+		Retry:      config.RetryPolicy{MaxAttempts: 2, BackoffSeconds: 1, RetryableErrors: []string{"overloaded"}},
+	})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "limited", Name: "Rate limited task", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	failed := waitForTask(t, taskStore, "limited", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Failed
+	})
+	if len(failed.Attempts) != 2 {
+		t.Fatalf("expected the rate-limited run to be retried, got %d attempt(s)", len(failed.Attempts))
+	}
+	if attempt := failed.Attempts[0]; attempt.Provider != "command" || attempt.Model != "local-7b" || !strings.Contains(attempt.Error, "rate limited") {
+		t.Errorf("unexpected attempt %+v", attempt)
+	}
+}
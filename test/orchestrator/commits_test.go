@@ -0,0 +1,86 @@
+package orchestrator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestCommitMessage tests that commit messages use the task name, the AI's summary and a task trailer
+func TestCommitMessage(t *testing.T) {
+	message := orchestrator.CommitMessage(&task.Task{ID: "abc", Name: "Add a footer", Summary: "Added footer.html."})
+	if message != "Add a footer\n\nAdded footer.html.\n\nLudwig-Task: abc" {
+		t.Errorf("unexpected commit message %q", message)
+	}
+
+	long := orchestrator.CommitMessage(&task.Task{ID: "abc", Name: strings.Repeat("word ", 30)})
+	subject := strings.SplitN(long, "\n", 2)[0]
+	if len(subject) > 72 || !strings.HasSuffix(subject, "...") {
+		t.Errorf("expected a long task name to be shortened, got %q", subject)
+	}
+	if !strings.HasSuffix(long, "\n\nLudwig-Task: abc") {
+		t.Errorf("expected only the trailer after the subject without a summary, got %q", long)
+	}
+}
+
+// TestCompletionCommitMessage tests that work the AI left uncommitted is committed with the task's message
+func TestCompletionCommitMessage(t *testing.T) {
+	repo := setupTempRepo(t)
+	installFakeCLI(t, "copilot", "echo page > page.txt\necho Added page.txt with a greeting.\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "c", Name: "Add a page", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "c", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed {
+		t.Fatalf("expected the task to complete, got %s", task.StatusString(*done))
+	}
+	message := strings.TrimSpace(runGit(t, repo, "log", "-1", "--format=%B", done.BranchName))
+	if !strings.HasPrefix(message, "Add a page\n\n") || !strings.Contains(message, "Added page.txt with a greeting.") || !strings.HasSuffix(message, "Ludwig-Task: c") {
+		t.Errorf("unexpected completion commit message %q", message)
+	}
+}
+
+// TestSquashOnCompletion tests that squash mode collapses the AI's commits into one commit with the configured identity
+func TestSquashOnCompletion(t *testing.T) {
+	repo := setupTempRepo(t)
+	// The fake agent commits twice and leaves a third change uncommitted
+	installFakeCLI(t, "copilot", "echo a > a.txt; git add a.txt; git commit -qm wip1\necho b > b.txt; git add b.txt; git commit -qm wip2\necho c > c.txt\necho Added three files.\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot", Commit: config.CommitConfig{Squash: true, AuthorName: "Ludwig Bot", AuthorEmail: "ludwig@example.com"}})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "s", Name: "Add three files", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "s", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed {
+		t.Fatalf("expected the task to complete, got %s", task.StatusString(*done))
+	}
+
+	if count := strings.TrimSpace(runGit(t, repo, "rev-list", "--count", "main.."+done.BranchName)); count != "1" {
+		t.Fatalf("expected a single commit on the branch, got %s", count)
+	}
+	if files := runGit(t, repo, "ls-tree", "-r", "--name-only", done.BranchName); !strings.Contains(files, "a.txt") || !strings.Contains(files, "c.txt") {
+		t.Errorf("expected the squashed commit to keep every change, got files %q", files)
+	}
+	message := strings.TrimSpace(runGit(t, repo, "log", "-1", "--format=%B", done.BranchName))
+	if !strings.HasPrefix(message, "Add three files\n\nAdded three files.") || !strings.HasSuffix(message, "Ludwig-Task: s") {
+		t.Errorf("unexpected squashed commit message %q", message)
+	}
+	if identity := strings.TrimSpace(runGit(t, repo, "log", "-1", "--format=%an <%ae> %cn", done.BranchName)); identity != "Ludwig Bot <ludwig@example.com> Ludwig Bot" {
+		t.Errorf("expected the configured identity, got %q", identity)
+	}
+}
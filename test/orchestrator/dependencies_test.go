@@ -0,0 +1,107 @@
+package orchestrator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestValidateDependencies tests that missing tasks, self-dependencies and cycles are rejected
+func TestValidateDependencies(t *testing.T) {
+	setupTempRepo(t)
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "schema", Name: "Add the schema", Status: task.Pending})
+	taskStore.AddTask(&task.Task{ID: "api", Name: "Build the API", Status: task.Pending, DependsOn: []string{"schema"}})
+
+	if err := orchestrator.ValidateDependencies(taskStore, "new", []string{"api"}); err != nil {
+		t.Errorf("expected a new task to depend on api, got %v", err)
+	}
+	if err := orchestrator.ValidateDependencies(taskStore, "new", []string{"missing"}); err == nil {
+		t.Errorf("expected an error for a missing dependency")
+	}
+	if err := orchestrator.ValidateDependencies(taskStore, "schema", []string{"schema"}); err == nil {
+		t.Errorf("expected an error for a self-dependency")
+	}
+	err := orchestrator.AddDependencies(taskStore, "schema", []string{"api"})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected a cycle to be rejected, got %v", err)
+	}
+	schema, _ := taskStore.GetTask("schema")
+	if len(schema.DependsOn) != 0 {
+		t.Errorf("expected rejected dependency not to be saved, got %v", schema.DependsOn)
+	}
+}
+
+// TestDependentTaskWaitsAndStartsFromDependencyBranch tests that a task waits for its dependency,
+// its worktree is created from the dependency's branch, and it is still merged into the base branch
+func TestDependentTaskWaitsAndStartsFromDependencyBranch(t *testing.T) {
+	repo := setupTempRepo(t)
+	installFakeCLI(t, "copilot", "echo done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	// The dependency's finished work lives on its branch
+	runGit(t, repo, "branch", "ludwig/add-schema")
+	schemaWorktree := t.TempDir()
+	runGit(t, repo, "worktree", "add", schemaWorktree, "ludwig/add-schema")
+	runGit(t, schemaWorktree, "commit", "-q", "--allow-empty", "-m", "Add schema")
+	runGit(t, repo, "worktree", "remove", "-f", schemaWorktree)
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "schema", Name: "Add the schema", Status: task.Failed, BranchName: "ludwig/add-schema"})
+	taskStore.AddTask(&task.Task{ID: "api", Name: "Build the API", Status: task.Pending, DependsOn: []string{"schema"}})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+
+	time.Sleep(2500 * time.Millisecond) // A full poll with the dependency unfinished
+	if api, _ := taskStore.GetTask("api"); api.Status != task.Pending || len(api.Attempts) != 0 {
+		t.Fatalf("expected blocked task to wait, got %s with %d attempts", task.StatusString(*api), len(api.Attempts))
+	}
+
+	schema, _ := taskStore.GetTask("schema")
+	schema.Status = task.Completed
+	taskStore.UpdateTask(schema)
+
+	api := waitForTask(t, taskStore, "api", 10*time.Second, func(tk *task.Task) bool { return tk.Status == task.Completed })
+	if api.StartRef != "ludwig/add-schema" || api.BaseBranch != "" {
+		t.Errorf("expected the task to start from ludwig/add-schema and keep the default base branch, got start %q and base %q", api.StartRef, api.BaseBranch)
+	}
+	if log := runGit(t, repo, "log", "--oneline", api.BranchName); !strings.Contains(log, "Add schema") {
+		t.Errorf("expected task branch to contain the dependency's commit, got %q", log)
+	}
+	if target, err := orchestrator.MergeTask(taskStore, "api", "ff"); err != nil || target != "main" {
+		t.Errorf("expected the task to merge into main, got %q (%v)", target, err)
+	}
+}
+
+// TestMergeIntoWorktreeAbortsConflicts tests that a conflicting merge leaves the worktree clean
+func TestMergeIntoWorktreeAbortsConflicts(t *testing.T) {
+	repo := setupTempRepo(t)
+	for _, branch := range []string{"ludwig/one", "ludwig/two"} {
+		dir := t.TempDir()
+		runGit(t, repo, "worktree", "add", "-q", "-b", branch, dir, "main")
+		if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte(branch+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		runGit(t, dir, "commit", "-q", "-am", "Edit on "+branch)
+		runGit(t, repo, "worktree", "remove", "-f", dir)
+	}
+
+	worktreePath, err := orchestrator.CreateWorktreeFrom("ludwig/combined", "combined", "ludwig/one")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	if err := orchestrator.MergeIntoWorktree(nil, worktreePath, "ludwig/two"); err == nil {
+		t.Fatalf("expected conflicting merge to fail")
+	}
+	if status := runGit(t, worktreePath, "status", "--porcelain"); status != "" {
+		t.Errorf("expected merge to be aborted, got status %q", status)
+	}
+}
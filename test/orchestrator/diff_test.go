@@ -0,0 +1,38 @@
+package orchestrator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ludwig/internal/orchestrator"
+)
+
+// TestDiffBranchListsCommits tests that a branch diff carries the branch's own commits, newest first
+func TestDiffBranchListsCommits(t *testing.T) {
+	repo := setupTempRepo(t)
+	runGit(t, repo, "checkout", "-q", "-b", "ludwig/two-commits")
+	for _, name := range []string{"first", "second"} {
+		if err := os.WriteFile(filepath.Join(repo, name+".txt"), []byte(name+"\n"), 0644); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+		runGit(t, repo, "add", "-A")
+		runGit(t, repo, "commit", "-q", "-m", "Add "+name)
+	}
+	runGit(t, repo, "checkout", "-q", "main")
+
+	diff, err := orchestrator.DiffBranch("ludwig/two-commits", "main")
+	if err != nil {
+		t.Fatalf("failed to diff branch: %v", err)
+	}
+	if len(diff.Commits) != 2 || diff.Commits[0].Subject != "Add second" || diff.Commits[1].Subject != "Add first" {
+		t.Fatalf("expected the branch's two commits newest first, got %+v", diff.Commits)
+	}
+	if diff.Commits[0].Hash == "" || diff.Commits[0].Author != "Test" {
+		t.Errorf("expected hash and author to be set, got %+v", diff.Commits[0])
+	}
+	if !strings.Contains(diff.Patch, "+first") || !strings.Contains(diff.Patch, "+second") {
+		t.Errorf("expected both files in the patch, got %q", diff.Patch)
+	}
+}
@@ -0,0 +1,239 @@
+package orchestrator_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"ludwig/internal/orchestrator/clients"
+)
+
+// collectEvents returns an EventHandler that appends every event to the given slice
+func collectEvents(events *[]clients.Event) clients.EventHandler {
+	return func(ev clients.Event) {
+		*events = append(*events, ev)
+	}
+}
+
+// TestOllamaStreamPromptEmitsEvents tests that Ollama /api/chat NDJSON is decoded into text, usage and result events
+func TestOllamaStreamPromptEmitsEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hello"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":" \"world\"\n"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":12,"eval_count":5}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+
+	var events []clients.Event
+	final, err := client.StreamPromptWithDir(context.Background(), "test prompt", "", collectEvents(&events))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if final != "Hello \"world\"\n" {
+		t.Errorf("expected unescaped final text, got %q", final)
+	}
+
+	var types []clients.EventType
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	expected := []clients.EventType{clients.EventText, clients.EventText, clients.EventUsage, clients.EventResult}
+	if len(types) != len(expected) {
+		t.Fatalf("expected event types %v, got %v", expected, types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("event %d: expected %s, got %s", i, expected[i], types[i])
+		}
+	}
+
+	usage := events[2].Usage
+	if usage == nil || usage.InputTokens != 12 || usage.OutputTokens != 5 || usage.TotalTokens != 17 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if events[3].Text != final {
+		t.Errorf("result event should carry the final text, got %q", events[3].Text)
+	}
+}
+
+// TestOllamaStreamPromptConcatenatedObjects tests decoding several JSON objects on one line
+func TestOllamaStreamPromptConcatenatedObjects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":{"content":"Hello"}}{"message":{"content":" world"}}`))
+	}))
+	defer server.Close()
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+	final, err := client.StreamPrompt(context.Background(), "test prompt", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Hello world" {
+		t.Errorf("expected 'Hello world', got %q", final)
+	}
+}
+
+// TestOllamaStreamPromptErrorEvent tests that HTTP errors produce an error event and an error result
+func TestOllamaStreamPromptErrorEvent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("boom"))
+	}))
+	defer server.Close()
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+
+	var events []clients.Event
+	_, err := client.StreamPrompt(context.Background(), "test prompt", collectEvents(&events))
+	if err == nil {
+		t.Fatalf("expected error for HTTP 500")
+	}
+	if len(events) != 2 || events[0].Type != clients.EventError || events[1].Type != clients.EventResult {
+		t.Fatalf("expected error then result events, got %+v", events)
+	}
+	if events[1].Status != "error" {
+		t.Errorf("expected result status 'error', got %q", events[1].Status)
+	}
+}
+
+// TestReviewMarkersFoundInAssistantText tests that markers split across deltas survive in the final text
+func TestReviewMarkersFoundInAssistantText(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"message":{"content":"Done.\n---NEEDS_"}}` + "\n"))
+		w.Write([]byte(`{"message":{"content":"REVIEW---\nQuestion: \"A\" or B?\n---END_REVIEW---"}}` + "\n"))
+	}))
+	defer server.Close()
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+	final, err := client.StreamPrompt(context.Background(), "test prompt", nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(final, "---NEEDS_REVIEW---\nQuestion: \"A\" or B?\n") {
+		t.Errorf("expected raw review block in final text, got %q", final)
+	}
+}
+
+// TestEventLoggerWritesJSONLines tests that the event logger writes one decodable JSON object per line
+func TestEventLoggerWritesJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	log := clients.NewEventLogger(&buf)
+
+	log(clients.Event{Type: clients.EventText, Text: "line one\nline two"})
+	log(clients.Event{Type: clients.EventToolCall, ToolName: "read_file", Parameters: map[string]any{"path": "a.go"}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+
+	var ev clients.Event
+	if err := json.Unmarshal([]byte(lines[0]), &ev); err != nil {
+		t.Fatalf("failed to decode event: %v", err)
+	}
+	if ev.Type != clients.EventText || ev.Text != "line one\nline two" {
+		t.Errorf("unexpected decoded event: %+v", ev)
+	}
+}
+
+// TestGeminiStreamPromptDecodesStreamJSON runs a fake gemini binary and checks the decoded events
+func TestGeminiStreamPromptDecodesStreamJSON(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake gemini script requires a POSIX shell")
+	}
+
+	binDir := t.TempDir()
+	script := `#!/bin/sh
+echo '{"type":"init","timestamp":"2025-01-01T00:00:00Z","model":"test"}'
+echo '{"type":"message","timestamp":"2025-01-01T00:00:00Z","role":"user","content":"prompt echo"}'
+echo '{"type":"message","timestamp":"2025-01-01T00:00:01Z","role":"assistant","content":"Working","delta":true}'
+echo '{"type":"tool_use","timestamp":"2025-01-01T00:00:02Z","tool_name":"run_shell_command","tool_id":"t1","parameters":{"command":"go test ./..."}}'
+echo '{"type":"tool_result","timestamp":"2025-01-01T00:00:03Z","tool_id":"t1","status":"success","output":"ok"}'
+echo '{"type":"message","timestamp":"2025-01-01T00:00:04Z","role":"assistant","content":" done","delta":true}'
+echo '{"type":"result","timestamp":"2025-01-01T00:00:05Z","status":"success","stats":{"total_tokens":30,"input_tokens":20,"output_tokens":10}}'
+`
+	if err := os.WriteFile(filepath.Join(binDir, "gemini"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake gemini: %v", err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	client := &clients.GeminiClient{}
+	var events []clients.Event
+	final, err := client.StreamPromptWithDir(context.Background(), "prompt", t.TempDir(), collectEvents(&events))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Working done" {
+		t.Errorf("expected final text 'Working done', got %q", final)
+	}
+
+	expected := []clients.EventType{
+		clients.EventText, clients.EventToolCall, clients.EventToolResult,
+		clients.EventText, clients.EventUsage, clients.EventResult,
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %+v", len(expected), len(events), events)
+	}
+	for i := range expected {
+		if events[i].Type != expected[i] {
+			t.Errorf("event %d: expected %s, got %s", i, expected[i], events[i].Type)
+		}
+	}
+	if events[1].ToolName != "run_shell_command" || events[1].Parameters["command"] != "go test ./..." {
+		t.Errorf("unexpected tool call event: %+v", events[1])
+	}
+	if events[4].Usage == nil || events[4].Usage.TotalTokens != 30 {
+		t.Errorf("unexpected usage event: %+v", events[4])
+	}
+}
+
+// TestGeminiStreamPromptFallbackKeepsLastRunText tests that text from a model that failed is not repeated in the final text
+func TestGeminiStreamPromptFallbackKeepsLastRunText(t *testing.T) {
+	installFakeCLI(t, "gemini", `case "$*" in
+*auto-gemini-3*) echo '{"type":"message","role":"assistant","content":"Half an answer","delta":true}'; exit 1;;
+*) echo '{"type":"message","role":"assistant","content":"Full answer","delta":true}';;
+esac
+`)
+	client := &clients.GeminiClient{}
+	var events []clients.Event
+	final, err := client.StreamPromptWithDir(context.Background(), "prompt", t.TempDir(), collectEvents(&events))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Full answer" {
+		t.Errorf("expected only the fallback model's text, got %q", final)
+	}
+	if texts := eventsOfType(events, clients.EventText); len(texts) != 2 || texts[0].Text != "Half an answer" {
+		t.Errorf("expected both runs' text to be streamed, got %+v", texts)
+	}
+}
+
+// TestReplayTextRecoversAssistantText tests recovering text from complete and truncated response logs
+func TestReplayTextRecoversAssistantText(t *testing.T) {
+	header := "# AI Response for Task: t\n\nGenerated: now\n\n---\n\n"
+	truncated := header +
+		`{"type":"text","timestamp":"2025-01-01T00:00:00Z","text":"Hello"}` + "\n" +
+		`{"type":"tool_call","timestamp":"2025-01-01T00:00:01Z","tool_name":"read_file"}` + "\n" +
+		`{"type":"text","timestamp":"2025-01-01T00:00:02Z","text":" world"}` + "\n"
+	if got := clients.ReplayText(truncated); got != "Hello world" {
+		t.Errorf("expected concatenated text deltas, got %q", got)
+	}
+
+	complete := truncated + `{"type":"result","timestamp":"2025-01-01T00:00:03Z","text":"Final text","status":"success"}` + "\n\n---\n\nCompleted: now\n"
+	if got := clients.ReplayText(complete); got != "Final text" {
+		t.Errorf("expected result event text, got %q", got)
+	}
+}
@@ -0,0 +1,130 @@
+package orchestrator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// writeResponse creates an empty response file for a task and returns its path relative to .ludwig
+func writeResponse(t *testing.T, taskID string) string {
+	t.Helper()
+	writer, path, err := storage.NewResponseWriter(taskID)
+	if err != nil {
+		t.Fatalf("failed to create response file: %v", err)
+	}
+	writer.Close()
+	return path
+}
+
+// gcItemNames returns the names of the report's items of one kind
+func gcItemNames(report *orchestrator.GCReport, kind string) []string {
+	var names []string
+	for _, item := range report.Items {
+		if item.Kind == kind {
+			names = append(names, item.Name)
+		}
+	}
+	return names
+}
+
+// TestCollectGarbage tests that gc reports merged and orphaned branches, stray worktrees and responses of deleted tasks,
+// leaves them alone on a dry run and removes them when pruning
+func TestCollectGarbage(t *testing.T) {
+	repo := setupTempRepo(t)
+	for _, branch := range []string{"ludwig/merged", "ludwig/unmerged", "ludwig/in-review", "ludwig/orphan"} {
+		runGit(t, repo, "branch", branch)
+	}
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "merged", Name: "Merged", Status: task.Completed, BranchName: "ludwig/merged", MergedAt: time.Now()})
+	taskStore.AddTask(&task.Task{ID: "unmerged", Name: "Unmerged", Status: task.Completed, BranchName: "ludwig/unmerged"})
+	taskStore.AddTask(&task.Task{ID: "review", Name: "In review", Status: task.NeedsReview, BranchName: "ludwig/in-review", MergedAt: time.Now()})
+	ghost := filepath.Join(repo, ".worktrees", "ghost")
+	if err := os.MkdirAll(ghost, 0755); err != nil {
+		t.Fatalf("failed to create stray worktree: %v", err)
+	}
+	deleted := writeResponse(t, "deleted-task")
+	kept := writeResponse(t, "unmerged")
+
+	report, err := orchestrator.CollectGarbage(taskStore, nil, false)
+	if err != nil {
+		t.Fatalf("failed to collect garbage: %v", err)
+	}
+	if branches := strings.Join(gcItemNames(report, orchestrator.GCBranch), ","); branches != "ludwig/merged,ludwig/orphan" {
+		t.Errorf("expected the merged and orphaned branches, got %s", branches)
+	}
+	if worktrees := gcItemNames(report, orchestrator.GCWorktree); len(worktrees) != 1 || worktrees[0] != ghost {
+		t.Errorf("expected the stray worktree, got %v", worktrees)
+	}
+	if responses := gcItemNames(report, orchestrator.GCResponse); len(responses) != 1 || responses[0] != deleted {
+		t.Errorf("expected the deleted task's response file, got %v", responses)
+	}
+	if !strings.Contains(report.String(), "gc --prune") {
+		t.Errorf("expected the dry run to explain how to prune, got %q", report.String())
+	}
+	if _, err := os.Stat(ghost); err != nil {
+		t.Error("expected a dry run to leave the worktree")
+	}
+
+	report, err = orchestrator.CollectGarbage(taskStore, nil, true)
+	if err != nil {
+		t.Fatalf("failed to prune: %v", err)
+	}
+	for _, item := range report.Items {
+		if item.Err != "" {
+			t.Errorf("failed to remove %s: %s", item.Name, item.Err)
+		}
+	}
+	for _, branch := range []string{"ludwig/merged", "ludwig/orphan"} {
+		if exists, _ := orchestrator.BranchExists(branch); exists {
+			t.Errorf("expected %s to be deleted", branch)
+		}
+	}
+	for _, branch := range []string{"ludwig/unmerged", "ludwig/in-review"} {
+		if exists, _ := orchestrator.BranchExists(branch); !exists {
+			t.Errorf("expected %s to be kept", branch)
+		}
+	}
+	if _, err := os.Stat(ghost); !os.IsNotExist(err) {
+		t.Error("expected the stray worktree to be removed")
+	}
+	if _, err := storage.ReadResponse(filepath.Join("responses", "archive", filepath.Base(deleted))); err != nil {
+		t.Errorf("expected the deleted task's response to be archived: %v", err)
+	}
+	if _, err := storage.ReadResponse(kept); err != nil {
+		t.Errorf("expected the existing task's response to be kept: %v", err)
+	}
+}
+
+// TestCollectGarbageRetention tests the configured retention rules for merged, old and orphaned branches
+func TestCollectGarbageRetention(t *testing.T) {
+	repo := setupTempRepo(t)
+	runGit(t, repo, "branch", "ludwig/recently-merged")
+	runGit(t, repo, "branch", "ludwig/orphan")
+	// A branch whose last commit is long past
+	runGit(t, repo, "checkout", "-q", "-b", "ludwig/old")
+	t.Setenv("GIT_COMMITTER_DATE", "2020-01-01T00:00:00")
+	commitFile(t, repo, "old.txt", "old\n", "Old work")
+	os.Unsetenv("GIT_COMMITTER_DATE")
+	runGit(t, repo, "checkout", "-q", "main")
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "recent", Name: "Recent", Status: task.Completed, BranchName: "ludwig/recently-merged", MergedAt: time.Now()})
+	taskStore.AddTask(&task.Task{ID: "old", Name: "Old", Status: task.Failed, BranchName: "ludwig/old"})
+
+	cfg := &config.Config{GC: config.GCPolicy{MergedBranchDays: 7, MaxBranchAgeDays: 30, KeepOrphanedBranches: true}}
+	report, err := orchestrator.CollectGarbage(taskStore, cfg, false)
+	if err != nil {
+		t.Fatalf("failed to collect garbage: %v", err)
+	}
+	if branches := gcItemNames(report, orchestrator.GCBranch); len(branches) != 1 || branches[0] != "ludwig/old" {
+		t.Errorf("expected only the old branch to be collected, got %v", branches)
+	}
+}
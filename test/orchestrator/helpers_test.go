@@ -0,0 +1,110 @@
+package orchestrator_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestMain runs the tests in a scratch repository, so tests that do not set up their own
+// leave their .ludwig, worktrees and branches there instead of in the Ludwig checkout
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "ludwig-orchestrator-test")
+	if err != nil {
+		panic(err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "--allow-empty", "-m", "initial"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if output, err := cmd.CombinedOutput(); err != nil {
+			panic(fmt.Sprintf("git %v failed: %v\n%s", args, err, output))
+		}
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// setupTempRepo creates a git repository with one commit on main and makes it the working directory
+func setupTempRepo(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "config", "user.email", "test@example.com")
+	runGit(t, dir, "config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# test\n"), 0644); err != nil {
+		t.Fatalf("failed to write README: %v", err)
+	}
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "-q", "-m", "initial")
+	t.Chdir(dir)
+	return dir
+}
+
+// runGit runs a git command in dir and fails the test on error
+func runGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+	return string(output)
+}
+
+// writeTestConfig saves cfg to .ludwig/config.json in the working directory
+func writeTestConfig(t *testing.T, cfg config.Config) {
+	t.Helper()
+	if err := os.MkdirAll(".ludwig", 0755); err != nil {
+		t.Fatalf("failed to create .ludwig: %v", err)
+	}
+	data, _ := json.Marshal(cfg)
+	if err := os.WriteFile(filepath.Join(".ludwig", "config.json"), data, 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+// waitForTask polls storage until cond holds for the task or the timeout expires
+func waitForTask(t *testing.T, taskStore storage.TaskStorage, id string, timeout time.Duration, cond func(*task.Task) bool) *task.Task {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for {
+		current, err := taskStore.GetTask(id)
+		if err == nil && cond(current) {
+			return current
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for task %s (last state: %+v, err: %v)", id, current, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// installFakeCLI writes a shell script named name into a temp dir and puts it first on PATH
+func installFakeCLI(t *testing.T, name string, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake CLI scripts require a POSIX shell")
+	}
+	binDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\n"+script), 0755); err != nil {
+		t.Fatalf("failed to write fake %s: %v", name, err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
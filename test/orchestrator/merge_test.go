@@ -0,0 +1,163 @@
+package orchestrator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// commitFile writes a file on the branch checked out in dir and commits it
+func commitFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", name, err)
+	}
+	runGit(t, dir, "add", name)
+	runGit(t, dir, "commit", "-q", "-m", message)
+}
+
+// setupTaskBranch creates ludwig/feature with one commit adding name, and a Completed task for it
+func setupTaskBranch(t *testing.T, repo, name, content string) storage.TaskStorage {
+	t.Helper()
+	runGit(t, repo, "checkout", "-q", "-b", "ludwig/feature")
+	commitFile(t, repo, name, content, "Change "+name)
+	runGit(t, repo, "checkout", "-q", "main")
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "m", Name: "Add the feature", Status: task.Completed, BranchName: "ludwig/feature"})
+	return taskStore
+}
+
+// TestMergeTaskFastForward tests that a completed branch fast-forwards the checked out base branch
+func TestMergeTaskFastForward(t *testing.T) {
+	repo := setupTempRepo(t)
+	taskStore := setupTaskBranch(t, repo, "feature.txt", "feature\n")
+
+	target, err := orchestrator.MergeTask(taskStore, "m", config.MergeFastForward)
+	if err != nil {
+		t.Fatalf("failed to merge: %v", err)
+	}
+	if target != "main" {
+		t.Errorf("expected to merge into main, got %q", target)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "feature.txt")); err != nil {
+		t.Errorf("expected the checkout to follow main: %v", err)
+	}
+	if stored, _ := taskStore.GetTask("m"); stored.MergedAt.IsZero() {
+		t.Error("expected the task to be marked as merged")
+	}
+	if _, err := orchestrator.MergeTask(taskStore, "m", "octopus"); err == nil {
+		t.Error("expected an unknown strategy to be rejected")
+	}
+}
+
+// TestMergeTaskAfterRebase tests that a branch behind its base must be rebased before it can fast-forward
+func TestMergeTaskAfterRebase(t *testing.T) {
+	repo := setupTempRepo(t)
+	taskStore := setupTaskBranch(t, repo, "feature.txt", "feature\n")
+	commitFile(t, repo, "other.txt", "other\n", "Unrelated change")
+
+	if _, err := orchestrator.MergeTask(taskStore, "m", config.MergeFastForward); err == nil || !strings.Contains(err.Error(), "rebase") {
+		t.Fatalf("expected the fast-forward to be refused, got %v", err)
+	}
+	if _, err := orchestrator.RebaseTask(taskStore, "m"); err != nil {
+		t.Fatalf("failed to rebase: %v", err)
+	}
+	if _, err := orchestrator.MergeTask(taskStore, "m", config.MergeFastForward); err != nil {
+		t.Fatalf("failed to merge after rebasing: %v", err)
+	}
+	if log := runGit(t, repo, "log", "--format=%s", "main"); !strings.HasPrefix(log, "Change feature.txt\nUnrelated change") {
+		t.Errorf("expected the task commit on top of main, got %q", log)
+	}
+	if dirs, _ := orchestrator.ListWorktreeDirs(); len(dirs) != 0 {
+		t.Errorf("expected the temporary worktree to be removed, got %v", dirs)
+	}
+}
+
+// TestMergeTaskSquash tests that a squash merge adds one commit named after the task, made as the configured identity
+func TestMergeTaskSquash(t *testing.T) {
+	repo := setupTempRepo(t)
+	taskStore := setupTaskBranch(t, repo, "feature.txt", "feature\n")
+	commitFile(t, repo, "other.txt", "other\n", "Unrelated change")
+	writeTestConfig(t, config.Config{Commit: config.CommitConfig{AuthorName: "Ludwig Bot", AuthorEmail: "ludwig@example.com"}})
+
+	if _, err := orchestrator.MergeTask(taskStore, "m", config.MergeSquash); err != nil {
+		t.Fatalf("failed to squash: %v", err)
+	}
+	if subject := runGit(t, repo, "log", "-1", "--format=%s", "main"); strings.TrimSpace(subject) != "Add the feature" {
+		t.Errorf("expected a commit named after the task, got %q", subject)
+	}
+	if identity := strings.TrimSpace(runGit(t, repo, "log", "-1", "--format=%an <%ae> %cn", "main")); identity != "Ludwig Bot <ludwig@example.com> Ludwig Bot" {
+		t.Errorf("expected the squashed commit to use the configured identity, got %q", identity)
+	}
+	if _, err := os.Stat(filepath.Join(repo, "feature.txt")); err != nil {
+		t.Errorf("expected the checkout to follow main: %v", err)
+	}
+}
+
+// TestMergeConflictsAreReported tests that conflicting changes abort the merge or rebase and name the files
+func TestMergeConflictsAreReported(t *testing.T) {
+	repo := setupTempRepo(t)
+	taskStore := setupTaskBranch(t, repo, "README.md", "# from the task\n")
+	commitFile(t, repo, "README.md", "# from main\n", "Change README on main")
+	before := runGit(t, repo, "rev-parse", "main", "ludwig/feature")
+
+	for name, merge := range map[string]func() error{
+		"squash": func() error { _, err := orchestrator.MergeTask(taskStore, "m", config.MergeSquash); return err },
+		"rebase": func() error { _, err := orchestrator.RebaseTask(taskStore, "m"); return err },
+	} {
+		var conflict *orchestrator.MergeConflictError
+		if err := merge(); !errors.As(err, &conflict) {
+			t.Fatalf("%s: expected a conflict error, got %v", name, err)
+		}
+		if len(conflict.Files) != 1 || conflict.Files[0] != "README.md" || conflict.Onto != "main" {
+			t.Errorf("%s: unexpected conflict %+v", name, conflict)
+		}
+	}
+	if after := runGit(t, repo, "rev-parse", "main", "ludwig/feature"); after != before {
+		t.Errorf("expected both branches to be unchanged")
+	}
+}
+
+// TestResolveConflictsResumesTask tests that conflicts handed back to the AI are resolved in the task's worktree
+func TestResolveConflictsResumesTask(t *testing.T) {
+	repo := setupTempRepo(t)
+	// The fake agent resolves the conflict by writing both lines and committing the merge
+	installFakeCLI(t, "copilot", "case \"$*\" in *\"Resolve the conflicts in README.md\"*) printf '# from main\\n# from the task\\n' > README.md; git add -A; git commit -qm resolved;; esac\necho done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+	taskStore := setupTaskBranch(t, repo, "README.md", "# from the task\n")
+	commitFile(t, repo, "README.md", "# from main\n", "Change README on main")
+
+	conflicted, err := orchestrator.ResolveConflicts(taskStore, "m")
+	if err != nil || !conflicted {
+		t.Fatalf("expected the conflicts to be handed back, got %v, %v", conflicted, err)
+	}
+	waiting, _ := taskStore.GetTask("m")
+	if waiting.Status != task.NeedsReview || waiting.ReviewResponse == nil {
+		t.Fatalf("expected the task to wait to resume, got %s", task.StatusString(*waiting))
+	}
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "m", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed {
+		t.Fatalf("expected the task to complete, got %s", task.StatusString(*done))
+	}
+	if _, err := orchestrator.MergeTask(taskStore, "m", config.MergeFastForward); err != nil {
+		t.Fatalf("expected the resolved branch to fast-forward main: %v", err)
+	}
+	content, _ := os.ReadFile(filepath.Join(repo, "README.md"))
+	if string(content) != "# from main\n# from the task\n" {
+		t.Errorf("expected the resolved README on main, got %q", content)
+	}
+}
@@ -0,0 +1,216 @@
+package orchestrator_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator/clients"
+)
+
+// chatRequest mirrors the parts of an /api/chat request the tests inspect
+type chatRequest struct {
+	Messages []struct {
+		Role     string `json:"role"`
+		Content  string `json:"content"`
+		ToolName string `json:"tool_name"`
+	} `json:"messages"`
+	Tools []json.RawMessage `json:"tools"`
+}
+
+// newToolCallServer starts a mock Ollama server that answers each request with the next reply,
+// recording the requests it received
+func newToolCallServer(t *testing.T, replies []string, requests *[]chatRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		*requests = append(*requests, req)
+		reply := replies[min(len(*requests), len(replies))-1]
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(reply + "\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestOllamaToolLoop tests that the model's tool calls are run in the working directory, streamed as events
+// and answered, until the model replies with text
+func TestOllamaToolLoop(t *testing.T) {
+	workDir := t.TempDir()
+	var requests []chatRequest
+	server := newToolCallServer(t, []string{
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"write_file","arguments":{"path":"docs/hello.txt","content":"hello\n"}}}]},"done":true}`,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"read_file","arguments":{"path":"../outside.txt"}}}]},"done":true}`,
+		`{"message":{"role":"assistant","content":"Wrote docs/hello.txt."},"done":true}`,
+	}, &requests)
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+	var events []clients.Event
+	final, err := client.StreamPromptWithDir(context.Background(), "Add a greeting", workDir, collectEvents(&events))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Wrote docs/hello.txt." {
+		t.Errorf("expected the model's closing text, got %q", final)
+	}
+	if content, err := os.ReadFile(filepath.Join(workDir, "docs", "hello.txt")); err != nil || string(content) != "hello\n" {
+		t.Errorf("expected the file to be written, got %q (%v)", content, err)
+	}
+
+	var calls, results []clients.Event
+	for _, ev := range events {
+		switch ev.Type {
+		case clients.EventToolCall:
+			calls = append(calls, ev)
+		case clients.EventToolResult:
+			results = append(results, ev)
+		}
+	}
+	if len(calls) != 2 || calls[0].ToolName != "write_file" || calls[0].Parameters["path"] != "docs/hello.txt" {
+		t.Fatalf("expected the write_file and read_file calls, got %+v", calls)
+	}
+	if len(results) != 2 || results[0].Status != "success" || results[1].Status != "error" {
+		t.Fatalf("expected a successful write and a refused read, got %+v", results)
+	}
+	if !strings.Contains(results[1].Output, "outside the working directory") {
+		t.Errorf("expected a path outside the working directory to be refused, got %q", results[1].Output)
+	}
+
+	if len(requests) != 3 {
+		t.Fatalf("expected three chat requests, got %d", len(requests))
+	}
+	if len(requests[0].Tools) == 0 {
+		t.Error("expected tools to be offered with a working directory")
+	}
+	last := requests[1].Messages[len(requests[1].Messages)-1]
+	if last.Role != "tool" || last.ToolName != "write_file" || !strings.Contains(last.Content, "Wrote") {
+		t.Errorf("expected the tool result to be sent back to the model, got %+v", last)
+	}
+}
+
+// TestOllamaToolLoopStepLimit tests that a model that keeps calling tools is stopped after MaxSteps requests
+func TestOllamaToolLoopStepLimit(t *testing.T) {
+	var requests []chatRequest
+	server := newToolCallServer(t, []string{
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"list_directory","arguments":{}}}]},"done":true}`,
+	}, &requests)
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+	client.MaxSteps = 3
+	_, err := client.StreamPromptWithDir(context.Background(), "Loop forever", t.TempDir(), nil)
+	if err == nil || !strings.Contains(err.Error(), "3 steps") {
+		t.Errorf("expected the step limit error, got %v", err)
+	}
+	if len(requests) != 3 {
+		t.Errorf("expected three chat requests, got %d", len(requests))
+	}
+}
+
+// TestOllamaToolsRefuseSymlinksOutside tests that paths leading outside the working directory through a symlink are refused
+func TestOllamaToolsRefuseSymlinksOutside(t *testing.T) {
+	workDir, outside := t.TempDir(), t.TempDir()
+	os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret\n"), 0644)
+	os.WriteFile(filepath.Join(workDir, "notes.txt"), []byte("notes\n"), 0644)
+	for link, target := range map[string]string{
+		"escape":   outside,
+		"dangling": filepath.Join(outside, "missing.txt"),
+		"inside":   filepath.Join(workDir, "notes.txt"),
+	} {
+		if err := os.Symlink(target, filepath.Join(workDir, link)); err != nil {
+			t.Skipf("symlinks are not supported: %v", err)
+		}
+	}
+
+	var requests []chatRequest
+	server := newToolCallServer(t, []string{
+		`{"message":{"role":"assistant","content":"","tool_calls":[` +
+			`{"function":{"name":"read_file","arguments":{"path":"escape/secret.txt"}}},` +
+			`{"function":{"name":"write_file","arguments":{"path":"escape/new/file.txt","content":"x"}}},` +
+			`{"function":{"name":"write_file","arguments":{"path":"dangling","content":"x"}}},` +
+			`{"function":{"name":"read_file","arguments":{"path":"inside"}}}]},"done":true}`,
+		`{"message":{"role":"assistant","content":"Done."},"done":true}`,
+	}, &requests)
+
+	var events []clients.Event
+	if _, err := clients.NewOllamaClient(server.URL, "mistral").StreamPromptWithDir(context.Background(), "Read the files", workDir, collectEvents(&events)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	results := eventsOfType(events, clients.EventToolResult)
+	if len(results) != 4 {
+		t.Fatalf("expected four tool results, got %+v", results)
+	}
+	for _, result := range results[:3] {
+		if result.Status != "error" {
+			t.Errorf("expected the path through a symlink to be refused, got %+v", result)
+		}
+	}
+	if results[3].Status != "success" || results[3].Output != "notes\n" {
+		t.Errorf("expected a symlink inside the working directory to be followed, got %+v", results[3])
+	}
+	for _, path := range []string{filepath.Join(outside, "new"), filepath.Join(outside, "missing.txt")} {
+		if _, err := os.Stat(path); err == nil {
+			t.Errorf("expected nothing to be written outside the working directory, found %s", path)
+		}
+	}
+}
+
+// TestOllamaToolCallWithoutWorkDir tests that a tool called without a working directory is answered with an explicit error
+func TestOllamaToolCallWithoutWorkDir(t *testing.T) {
+	var requests []chatRequest
+	server := newToolCallServer(t, []string{
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"list_directory","arguments":{}}}]},"done":true}`,
+		`{"message":{"role":"assistant","content":"Done."},"done":true}`,
+	}, &requests)
+
+	var events []clients.Event
+	if _, err := clients.NewOllamaClient(server.URL, "mistral").StreamPrompt(context.Background(), "List files", collectEvents(&events)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results := eventsOfType(events, clients.EventToolResult); len(results) != 1 || !strings.Contains(results[0].Output, "no working directory") {
+		t.Errorf("expected a no working directory error, got %+v", results)
+	}
+}
+
+// TestOllamaGitCommitUsesIdentity tests that the git_commit tool commits as the client's commit identity
+func TestOllamaGitCommitUsesIdentity(t *testing.T) {
+	repo := setupTempRepo(t)
+	cfg := config.Config{Commit: config.CommitConfig{AuthorName: "Ludwig Bot", AuthorEmail: "ludwig@example.com"}}
+	var requests []chatRequest
+	server := newToolCallServer(t, []string{
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"write_file","arguments":{"path":"a.txt","content":"a\n"}}}]},"done":true}`,
+		`{"message":{"role":"assistant","content":"","tool_calls":[{"function":{"name":"git_commit","arguments":{"message":"Add a.txt"}}}]},"done":true}`,
+		`{"message":{"role":"assistant","content":"Committed."},"done":true}`,
+	}, &requests)
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+	client.CommitEnv = cfg.CommitEnv()
+	if _, err := client.StreamPromptWithDir(context.Background(), "Add a.txt", repo, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identity := strings.TrimSpace(runGit(t, repo, "log", "-1", "--format=%s %an <%ae> %cn <%ce>")); identity != "Add a.txt Ludwig Bot <ludwig@example.com> Ludwig Bot <ludwig@example.com>" {
+		t.Errorf("expected the commit to use the configured identity, got %q", identity)
+	}
+}
+
+// TestOllamaWithoutWorkDirOffersNoTools tests that a prompt without a working directory is a plain chat
+func TestOllamaWithoutWorkDirOffersNoTools(t *testing.T) {
+	var requests []chatRequest
+	server := newToolCallServer(t, []string{`{"message":{"content":"Hi"},"done":true}`}, &requests)
+
+	client := clients.NewOllamaClient(server.URL, "mistral")
+	if _, err := client.StreamPrompt(context.Background(), "Hello", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(requests) != 1 || len(requests[0].Tools) != 0 {
+		t.Errorf("expected a single request without tools, got %+v", requests)
+	}
+}
@@ -0,0 +1,171 @@
+package orchestrator_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ludwig/internal/orchestrator/clients"
+)
+
+// openAIRequest mirrors the parts of a chat completions request the tests inspect
+type openAIRequest struct {
+	Model    string `json:"model"`
+	Stream   bool   `json:"stream"`
+	Messages []struct {
+		Role       string `json:"role"`
+		Content    string `json:"content"`
+		ToolCallID string `json:"tool_call_id"`
+		ToolCalls  []struct {
+			ID string `json:"id"`
+		} `json:"tool_calls"`
+	} `json:"messages"`
+	Tools []json.RawMessage `json:"tools"`
+}
+
+// newOpenAIServer starts a mock OpenAI-compatible server that streams the next list of SSE chunks for each request,
+// recording the requests and their Authorization headers
+func newOpenAIServer(t *testing.T, replies [][]string, requests *[]openAIRequest, auth *[]string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("expected /v1/chat/completions, got %s", r.URL.Path)
+		}
+		var req openAIRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("failed to decode request: %v", err)
+		}
+		*requests = append(*requests, req)
+		*auth = append(*auth, r.Header.Get("Authorization"))
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, chunk := range replies[min(len(*requests), len(replies))-1] {
+			w.Write([]byte("data: " + chunk + "\n\n"))
+		}
+		w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// TestOpenAIClientStreaming tests that SSE content deltas are streamed as text and the usage chunk as a usage event
+func TestOpenAIClientStreaming(t *testing.T) {
+	var requests []openAIRequest
+	var auth []string
+	server := newOpenAIServer(t, [][]string{{
+		`{"choices":[{"delta":{"role":"assistant","content":"Hello"}}]}`,
+		`{"choices":[{"delta":{"content":" world"},"finish_reason":"stop"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":9,"completion_tokens":2,"total_tokens":11}}`,
+	}}, &requests, &auth)
+
+	client := clients.NewOpenAIClient(server.URL+"/v1", "qwen2.5-coder", "secret")
+	var output bytes.Buffer
+	var events []clients.Event
+	final, err := client.StreamPrompt(context.Background(), "Say hello", func(ev clients.Event) {
+		events = append(events, ev)
+		if ev.Type == clients.EventText {
+			output.WriteString(ev.Text)
+		}
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Hello world" || output.String() != "Hello world" {
+		t.Errorf("expected 'Hello world', got %q (streamed %q)", final, output.String())
+	}
+
+	var usage *clients.Usage
+	for _, ev := range events {
+		if ev.Type == clients.EventUsage {
+			usage = ev.Usage
+		}
+	}
+	if usage == nil || usage.InputTokens != 9 || usage.OutputTokens != 2 || usage.TotalTokens != 11 {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if len(requests) != 1 || requests[0].Model != "qwen2.5-coder" || !requests[0].Stream || len(requests[0].Tools) != 0 {
+		t.Errorf("expected one streamed request for the model without tools, got %+v", requests)
+	}
+	if auth[0] != "Bearer secret" {
+		t.Errorf("expected the API key as a bearer token, got %q", auth[0])
+	}
+}
+
+// TestOpenAIClientToolLoop tests that tool calls streamed in fragments are run in the working directory
+// and answered with their call ID
+func TestOpenAIClientToolLoop(t *testing.T) {
+	workDir := t.TempDir()
+	var requests []openAIRequest
+	var auth []string
+	server := newOpenAIServer(t, [][]string{
+		{
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"write_file","arguments":""}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"path\":\"notes.txt\","}}]}}]}`,
+			`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"content\":\"remember\\n\"}"}}]},"finish_reason":"tool_calls"}]}`,
+		},
+		{`{"choices":[{"delta":{"content":"Added notes.txt."},"finish_reason":"stop"}]}`},
+	}, &requests, &auth)
+
+	client := clients.NewOpenAIClient(server.URL+"/v1/", "", "")
+	var events []clients.Event
+	final, err := client.StreamPromptWithDir(context.Background(), "Take a note", workDir, collectEvents(&events))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if final != "Added notes.txt." {
+		t.Errorf("expected the model's closing text, got %q", final)
+	}
+	if content, err := os.ReadFile(filepath.Join(workDir, "notes.txt")); err != nil || string(content) != "remember\n" {
+		t.Errorf("expected the file to be written, got %q (%v)", content, err)
+	}
+
+	var results []clients.Event
+	for _, ev := range events {
+		if ev.Type == clients.EventToolResult {
+			results = append(results, ev)
+		}
+	}
+	if len(results) != 1 || results[0].ToolID != "call_1" || results[0].Status != "success" {
+		t.Fatalf("expected a successful write_file result, got %+v", results)
+	}
+
+	if len(requests) != 2 {
+		t.Fatalf("expected two chat requests, got %d", len(requests))
+	}
+	if len(requests[0].Tools) == 0 || requests[0].Model != "" {
+		t.Error("expected tools to be offered, and no model to be requested when none is configured")
+	}
+	messages := requests[1].Messages
+	assistant, tool := messages[len(messages)-2], messages[len(messages)-1]
+	if assistant.Role != "assistant" || len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].ID != "call_1" {
+		t.Errorf("expected the assistant's tool call to be sent back, got %+v", assistant)
+	}
+	if tool.Role != "tool" || tool.ToolCallID != "call_1" || !strings.Contains(tool.Content, "Wrote") {
+		t.Errorf("expected the tool result to answer call_1, got %+v", tool)
+	}
+	if auth[0] != "" {
+		t.Errorf("expected no Authorization header without an API key, got %q", auth[0])
+	}
+}
+
+// TestOpenAIClientHTTPError tests that an error status is reported with the server's message
+func TestOpenAIClientHTTPError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error":{"message":"invalid api key"}}`))
+	}))
+	defer server.Close()
+
+	client := clients.NewOpenAIClient(server.URL, "gpt", "wrong")
+	_, err := client.SendPrompt(context.Background(), "test prompt", nil)
+	if err == nil || !strings.Contains(err.Error(), "status 401") || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("expected the status and message in the error, got %v", err)
+	}
+}
@@ -1,10 +1,16 @@
 package orchestrator_test
 
 import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
+	"ludwig/internal/config"
 	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
 	"ludwig/internal/types/task"
 )
 
@@ -50,6 +56,32 @@ func TestOrchestratorQuickStartStop(t *testing.T) {
 	}
 }
 
+// TestStopClosesTaskStorage tests that the task database opened by Start is closed again by Stop
+func TestStopClosesTaskStorage(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("open files are counted through /proc")
+	}
+	setupTempRepo(t)
+	writeTestConfig(t, config.Config{AIProvider: "copilot", StorageBackend: storage.BackendSQLite})
+
+	for range 3 {
+		if err := orchestrator.Start(); err != nil {
+			t.Fatalf("failed to start: %v", err)
+		}
+		orchestrator.Stop()
+	}
+
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatalf("failed to list open files: %v", err)
+	}
+	for _, fd := range fds {
+		if target, _ := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); strings.Contains(target, "tasks.db") {
+			t.Errorf("expected the task database to be closed, but %s is still open", target)
+		}
+	}
+}
+
 // Test branch name generation from various task formats
 func TestBranchNameGenerationVariants(t *testing.T) {
 	testCases := []struct {
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"ludwig/internal/orchestrator"
+	"ludwig/internal/types/task"
 )
 
 func TestBuildTaskPrompt(t *testing.T) {
@@ -123,3 +124,94 @@ func validatePrompt(prompt string) error {
 	}
 	return nil
 }
+
+func TestBuildRecoveryPrompt(t *testing.T) {
+	prompt := orchestrator.BuildRecoveryPrompt("Add pagination", "✓ Added page parameter")
+
+	if !strings.Contains(prompt, "Add pagination") {
+		t.Errorf("expected prompt to contain original task")
+	}
+	if !strings.Contains(prompt, "✓ Added page parameter") {
+		t.Errorf("expected prompt to contain previous output")
+	}
+	if !strings.Contains(prompt, "interrupted") {
+		t.Errorf("expected prompt to explain the interruption")
+	}
+
+	empty := orchestrator.BuildRecoveryPrompt("Add pagination", "")
+	if strings.Contains(empty, "output of the previous attempt") {
+		t.Errorf("expected no previous output section when there is none")
+	}
+}
+
+func TestBuildVerifyFixPrompt(t *testing.T) {
+	prompt := orchestrator.BuildVerifyFixPrompt("Add pagination", "test", "go test ./...", "--- FAIL: TestPage")
+
+	if !strings.Contains(prompt, "Add pagination") {
+		t.Errorf("expected prompt to contain original task")
+	}
+	if !strings.Contains(prompt, "go test ./...") || !strings.Contains(prompt, "--- FAIL: TestPage") {
+		t.Errorf("expected prompt to contain the failing command and its output")
+	}
+}
+
+// TestBuildReviewResumePrompt tests that the answers to every question are carried back to the AI
+func TestBuildReviewResumePrompt(t *testing.T) {
+	review := &task.ReviewRequest{
+		Question: "Which database?",
+		Questions: []task.ReviewQuestion{
+			{ID: "q1", Kind: task.SingleChoice, Text: "Which database?", Options: []task.ReviewOption{{ID: "pg", Label: "PostgreSQL"}, {ID: "sqlite", Label: "SQLite"}}},
+			{ID: "q2", Kind: task.FreeText, Text: "Which port?"},
+			{ID: "q3", Kind: task.YesNo, Text: "Add migrations?", Options: task.YesNoOptions()},
+		},
+	}
+	response := &task.ReviewResponse{
+		UserNotes: "Keep it small",
+		Answers: []task.ReviewAnswer{
+			{QuestionID: "q1", OptionIDs: []string{task.OtherOptionID}, Text: "MySQL"},
+			{QuestionID: "q2", Text: "8081"},
+			{QuestionID: "q3", OptionIDs: []string{"yes"}},
+		},
+	}
+
+	prompt := orchestrator.BuildReviewResumePrompt("Add storage", "Created the model", review, response)
+
+	for _, want := range []string{
+		"Q1: Which database?\nOptions were: PostgreSQL; SQLite\nAnswer: MySQL",
+		"Q2: Which port?\nAnswer: 8081",
+		"Q3: Add migrations?\nAnswer: Yes",
+		"User notes: Keep it small",
+		"Created the model",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("expected prompt to contain %q, got: %s", want, prompt)
+		}
+	}
+}
+
+// TestBuildReviewResumePromptSingleAnswer tests that a review answered without per-question answers uses the single choice prompt
+func TestBuildReviewResumePromptSingleAnswer(t *testing.T) {
+	review := &task.ReviewRequest{Question: "Continue?", Options: []task.ReviewOption{{ID: "a", Label: "Proceed"}}}
+	response := &task.ReviewResponse{ChosenOptionID: "a", ChosenLabel: "Proceed"}
+
+	prompt := orchestrator.BuildReviewResumePrompt("Task", "", review, response)
+	if !strings.Contains(prompt, "Q: Continue?") || !strings.Contains(prompt, "User chose: Proceed") {
+		t.Errorf("expected the single choice prompt, got: %s", prompt)
+	}
+}
+
+// TestBuildReviewResumePromptParseError tests that the AI is shown its unreadable review block
+func TestBuildReviewResumePromptParseError(t *testing.T) {
+	review := &task.ReviewRequest{
+		Question:   "The review block could not be read",
+		Options:    []task.ReviewOption{{ID: "ask-again", Label: "Ask again"}},
+		Raw:        `{"questions": []}`,
+		ParseError: "invalid review JSON: no questions",
+	}
+	response := &task.ReviewResponse{ChosenOptionID: "ask-again", ChosenLabel: "Ask again"}
+
+	prompt := orchestrator.BuildReviewResumePrompt("Task", "", review, response)
+	if !strings.Contains(prompt, "invalid review JSON: no questions") || !strings.Contains(prompt, `{"questions": []}`) {
+		t.Errorf("expected the parse error and raw block in the prompt, got: %s", prompt)
+	}
+}
@@ -0,0 +1,82 @@
+package orchestrator_test
+
+import (
+	"testing"
+	"time"
+
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+func queueIDs(t *testing.T, taskStore storage.TaskStorage) []string {
+	t.Helper()
+	tasks, err := taskStore.ListTasks()
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	ids := make([]string, len(tasks))
+	for i, tk := range tasks {
+		ids[i] = tk.ID
+	}
+	return ids
+}
+
+// TestChangePriority tests that bumping a task moves it to the front of the queue
+func TestChangePriority(t *testing.T) {
+	setupTempRepo(t)
+	taskStore, _ := storage.NewFileTaskStorage()
+	now := time.Now()
+	taskStore.AddTask(&task.Task{ID: "a", Name: "A", Status: task.Pending, CreatedAt: now})
+	taskStore.AddTask(&task.Task{ID: "b", Name: "B", Status: task.Pending, CreatedAt: now})
+
+	priority, err := orchestrator.ChangePriority(taskStore, "b", 1)
+	if err != nil {
+		t.Fatalf("failed to bump task: %v", err)
+	}
+	if priority != 1 {
+		t.Errorf("expected priority 1, got %d", priority)
+	}
+	if ids := queueIDs(t, taskStore); ids[0] != "b" {
+		t.Errorf("expected bumped task first, got %v", ids)
+	}
+
+	orchestrator.ChangePriority(taskStore, "b", -2)
+	if ids := queueIDs(t, taskStore); ids[1] != "b" {
+		t.Errorf("expected lowered task last, got %v", ids)
+	}
+}
+
+// TestMoveTask tests reordering a task before another, including across priorities
+func TestMoveTask(t *testing.T) {
+	setupTempRepo(t)
+	taskStore, _ := storage.NewFileTaskStorage()
+	now := time.Now()
+	for _, id := range []string{"a", "b", "c"} {
+		taskStore.AddTask(&task.Task{ID: id, Name: id, Status: task.Pending, CreatedAt: now})
+	}
+
+	if err := orchestrator.MoveTask(taskStore, "c", "a"); err != nil {
+		t.Fatalf("failed to move task: %v", err)
+	}
+	if ids := queueIDs(t, taskStore); ids[0] != "c" || ids[1] != "a" || ids[2] != "b" {
+		t.Errorf("expected order [c a b], got %v", ids)
+	}
+
+	// Moving before a higher priority task takes on its priority
+	orchestrator.ChangePriority(taskStore, "b", 1)
+	if err := orchestrator.MoveTask(taskStore, "a", "b"); err != nil {
+		t.Fatalf("failed to move task: %v", err)
+	}
+	moved, _ := taskStore.GetTask("a")
+	if moved.Priority != 1 {
+		t.Errorf("expected moved task to take priority 1, got %d", moved.Priority)
+	}
+	if ids := queueIDs(t, taskStore); ids[0] != "a" || ids[1] != "b" || ids[2] != "c" {
+		t.Errorf("expected order [a b c], got %v", ids)
+	}
+
+	if err := orchestrator.MoveTask(taskStore, "a", "missing"); err == nil {
+		t.Errorf("expected error moving before a missing task")
+	}
+}
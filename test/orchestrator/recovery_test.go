@@ -0,0 +1,214 @@
+package orchestrator_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// newRecoveryStore creates a temp repo and a task storage inside it
+func newRecoveryStore(t *testing.T) (string, *storage.FileTaskStorage) {
+	t.Helper()
+	repo := setupTempRepo(t)
+	taskStore, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	return repo, taskStore
+}
+
+// writeResponseLog writes a response file containing the given event lines and returns its relative path
+func writeResponseLog(t *testing.T, taskID string, events ...string) string {
+	t.Helper()
+	rw, path, err := storage.NewResponseWriter(taskID)
+	if err != nil {
+		t.Fatalf("failed to create response writer: %v", err)
+	}
+	for _, ev := range events {
+		rw.WriteChunk(ev + "\n")
+	}
+	// Leave the file without a footer, as if the process had crashed mid-stream
+	return path
+}
+
+// TestReconcileResumesTaskWithWorktree tests that a crashed task keeps its worktree and previous output
+func TestReconcileResumesTaskWithWorktree(t *testing.T) {
+	repo, taskStore := newRecoveryStore(t)
+
+	worktreePath, err := orchestrator.CreateWorktree("ludwig/crashed-task", "crashed")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(worktreePath, "half.txt"), []byte("half done\n"), 0644); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	responseFile := writeResponseLog(t, "crashed",
+		`{"type":"text","timestamp":"2025-01-01T00:00:00Z","text":"Created half.txt, "}`,
+		`{"type":"text","timestamp":"2025-01-01T00:00:01Z","text":"now writing tests"}`,
+	)
+
+	taskStore.AddTask(&task.Task{
+		ID:           "crashed",
+		Name:         "Crashed task",
+		Status:       task.InProgress,
+		BranchName:   "ludwig/crashed-task",
+		WorktreePath: worktreePath,
+		ResponseFile: responseFile,
+	})
+
+	report, err := orchestrator.Reconcile(taskStore, nil, false)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if len(report.Resumed) != 1 || report.Resumed[0] != "crashed" {
+		t.Errorf("expected task to be resumed, got report %+v", report)
+	}
+
+	recovered, _ := taskStore.GetTask("crashed")
+	if recovered.Status != task.Pending {
+		t.Errorf("expected Pending, got %s", task.StatusString(*recovered))
+	}
+	if recovered.WorktreePath != worktreePath {
+		t.Errorf("expected worktree to be kept, got %q", recovered.WorktreePath)
+	}
+	if recovered.WorkInProgress != "Created half.txt, now writing tests" {
+		t.Errorf("expected previous output as work in progress, got %q", recovered.WorkInProgress)
+	}
+	if status := runGit(t, worktreePath, "status", "--porcelain"); status != "" {
+		t.Errorf("expected partial changes to be committed, got %q", status)
+	}
+	if log := runGit(t, repo, "log", "--oneline", "ludwig/crashed-task"); !strings.Contains(log, "Task interrupted") {
+		t.Errorf("expected partial commit on task branch, got %q", log)
+	}
+}
+
+// TestReconcileRestoresDeletedWorktree tests that a task whose worktree directory vanished is resumed from its branch
+func TestReconcileRestoresDeletedWorktree(t *testing.T) {
+	_, taskStore := newRecoveryStore(t)
+
+	worktreePath, err := orchestrator.CreateWorktree("ludwig/lost-worktree", "lost")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	os.WriteFile(filepath.Join(worktreePath, "work.txt"), []byte("work\n"), 0644)
+	runGit(t, worktreePath, "add", "-A")
+	runGit(t, worktreePath, "commit", "-q", "-m", "Work")
+	os.RemoveAll(worktreePath)
+
+	taskStore.AddTask(&task.Task{
+		ID:           "lost",
+		Name:         "Lost worktree",
+		Status:       task.InProgress,
+		BranchName:   "ludwig/lost-worktree",
+		WorktreePath: worktreePath,
+	})
+
+	report, err := orchestrator.Reconcile(taskStore, nil, false)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if len(report.Resumed) != 1 {
+		t.Errorf("expected branch with commits to be resumed, got report %+v", report)
+	}
+
+	recovered, _ := taskStore.GetTask("lost")
+	if _, err := os.Stat(filepath.Join(recovered.WorktreePath, "work.txt")); err != nil {
+		t.Errorf("expected worktree to be restored from branch: %v", err)
+	}
+}
+
+// TestReconcileRequeuesTaskWithoutWorktree tests that a task with nothing to resume starts over
+func TestReconcileRequeuesTaskWithoutWorktree(t *testing.T) {
+	repo, taskStore := newRecoveryStore(t)
+
+	taskStore.AddTask(&task.Task{
+		ID:           "gone",
+		Name:         "Gone task",
+		Status:       task.InProgress,
+		BranchName:   "ludwig/never-created",
+		WorktreePath: filepath.Join(repo, ".worktrees", "gone"),
+	})
+
+	report, err := orchestrator.Reconcile(taskStore, nil, false)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if len(report.Requeued) != 1 || report.Requeued[0] != "gone" {
+		t.Errorf("expected task to be re-queued, got report %+v", report)
+	}
+
+	recovered, _ := taskStore.GetTask("gone")
+	if recovered.Status != task.Pending || recovered.WorktreePath != "" || recovered.BranchName != "" {
+		t.Errorf("expected a fresh Pending task, got %+v", recovered)
+	}
+}
+
+// TestReconcileReplaysReviewAnswer tests that a task that crashed while acting on a review answer returns to In Review
+func TestReconcileReplaysReviewAnswer(t *testing.T) {
+	_, taskStore := newRecoveryStore(t)
+
+	worktreePath, err := orchestrator.CreateWorktree("ludwig/reviewed-task", "reviewed")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	taskStore.AddTask(&task.Task{
+		ID:             "reviewed",
+		Name:           "Reviewed task",
+		Status:         task.InProgress,
+		BranchName:     "ludwig/reviewed-task",
+		WorktreePath:   worktreePath,
+		Review:         &task.ReviewRequest{Question: "A or B?"},
+		ReviewResponse: &task.ReviewResponse{ChosenOptionID: "a", ChosenLabel: "A"},
+	})
+
+	if _, err := orchestrator.Reconcile(taskStore, nil, false); err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+
+	recovered, _ := taskStore.GetTask("reviewed")
+	if recovered.Status != task.NeedsReview || recovered.ReviewResponse == nil {
+		t.Errorf("expected In Review with the answer kept, got %+v", recovered)
+	}
+}
+
+// TestReconcileReportsAndPrunesOrphanedWorktrees tests orphaned worktree detection with and without pruning
+func TestReconcileReportsAndPrunesOrphanedWorktrees(t *testing.T) {
+	_, taskStore := newRecoveryStore(t)
+
+	orphan, err := orchestrator.CreateWorktree("ludwig/orphan", "orphan")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	owned, err := orchestrator.CreateWorktree("ludwig/owned", "owned")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	taskStore.AddTask(&task.Task{ID: "owned", Name: "Owned", Status: task.NeedsReview, WorktreePath: owned})
+
+	report, err := orchestrator.Reconcile(taskStore, nil, false)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if len(report.OrphanedWorktrees) != 1 || report.OrphanedWorktrees[0] != orphan {
+		t.Errorf("expected only %s to be orphaned, got %v", orphan, report.OrphanedWorktrees)
+	}
+	if !orchestrator.WorktreeExists(orphan) {
+		t.Errorf("orphaned worktree should not be removed without prune")
+	}
+
+	report, err = orchestrator.Reconcile(taskStore, nil, true)
+	if err != nil {
+		t.Fatalf("reconcile failed: %v", err)
+	}
+	if len(report.PrunedWorktrees) != 1 || orchestrator.WorktreeExists(orphan) {
+		t.Errorf("expected orphaned worktree to be pruned, got report %+v", report)
+	}
+	if !orchestrator.WorktreeExists(owned) {
+		t.Errorf("owned worktree must not be pruned")
+	}
+}
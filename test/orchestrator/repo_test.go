@@ -0,0 +1,147 @@
+package orchestrator_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestFindRepoRootFromSubdirectory tests that the repository root is found from a nested directory
+func TestFindRepoRootFromSubdirectory(t *testing.T) {
+	repo := setupTempRepo(t)
+	sub := filepath.Join(repo, "internal", "pkg")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("failed to create subdirectory: %v", err)
+	}
+
+	root, err := orchestrator.FindRepoRoot(sub)
+	if err != nil {
+		t.Fatalf("failed to find repository root: %v", err)
+	}
+	want, _ := filepath.EvalSymlinks(repo)
+	if got, _ := filepath.EvalSymlinks(root); got != want {
+		t.Errorf("expected root %q, got %q", want, got)
+	}
+}
+
+// TestLudwigDirFromSubdirectory tests that config and tasks are read from the repository root's .ludwig from a nested directory
+func TestLudwigDirFromSubdirectory(t *testing.T) {
+	repo := setupTempRepo(t)
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+	rootStore, _ := storage.NewFileTaskStorage()
+	rootStore.AddTask(&task.Task{ID: "root", Name: "Stored at the root", Status: task.Pending})
+
+	sub := filepath.Join(repo, "internal", "pkg")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatalf("failed to create subdirectory: %v", err)
+	}
+	t.Chdir(sub)
+
+	cfg, err := config.LoadConfig()
+	if err != nil || cfg == nil || cfg.AIProvider != "copilot" {
+		t.Fatalf("expected the root config, got %+v (%v)", cfg, err)
+	}
+	cfg.DelayMs = 50
+	if err := config.SaveConfig(cfg); err != nil {
+		t.Fatalf("failed to save config: %v", err)
+	}
+	taskStore, _ := storage.NewFileTaskStorage()
+	if _, err := taskStore.GetTask("root"); err != nil {
+		t.Errorf("expected the root's tasks, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(sub, ".ludwig")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no .ludwig directory in the subdirectory, got %v", err)
+	}
+	if saved, _ := os.ReadFile(filepath.Join(repo, ".ludwig", "config.json")); !strings.Contains(string(saved), `"delayMs":50`) {
+		t.Errorf("expected the config to be saved at the root, got %s", saved)
+	}
+}
+
+// TestFindRepoRootErrors tests that a plain directory and a bare repository are rejected with clear errors
+func TestFindRepoRootErrors(t *testing.T) {
+	plain := t.TempDir()
+	if _, err := orchestrator.FindRepoRoot(plain); !errors.Is(err, orchestrator.ErrNotRepository) || !strings.Contains(err.Error(), "git init") {
+		t.Errorf("expected a not-a-repository error, got %v", err)
+	}
+
+	bare := t.TempDir()
+	runGit(t, bare, "init", "-q", "--bare")
+	if _, err := orchestrator.FindRepoRoot(bare); !errors.Is(err, orchestrator.ErrBareRepository) {
+		t.Errorf("expected a bare repository error, got %v", err)
+	}
+
+	t.Chdir(plain)
+	if err := orchestrator.Start(); !errors.Is(err, orchestrator.ErrNotRepository) {
+		orchestrator.Stop()
+		t.Errorf("expected the orchestrator to refuse to start outside a repository, got %v", err)
+	}
+}
+
+// TestConfiguredBaseBranch tests that tasks start from baseBranch in the config, and that a missing one stops Start
+func TestConfiguredBaseBranch(t *testing.T) {
+	repo := setupTempRepo(t)
+	runGit(t, repo, "checkout", "-q", "-b", "develop")
+	commitFile(t, repo, "develop.txt", "develop\n", "Develop only")
+	runGit(t, repo, "checkout", "-q", "main")
+
+	writeTestConfig(t, config.Config{BaseBranch: "develop"})
+	if base, err := orchestrator.BaseBranch(); err != nil || base != "develop" {
+		t.Fatalf("expected develop as the base branch, got %q, %v", base, err)
+	}
+	worktreePath, err := orchestrator.CreateWorktree("ludwig/from-develop", "d")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(worktreePath, "develop.txt")); err != nil {
+		t.Errorf("expected the worktree to start from develop: %v", err)
+	}
+
+	writeTestConfig(t, config.Config{BaseBranch: "missing"})
+	if err := orchestrator.Start(); err == nil || !strings.Contains(err.Error(), "missing") {
+		orchestrator.Stop()
+		t.Errorf("expected Start to report the missing base branch, got %v", err)
+	}
+}
+
+// TestTaskBaseBranchOverride tests that a task with its own base branch starts from it and merges back into it
+func TestTaskBaseBranchOverride(t *testing.T) {
+	repo := setupTempRepo(t)
+	runGit(t, repo, "checkout", "-q", "-b", "release")
+	commitFile(t, repo, "release.txt", "release\n", "Release only")
+	runGit(t, repo, "checkout", "-q", "main")
+	installFakeCLI(t, "copilot", "echo fix > fix.txt\necho done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "hotfix", Name: "Fix the release", Status: task.Pending, BaseBranch: "release"})
+
+	if err := orchestrator.Start(); err != nil {
+		t.Fatalf("failed to start: %v", err)
+	}
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "hotfix", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed || done.BaseBranch != "release" {
+		t.Fatalf("expected the task to complete from release, got %s from %q", task.StatusString(*done), done.BaseBranch)
+	}
+	if files := runGit(t, repo, "ls-tree", "-r", "--name-only", done.BranchName); !strings.Contains(files, "release.txt") {
+		t.Errorf("expected the task branch to start from release, got files %q", files)
+	}
+
+	target, err := orchestrator.MergeTask(taskStore, "hotfix", config.MergeFastForward)
+	if err != nil || target != "release" {
+		t.Fatalf("expected to merge into release, got %q, %v", target, err)
+	}
+	if files := runGit(t, repo, "ls-tree", "-r", "--name-only", "main"); strings.Contains(files, "fix.txt") {
+		t.Errorf("expected main to be untouched, got files %q", files)
+	}
+}
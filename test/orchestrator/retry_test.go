@@ -0,0 +1,102 @@
+package orchestrator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// startFailingTask runs the orchestrator against a copilot CLI that always fails and waits for the task to be marked Failed
+func startFailingTask(t *testing.T, policy config.RetryPolicy) (*storage.FileTaskStorage, *task.Task) {
+	t.Helper()
+	setupTempRepo(t)
+	installFakeCLI(t, "copilot", "echo 'partial output'\necho 'model overloaded' >&2\nexit 1\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot", CopilotModel: "gpt-5-mini", Retry: policy})
+
+	taskStore, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	if err := taskStore.AddTask(&task.Task{ID: "failing", Name: "Always failing task", Status: task.Pending}); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	failed := waitForTask(t, taskStore, "failing", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Failed
+	})
+	return taskStore, failed
+}
+
+// TestFailedTaskRetriesThenFails tests that a failing task is retried with backoff and then marked Failed
+func TestFailedTaskRetriesThenFails(t *testing.T) {
+	taskStore, failed := startFailingTask(t, config.RetryPolicy{MaxAttempts: 2, BackoffSeconds: 1})
+
+	if len(failed.Attempts) != 2 {
+		t.Fatalf("expected 2 attempts, got %d: %+v", len(failed.Attempts), failed.Attempts)
+	}
+	for i, attempt := range failed.Attempts {
+		if attempt.Provider != "copilot" || attempt.Model != "gpt-5-mini" {
+			t.Errorf("attempt %d: unexpected provider/model %q/%q", i, attempt.Provider, attempt.Model)
+		}
+		if !strings.Contains(attempt.Error, "model overloaded") {
+			t.Errorf("attempt %d: expected error text to be recorded, got %q", i, attempt.Error)
+		}
+		if attempt.ResponseFile == "" || attempt.EndedAt.Before(attempt.StartedAt) {
+			t.Errorf("attempt %d: expected response file and end time, got %+v", i, attempt)
+		}
+	}
+	if gap := failed.Attempts[1].StartedAt.Sub(failed.Attempts[0].EndedAt); gap < time.Second {
+		t.Errorf("expected retry to wait for the backoff, waited %v", gap)
+	}
+	if failed.FailureCount != 2 {
+		t.Errorf("expected failure count 2, got %d", failed.FailureCount)
+	}
+	if !strings.Contains(failed.WorkInProgress, "partial output") {
+		t.Errorf("expected partial output to be kept, got %q", failed.WorkInProgress)
+	}
+	if failed.WorktreePath == "" || !orchestrator.WorktreeExists(failed.WorktreePath) {
+		t.Errorf("expected failed task to keep its worktree, got %q", failed.WorktreePath)
+	}
+
+	orchestrator.Stop()
+	if err := orchestrator.RetryTask(taskStore, "failing"); err != nil {
+		t.Fatalf("retry failed: %v", err)
+	}
+	retried, _ := taskStore.GetTask("failing")
+	if retried.Status != task.Pending || retried.FailureCount != 0 || !retried.NextAttemptAt.IsZero() {
+		t.Errorf("expected a fresh Pending task, got status %s, failures %d", task.StatusString(*retried), retried.FailureCount)
+	}
+	if len(retried.Attempts) != 2 {
+		t.Errorf("expected attempt history to be kept, got %d attempts", len(retried.Attempts))
+	}
+}
+
+// TestNonRetryableErrorFailsImmediately tests that errors not matching the retryable list are not retried
+func TestNonRetryableErrorFailsImmediately(t *testing.T) {
+	_, failed := startFailingTask(t, config.RetryPolicy{MaxAttempts: 5, BackoffSeconds: 1, RetryableErrors: []string{"rate limit"}})
+
+	if len(failed.Attempts) != 1 {
+		t.Errorf("expected a single attempt, got %d", len(failed.Attempts))
+	}
+}
+
+// TestRetryTaskRejectsNonFailedTask tests that only failed tasks can be retried
+func TestRetryTaskRejectsNonFailedTask(t *testing.T) {
+	setupTempRepo(t)
+	taskStore, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	taskStore.AddTask(&task.Task{ID: "done", Name: "Done task", Status: task.Completed})
+
+	if err := orchestrator.RetryTask(taskStore, "done"); err == nil {
+		t.Errorf("expected an error when retrying a completed task")
+	}
+}
@@ -0,0 +1,416 @@
+package orchestrator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestAnswerReview tests that an answer is saved on a task waiting for review
+func TestAnswerReview(t *testing.T) {
+	setupTempRepo(t)
+	taskStore, _ := storage.NewFileTaskStorage()
+	review := &task.ReviewRequest{Question: "Which database?", CreatedAt: time.Now()}
+	taskStore.AddTask(&task.Task{ID: "r", Name: "Review me", Status: task.NeedsReview, Review: review})
+
+	err := orchestrator.AnswerReview(taskStore, "r", review, task.ReviewResponse{
+		ChosenOptionID: task.OtherOptionID,
+		ChosenLabel:    "Other",
+		UserNotes:      "Use SQLite",
+	})
+	if err != nil {
+		t.Fatalf("failed to answer review: %v", err)
+	}
+
+	stored, _ := taskStore.GetTask("r")
+	if stored.ReviewResponse == nil {
+		t.Fatal("expected the answer to be saved")
+	}
+	if stored.ReviewResponse.UserNotes != "Use SQLite" || stored.ReviewResponse.ChosenOptionID != task.OtherOptionID {
+		t.Errorf("unexpected answer saved: %+v", stored.ReviewResponse)
+	}
+	if stored.ReviewResponse.RespondedAt.IsZero() {
+		t.Error("expected RespondedAt to be set")
+	}
+}
+
+// TestAnswerReviewRejectsOutdatedReview tests that an answer is not saved for a task that moved on
+func TestAnswerReviewRejectsOutdatedReview(t *testing.T) {
+	setupTempRepo(t)
+	taskStore, _ := storage.NewFileTaskStorage()
+	opened := &task.ReviewRequest{Question: "Old question", CreatedAt: time.Now().Add(-time.Minute)}
+	taskStore.AddTask(&task.Task{ID: "r", Name: "Review me", Status: task.NeedsReview,
+		Review: &task.ReviewRequest{Question: "New question", CreatedAt: time.Now()}})
+	taskStore.AddTask(&task.Task{ID: "p", Name: "Pending", Status: task.Pending})
+
+	if err := orchestrator.AnswerReview(taskStore, "r", opened, task.ReviewResponse{ChosenOptionID: "a"}); err == nil {
+		t.Error("expected an answer to an old question to be rejected")
+	}
+	if err := orchestrator.AnswerReview(taskStore, "p", nil, task.ReviewResponse{ChosenOptionID: "a"}); err == nil {
+		t.Error("expected an answer for a task not in review to be rejected")
+	}
+	if stored, _ := taskStore.GetTask("r"); stored.ReviewResponse != nil {
+		t.Errorf("expected no answer to be saved, got %+v", stored.ReviewResponse)
+	}
+}
+
+// resumingStore resumes a task after it is first read, as the orchestrator can while the user answers
+type resumingStore struct {
+	storage.TaskStorage
+	resumed bool
+}
+
+func (s *resumingStore) GetTask(id string) (*task.Task, error) {
+	t, err := s.TaskStorage.GetTask(id)
+	if err == nil && !s.resumed {
+		s.resumed = true
+		stored := *t
+		stored.Status = task.InProgress
+		stored.Review = nil
+		if err := s.TaskStorage.UpdateTask(&stored); err != nil {
+			return nil, err
+		}
+	}
+	return t, err
+}
+
+// TestAnswerReviewRejectsTaskResumedMeanwhile tests that an answer is not saved if the task leaves review while it is being saved
+func TestAnswerReviewRejectsTaskResumedMeanwhile(t *testing.T) {
+	setupTempRepo(t)
+	fileStore, _ := storage.NewFileTaskStorage()
+	review := &task.ReviewRequest{Question: "Which database?", CreatedAt: time.Now()}
+	fileStore.AddTask(&task.Task{ID: "r", Name: "Review me", Status: task.NeedsReview, Review: review})
+	taskStore := &resumingStore{TaskStorage: fileStore}
+
+	if err := orchestrator.AnswerReview(taskStore, "r", review, task.ReviewResponse{ChosenOptionID: "a"}); err == nil {
+		t.Error("expected the answer to be rejected once the task was resumed")
+	}
+	stored, _ := fileStore.GetTask("r")
+	if stored.Status != task.InProgress || stored.ReviewResponse != nil {
+		t.Errorf("expected the resumed task to be left alone, got status %s and answer %+v", task.StatusString(*stored), stored.ReviewResponse)
+	}
+}
+
+// TestMultiQuestionReviewRoundTrip tests that a review with several questions is parsed and every answer reaches the AI
+func TestMultiQuestionReviewRoundTrip(t *testing.T) {
+	repo := setupTempRepo(t)
+	installFakeCLI(t, "copilot", `case "$*" in
+*"Answer: 8081"*) echo "$*" > answers.txt; echo done;;
+*) printf '%s\n' 'Started.' '---NEEDS_REVIEW---' 'Context: Setting up the server' \
+	'Question: Which database?' '- id: pg | label: PostgreSQL' '- id: sqlite | label: SQLite' 'Default: sqlite' \
+	'Question: Which platforms?' 'Kind: multi' '- id: linux | label: Linux' '- id: mac | label: macOS' 'Default: linux, mac' \
+	'Question: Which port?' 'Kind: text' 'Default: 8080' \
+	'Question: Add migrations?' 'Kind: yesno' 'Default: maybe' \
+	'---END_REVIEW---';;
+esac
+`)
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "multi", Name: "Set up the server", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	reviewed := waitForTask(t, taskStore, "multi", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.NeedsReview
+	})
+
+	questions := reviewed.Review.AllQuestions()
+	if len(questions) != 4 {
+		t.Fatalf("expected 4 questions, got %+v", questions)
+	}
+	wantKinds := []task.QuestionKind{task.SingleChoice, task.MultiChoice, task.FreeText, task.YesNo}
+	wantDefaults := []string{"sqlite", "linux,mac", "8080", ""}
+	for i, question := range questions {
+		if question.Kind != wantKinds[i] || question.Default != wantDefaults[i] {
+			t.Errorf("question %d: expected kind %q and default %q, got %+v", i+1, wantKinds[i], wantDefaults[i], question)
+		}
+	}
+	if reviewed.Review.Question != "Which database?" || len(reviewed.Review.Options) != 2 {
+		t.Errorf("expected the first question in Question and Options, got %+v", reviewed.Review)
+	}
+
+	err := orchestrator.AnswerReview(taskStore, "multi", reviewed.Review, task.ReviewResponse{Answers: []task.ReviewAnswer{
+		{QuestionID: "q1", OptionIDs: []string{"pg"}},
+		{QuestionID: "q2", OptionIDs: []string{"linux"}},
+		{QuestionID: "q3", Text: "8081"},
+		{QuestionID: "q4", OptionIDs: []string{"no"}},
+	}})
+	if err != nil {
+		t.Fatalf("failed to answer review: %v", err)
+	}
+
+	done := waitForTask(t, taskStore, "multi", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed {
+		t.Fatalf("expected task to complete after the answers, got %s", task.StatusString(*done))
+	}
+	answers := runGit(t, repo, "show", done.BranchName+":answers.txt")
+	for _, want := range []string{"Answer: PostgreSQL", "Answer: Linux", "Answer: 8081", "Answer: No"} {
+		if !strings.Contains(answers, want) {
+			t.Errorf("expected the resume prompt to contain %q, got %q", want, answers)
+		}
+	}
+}
+
+// TestFollowUpReviewAfterAnswers tests that the AI can ask a second round of questions after a multi-question answer
+func TestFollowUpReviewAfterAnswers(t *testing.T) {
+	repo := setupTempRepo(t)
+	installFakeCLI(t, "copilot", `case "$*" in
+*"Answer: public"*) echo "$*" > answers.txt; echo done;;
+*"Answer: 8081"*) printf '%s\n' 'Database created.' '---NEEDS_REVIEW---' 'Question: Which schema?' 'Kind: text' '---END_REVIEW---';;
+*) printf '%s\n' '---NEEDS_REVIEW---' \
+	'Question: Which database?' '- id: pg | label: PostgreSQL' '- id: sqlite | label: SQLite' \
+	'Question: Which port?' 'Kind: text' '---END_REVIEW---';;
+esac
+`)
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "follow", Name: "Set up the database", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	first := waitForTask(t, taskStore, "follow", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.NeedsReview
+	})
+	err := orchestrator.AnswerReview(taskStore, "follow", first.Review, task.ReviewResponse{Answers: []task.ReviewAnswer{
+		{QuestionID: "q1", OptionIDs: []string{"pg"}},
+		{QuestionID: "q2", Text: "8081"},
+	}})
+	if err != nil {
+		t.Fatalf("failed to answer the first review: %v", err)
+	}
+
+	second := waitForTask(t, taskStore, "follow", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status != task.InProgress && tk.Review != nil && !tk.Review.CreatedAt.Equal(first.Review.CreatedAt)
+	})
+	if second.Status != task.NeedsReview || second.Review.Question != "Which schema?" || second.ReviewResponse != nil {
+		t.Fatalf("expected the follow-up question to wait for an answer, got %s %+v", task.StatusString(*second), second.Review)
+	}
+	if err := orchestrator.AnswerReview(taskStore, "follow", second.Review, task.ReviewResponse{Answers: []task.ReviewAnswer{
+		{QuestionID: "q1", Text: "public"},
+	}}); err != nil {
+		t.Fatalf("failed to answer the follow-up review: %v", err)
+	}
+
+	done := waitForTask(t, taskStore, "follow", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+	if done.Status != task.Completed {
+		t.Fatalf("expected task to complete after the follow-up answer, got %s", task.StatusString(*done))
+	}
+	answers := runGit(t, repo, "show", done.BranchName+":answers.txt")
+	for _, want := range []string{"Answer: PostgreSQL", "Answer: 8081", "Database created.", "Answer: public"} {
+		if !strings.Contains(answers, want) {
+			t.Errorf("expected the final resume prompt to contain %q, got %q", want, answers)
+		}
+	}
+}
+
+// TestReviewBlockFormats tests the JSON and line review formats, and that a malformed block waits for the user
+func TestReviewBlockFormats(t *testing.T) {
+	setupTempRepo(t)
+	installFakeCLI(t, "copilot", `case "$*" in
+*"Fenced JSON"*) printf '%s\n' 'Done so far.' '---NEEDS_REVIEW---' '`+"```json"+`' \
+	'{"context": "Two stores exist.\nBoth work.", "questions": [' \
+	'  {"id": "store", "question": "Which store?", "options": [{"id": "a", "label": "Files | JSON"}, {"id": "b", "label": "SQLite"}], "default": "b"},' \
+	'  {"id": "tests", "kind": "yesno", "question": "Add tests?", "default": true}' \
+	']}' '`+"```"+`' '---END_REVIEW---';;
+*"Pipe lines"*) printf '%s\n' '`+"```"+`' '---NEEDS_REVIEW---' 'Context: First line' 'still context' \
+	'Question: Which format?' '- id: a | label: CSV | TSV' '- id: b | label: JSON' '---END_REVIEW---' '`+"```"+`';;
+*"Bad JSON"*) printf '%s\n' '---NEEDS_REVIEW---' '{"questions": [{"question": "Which?", "kind": "dropdown"}]}' '---END_REVIEW---';;
+*"No end"*) printf '%s\n' 'Partial.' '---NEEDS_REVIEW---' 'Question: Which?';;
+esac
+`)
+	writeTestConfig(t, config.Config{AIProvider: "copilot", MaxParallelTasks: 4})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	for _, name := range []string{"Fenced JSON", "Pipe lines", "Bad JSON", "No end"} {
+		taskStore.AddTask(&task.Task{ID: name, Name: name, Status: task.Pending})
+	}
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	inReview := func(tk *task.Task) bool { return tk.Status != task.Pending && tk.Status != task.InProgress }
+
+	fenced := waitForTask(t, taskStore, "Fenced JSON", 15*time.Second, inReview)
+	if fenced.Status != task.NeedsReview || fenced.Review.ParseError != "" {
+		t.Fatalf("expected the JSON review to be read, got %s %+v", task.StatusString(*fenced), fenced.Review)
+	}
+	questions := fenced.Review.AllQuestions()
+	if len(questions) != 2 || questions[0].ID != "store" || questions[0].Options[0].Label != "Files | JSON" || questions[0].Default != "b" {
+		t.Errorf("unexpected first question: %+v", questions)
+	}
+	if questions[1].Kind != task.YesNo || questions[1].Default != "yes" {
+		t.Errorf("expected a yes/no question suggesting yes, got %+v", questions[1])
+	}
+	if fenced.Review.Context != "Two stores exist.\nBoth work." || !strings.Contains(fenced.Review.Raw, `"questions"`) {
+		t.Errorf("expected multi-line context and the raw block, got %+v", fenced.Review)
+	}
+	if fenced.WorkInProgress != "Done so far." {
+		t.Errorf("expected work in progress before the block, got %q", fenced.WorkInProgress)
+	}
+
+	lines := waitForTask(t, taskStore, "Pipe lines", 15*time.Second, inReview)
+	if lines.Status != task.NeedsReview || len(lines.Review.Options) != 2 || lines.Review.Options[0].Label != "CSV | TSV" {
+		t.Errorf("expected option labels to keep their |, got %+v", lines.Review)
+	}
+	if lines.Review.Context != "First line\nstill context" || lines.WorkInProgress != "" {
+		t.Errorf("expected multi-line context and no fence in work in progress, got %q / %q", lines.Review.Context, lines.WorkInProgress)
+	}
+
+	for _, id := range []string{"Bad JSON", "No end"} {
+		broken := waitForTask(t, taskStore, id, 15*time.Second, inReview)
+		if broken.Status != task.NeedsReview || broken.Review == nil || broken.Review.ParseError == "" {
+			t.Errorf("%s: expected a parse error review instead of %s, got %+v", id, task.StatusString(*broken), broken.Review)
+			continue
+		}
+		if broken.Review.Raw == "" || !strings.Contains(broken.Review.Context, broken.Review.ParseError) {
+			t.Errorf("%s: expected the raw block and the error to be kept, got %+v", id, broken.Review)
+		}
+	}
+}
+
+// TestParseErrorReviewAskAgain tests that a review block repeated after "ask-again" pauses the resumed task again
+func TestParseErrorReviewAskAgain(t *testing.T) {
+	setupTempRepo(t)
+	installFakeCLI(t, "copilot", `case "$*" in
+*"could not be read"*) printf '%s\n' 'Retrying.' '---NEEDS_REVIEW---' 'Question: Which format?' '- id: a | label: CSV' '- id: b | label: JSON' '---END_REVIEW---';;
+*) printf '%s\n' 'Partial.' '---NEEDS_REVIEW---' 'Question: Which?';;
+esac
+`)
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "again", Name: "Export the data", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	broken := waitForTask(t, taskStore, "again", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.NeedsReview
+	})
+	if broken.Review.ParseError == "" {
+		t.Fatalf("expected a parse error review, got %+v", broken.Review)
+	}
+
+	err := orchestrator.AnswerReview(taskStore, "again", broken.Review, task.ReviewResponse{
+		ChosenOptionID: "ask-again",
+		ChosenLabel:    "Ask the AI to repeat its question in the correct format",
+	})
+	if err != nil {
+		t.Fatalf("failed to answer review: %v", err)
+	}
+
+	asked := waitForTask(t, taskStore, "again", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status != task.InProgress && (tk.Review == nil || tk.Review.ParseError == "")
+	})
+	if asked.Status != task.NeedsReview {
+		t.Fatalf("expected the repeated question to return the task to In Review, got %s", task.StatusString(*asked))
+	}
+	if asked.ReviewResponse != nil || asked.Review.Question != "Which format?" || len(asked.Review.Options) != 2 {
+		t.Errorf("expected the repeated question without an answer, got %+v / %+v", asked.Review, asked.ReviewResponse)
+	}
+	if !strings.Contains(asked.WorkInProgress, "Retrying.") {
+		t.Errorf("expected the resumed run's text in the work in progress, got %q", asked.WorkInProgress)
+	}
+}
+
+// TestReviewDeadline tests when a review is answered automatically under the agent's timeout and the config policy
+func TestReviewDeadline(t *testing.T) {
+	created := time.Now()
+	withDefault := func(timeout time.Duration) *task.ReviewRequest {
+		return &task.ReviewRequest{CreatedAt: created, Timeout: timeout, Questions: []task.ReviewQuestion{
+			{ID: "q1", Kind: task.SingleChoice, Text: "Which?", Options: []task.ReviewOption{{ID: "a", Label: "A"}, {ID: "b", Label: "B"}}, Default: "b"},
+			{ID: "q2", Kind: task.FreeText, Text: "Port?", Default: "8080"},
+		}}
+	}
+	policy := &config.Config{Review: config.ReviewPolicy{TimeoutMinutes: 60}}
+
+	if _, ok := orchestrator.ReviewDeadline(nil, withDefault(0)); ok {
+		t.Error("expected a review without a timeout to wait for the user")
+	}
+	if deadline, ok := orchestrator.ReviewDeadline(nil, withDefault(10*time.Minute)); !ok || !deadline.Equal(created.Add(10*time.Minute)) {
+		t.Errorf("expected the agent's timeout to be used, got %v %v", deadline, ok)
+	}
+	if deadline, ok := orchestrator.ReviewDeadline(policy, withDefault(0)); !ok || !deadline.Equal(created.Add(time.Hour)) {
+		t.Errorf("expected the configured timeout to be used, got %v %v", deadline, ok)
+	}
+	if deadline, ok := orchestrator.ReviewDeadline(policy, withDefault(10*time.Minute)); !ok || !deadline.Equal(created.Add(10*time.Minute)) {
+		t.Errorf("expected the agent's timeout to take precedence, got %v %v", deadline, ok)
+	}
+
+	manual := withDefault(10 * time.Minute)
+	manual.Manual = true
+	if _, ok := orchestrator.ReviewDeadline(policy, manual); ok {
+		t.Error("expected a manual review to wait for the user")
+	}
+
+	noDefault := withDefault(0)
+	noDefault.Questions[0].Default = ""
+	if _, ok := orchestrator.ReviewDeadline(policy, noDefault); ok {
+		t.Error("expected a review with a question without default to wait for the user")
+	}
+	policy.Review.FirstOptionAsDefault = true
+	if _, ok := orchestrator.ReviewDeadline(policy, noDefault); !ok {
+		t.Error("expected the first option policy to provide the missing default")
+	}
+}
+
+// TestExpiredReviewIsAnsweredAutomatically tests that the loop answers an expired review with its defaults and resumes the task
+func TestExpiredReviewIsAnsweredAutomatically(t *testing.T) {
+	repo := setupTempRepo(t)
+	installFakeCLI(t, "copilot", "echo \"$*\" > prompt.txt\necho done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot"})
+
+	worktree, err := orchestrator.CreateWorktree("ludwig/expired", "expired")
+	if err != nil {
+		t.Fatalf("failed to create worktree: %v", err)
+	}
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{
+		ID: "expired", Name: "Configure the server", Status: task.NeedsReview,
+		BranchName: "ludwig/expired", WorktreePath: worktree,
+		Review: &task.ReviewRequest{
+			Question:  "Which port?",
+			CreatedAt: time.Now().Add(-time.Hour),
+			Timeout:   30 * time.Minute,
+			Questions: []task.ReviewQuestion{{ID: "q1", Kind: task.FreeText, Text: "Which port?", Default: "8080"}},
+		},
+	})
+	taskStore.AddTask(&task.Task{
+		ID: "waiting", Name: "Waits for the user", Status: task.NeedsReview,
+		Review: &task.ReviewRequest{
+			Question:  "Which port?",
+			CreatedAt: time.Now().Add(-time.Hour),
+			Questions: []task.ReviewQuestion{{ID: "q1", Kind: task.FreeText, Text: "Which port?", Default: "8080"}},
+		},
+	})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "expired", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+
+	if done.Status != task.Completed {
+		t.Fatalf("expected the task to resume and complete, got %s", task.StatusString(*done))
+	}
+	if done.ReviewResponse == nil || !done.ReviewResponse.Automatic || done.ReviewResponse.Answers[0].Text != "8080" {
+		t.Fatalf("expected an automatic answer with the default, got %+v", done.ReviewResponse)
+	}
+	prompt := runGit(t, repo, "show", done.BranchName+":prompt.txt")
+	if !strings.Contains(prompt, "Answer: 8080") || !strings.Contains(prompt, "answered automatically") {
+		t.Errorf("expected the automatic answer to reach the AI, got %q", prompt)
+	}
+
+	if waiting, _ := taskStore.GetTask("waiting"); waiting.ReviewResponse != nil {
+		t.Errorf("expected a review without a timeout to keep waiting, got %+v", waiting.ReviewResponse)
+	}
+}
@@ -0,0 +1,60 @@
+package orchestrator_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestProviderConcurrencyLimit tests that a provider limit holds back tasks and can be raised at runtime
+func TestProviderConcurrencyLimit(t *testing.T) {
+	setupTempRepo(t)
+	installFakeCLI(t, "copilot", "echo working\nsleep 30\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot", MaxParallelTasks: 3, ProviderConcurrency: map[string]int{"copilot": 1}})
+
+	taskStore, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create storage: %v", err)
+	}
+	now := time.Now()
+	taskStore.AddTask(&task.Task{ID: "first", Name: "First task", Status: task.Pending, CreatedAt: now})
+	taskStore.AddTask(&task.Task{ID: "second", Name: "Second task", Status: task.Pending, CreatedAt: now.Add(time.Second)})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+
+	waitForTask(t, taskStore, "first", 10*time.Second, func(tk *task.Task) bool { return tk.Status == task.InProgress })
+	time.Sleep(2500 * time.Millisecond) // Give the loop a full poll to (wrongly) start the second task
+
+	second, _ := taskStore.GetTask("second")
+	if second.Status != task.Pending {
+		t.Fatalf("expected second task to wait for a copilot slot, got %s", task.StatusString(*second))
+	}
+	if usage := orchestrator.Slots().String(); !strings.Contains(usage, "Slots 1/3") || !strings.Contains(usage, "copilot 1/1") {
+		t.Errorf("unexpected slot usage %q", usage)
+	}
+
+	// Raising the limit takes effect without restarting the orchestrator
+	writeTestConfig(t, config.Config{AIProvider: "copilot", MaxParallelTasks: 3, ProviderConcurrency: map[string]int{"copilot": 2}})
+	waitForTask(t, taskStore, "second", 10*time.Second, func(tk *task.Task) bool { return tk.Status == task.InProgress })
+}
+
+// TestSlotUsageString tests the slot summary shown under the kanban board
+func TestSlotUsageString(t *testing.T) {
+	usage := orchestrator.SlotUsage{
+		Running: 2,
+		Limit:   3,
+		Providers: []orchestrator.ProviderSlots{
+			{Provider: "copilot", Running: 1},
+			{Provider: "ollama", Running: 1, Limit: 1},
+		},
+	}
+	if got, want := usage.String(), "Slots 2/3 · copilot 1 · ollama 1/1"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,74 @@
+package orchestrator_test
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/config"
+	"ludwig/internal/orchestrator"
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// TestVerificationFailureIsSentBackToAI tests that a failing check triggers a fix-up prompt and passes afterwards
+func TestVerificationFailureIsSentBackToAI(t *testing.T) {
+	repo := setupTempRepo(t)
+	// The fake agent only fixes the project when it is told a check failed
+	installFakeCLI(t, "copilot", "case \"$*\" in *\"check failed\"*) echo fixed > fixed.txt;; esac\necho done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot", Verify: config.VerifyConfig{Test: "test -f fixed.txt"}})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "verified", Name: "Make the tests pass", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	done := waitForTask(t, taskStore, "verified", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+
+	if done.Status != task.Completed {
+		t.Fatalf("expected task to complete after the fix-up round, got %s", task.StatusString(*done))
+	}
+	if files := runGit(t, repo, "ls-tree", "-r", "--name-only", done.BranchName); !strings.Contains(files, "fixed.txt") {
+		t.Errorf("expected the fix to be committed, got files %q", files)
+	}
+	log, _ := os.ReadFile(".ludwig/" + done.ResponseFile)
+	if !strings.Contains(string(log), "verify test") || !strings.Contains(string(log), "test -f fixed.txt") {
+		t.Errorf("expected verification to be logged, got %q", log)
+	}
+}
+
+// TestVerificationFailsTaskAfterMaxRounds tests that a check that keeps failing marks the task Failed
+func TestVerificationFailsTaskAfterMaxRounds(t *testing.T) {
+	setupTempRepo(t)
+	installFakeCLI(t, "copilot", "echo done\n")
+	writeTestConfig(t, config.Config{AIProvider: "copilot", Verify: config.VerifyConfig{
+		Build:     "true",
+		Test:      "echo 'FAIL: TestSomething' && exit 1",
+		MaxRounds: 1,
+	}})
+
+	taskStore, _ := storage.NewFileTaskStorage()
+	taskStore.AddTask(&task.Task{ID: "broken", Name: "Break the tests", Status: task.Pending})
+
+	orchestrator.Start()
+	defer orchestrator.Stop()
+	failed := waitForTask(t, taskStore, "broken", 15*time.Second, func(tk *task.Task) bool {
+		return tk.Status == task.Completed || tk.Status == task.Failed
+	})
+
+	if failed.Status != task.Failed {
+		t.Fatalf("expected task to fail verification, got %s", task.StatusString(*failed))
+	}
+	if !strings.Contains(failed.WorkInProgress, "FAIL: TestSomething") {
+		t.Errorf("expected failing output in work-in-progress, got %q", failed.WorkInProgress)
+	}
+	if len(failed.Attempts) != 1 || !strings.Contains(failed.Attempts[0].Error, "test check failed") {
+		t.Errorf("expected the attempt to record the failing check, got %+v", failed.Attempts)
+	}
+	if failed.WorktreePath == "" || !orchestrator.WorktreeExists(failed.WorktreePath) {
+		t.Errorf("expected the worktree to be kept for a retry")
+	}
+}
@@ -1,6 +1,7 @@
 package orchestrator_test
 
 import (
+	"context"
 	"bytes"
 	"os"
 	"path/filepath"
@@ -33,7 +34,7 @@ func TestGeminiClientWorktreeIsolation(t *testing.T) {
 	// Verify that SendPromptWithDir accepts the working directory parameter
 	// (We can't actually call it without a real gemini CLI, but we verify the interface)
 	// This will fail because gemini CLI is not available, but it tests that the interface works
-	_, _ = client.SendPromptWithDir("test prompt", &mockWriter, tmpDir)
+	_, _ = client.SendPromptWithDir(context.Background(), "test prompt", &mockWriter, tmpDir)
 	// The method signature exists and accepts the workDir parameter
 }
 
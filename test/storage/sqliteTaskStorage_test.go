@@ -0,0 +1,242 @@
+package storage_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+func newTestSQLiteStorage(t *testing.T) *storage.SQLiteTaskStorage {
+	t.Helper()
+	s, err := storage.NewSQLiteTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create sqlite storage: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+// Test basic add, get, update and delete against the SQLite backend
+func TestSQLiteTaskStorageCRUD(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	s := newTestSQLiteStorage(t)
+	testTask := &task.Task{ID: "sql-1", Name: "SQLite task", Status: task.Pending, CreatedAt: time.Now()}
+	if err := s.AddTask(testTask); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+
+	retrieved, err := s.GetTask("sql-1")
+	if err != nil {
+		t.Fatalf("failed to get task: %v", err)
+	}
+	if retrieved.Name != "SQLite task" || retrieved.Version != 1 {
+		t.Errorf("unexpected stored task: %+v", retrieved)
+	}
+
+	retrieved.Status = task.InProgress
+	if err := s.UpdateTask(retrieved); err != nil {
+		t.Fatalf("failed to update task: %v", err)
+	}
+	updated, _ := s.GetTask("sql-1")
+	if updated.Status != task.InProgress || updated.Version != 2 {
+		t.Errorf("expected In Progress at version 2, got %s at version %d", task.StatusString(*updated), updated.Version)
+	}
+
+	if err := s.DeleteTask("sql-1"); err != nil {
+		t.Fatalf("failed to delete task: %v", err)
+	}
+	if _, err := s.GetTask("sql-1"); !errors.Is(err, storage.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound after delete, got %v", err)
+	}
+	if err := s.DeleteTask("sql-1"); !errors.Is(err, storage.ErrTaskNotFound) {
+		t.Errorf("expected ErrTaskNotFound deleting a missing task, got %v", err)
+	}
+}
+
+// Test that the SQLite backend rejects stale updates like the JSON backend
+func TestSQLiteTaskStorageRejectsStaleWrite(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	s := newTestSQLiteStorage(t)
+	s.AddTask(&task.Task{ID: "contested", Name: "Contested", Status: task.Pending})
+
+	first, _ := s.GetTask("contested")
+	second, _ := s.GetTask("contested")
+
+	first.Status = task.InProgress
+	if err := s.UpdateTask(first); err != nil {
+		t.Fatalf("first update should succeed: %v", err)
+	}
+	second.Status = task.Completed
+	if err := s.UpdateTask(second); !errors.Is(err, storage.ErrStaleTask) {
+		t.Fatalf("expected ErrStaleTask, got %v", err)
+	}
+
+	stored, _ := s.GetTask("contested")
+	if stored.Status != task.InProgress {
+		t.Errorf("stale write should not be applied, got %s", task.StatusString(*stored))
+	}
+}
+
+// Test that tasks can be queried by status in queue order
+func TestSQLiteTaskStorageListTasksByStatus(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	s := newTestSQLiteStorage(t)
+	base := time.Now()
+	s.AddTask(&task.Task{ID: "b", Name: "Second pending", Status: task.Pending, CreatedAt: base.Add(time.Minute)})
+	s.AddTask(&task.Task{ID: "a", Name: "First pending", Status: task.Pending, CreatedAt: base})
+	s.AddTask(&task.Task{ID: "c", Name: "Review", Status: task.NeedsReview, CreatedAt: base.Add(2 * time.Minute)})
+	s.AddTask(&task.Task{ID: "d", Name: "Done", Status: task.Completed, CreatedAt: base.Add(3 * time.Minute)})
+
+	pending, err := s.ListTasksByStatus(task.Pending)
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	// Tasks queue in the order they were added
+	if len(pending) != 2 || pending[0].ID != "b" || pending[1].ID != "a" {
+		t.Errorf("expected pending tasks b, a in queue order, got %v", taskIDs(pending))
+	}
+
+	queued, _ := s.ListTasksByStatus(task.Pending, task.NeedsReview)
+	if len(queued) != 3 {
+		t.Errorf("expected 3 queued tasks, got %v", taskIDs(queued))
+	}
+
+	all, _ := s.ListTasks()
+	if len(all) != 4 {
+		t.Errorf("expected 4 tasks, got %d", len(all))
+	}
+}
+
+// Test that an existing tasks.json is imported once and then set aside
+func TestSQLiteTaskStorageMigratesJSON(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	fileStore, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+	fileStore.AddTask(&task.Task{ID: "legacy-1", Name: "Legacy one", Status: task.Completed})
+	fileStore.AddTask(&task.Task{ID: "legacy-2", Name: "Legacy two", Status: task.Pending, WorkInProgress: "notes"})
+
+	s := newTestSQLiteStorage(t)
+	tasks, _ := s.ListTasks()
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 migrated tasks, got %d", len(tasks))
+	}
+	migrated, err := s.GetTask("legacy-2")
+	if err != nil {
+		t.Fatalf("migrated task missing: %v", err)
+	}
+	if migrated.WorkInProgress != "notes" || migrated.Status != task.Pending {
+		t.Errorf("migrated task lost fields: %+v", migrated)
+	}
+
+	cwd, _ := os.Getwd()
+	jsonPath := filepath.Join(cwd, ".ludwig", "tasks.json")
+	if _, err := os.Stat(jsonPath); !os.IsNotExist(err) {
+		t.Errorf("expected tasks.json to be renamed after migration")
+	}
+	if _, err := os.Stat(jsonPath + ".migrated"); err != nil {
+		t.Errorf("expected tasks.json.migrated to exist: %v", err)
+	}
+
+	// Reopening must not import anything again
+	s.Close()
+	reopened := newTestSQLiteStorage(t)
+	tasks, _ = reopened.ListTasks()
+	if len(tasks) != 2 {
+		t.Errorf("expected 2 tasks after reopening, got %d", len(tasks))
+	}
+}
+
+// Test that both backends list tasks with equal priority, position and creation time in the same order
+func TestBackendsShareQueueOrder(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	// The SQLite store comes first, so it has no tasks.json to import
+	sqliteStore := newTestSQLiteStorage(t)
+	fileStore, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("failed to create file storage: %v", err)
+	}
+
+	// Tasks tied on priority and queue position, most of them also on creation time
+	created := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	fixtures := []task.Task{
+		{ID: "a", QueuePosition: 1, Name: "Longer task name here", CreatedAt: created},
+		{ID: "b", QueuePosition: 1, Name: "Long task name", CreatedAt: created},
+		{ID: "c", QueuePosition: 1, Name: "Short", CreatedAt: created.Add(100 * time.Millisecond)},
+		{ID: "d", QueuePosition: 1, Name: "x", CreatedAt: created},
+		{ID: "e", QueuePosition: 1, Name: "y", CreatedAt: created},
+	}
+	want := "a b d e c"
+	for name, s := range map[string]storage.TaskStorage{"json": fileStore, "sqlite": sqliteStore} {
+		for _, fixture := range fixtures {
+			tk := fixture
+			if err := s.AddTask(&tk); err != nil {
+				t.Fatalf("%s: failed to add task: %v", name, err)
+			}
+		}
+		tasks, err := s.ListTasks()
+		if err != nil {
+			t.Fatalf("%s: failed to list tasks: %v", name, err)
+		}
+		if got := strings.Join(taskIDs(tasks), " "); got != want {
+			t.Errorf("%s: expected queue order %q, got %q", name, want, got)
+		}
+		pending, _ := s.ListTasksByStatus(task.Pending)
+		if got := strings.Join(taskIDs(pending), " "); got != want {
+			t.Errorf("%s: expected pending tasks in queue order %q, got %q", name, want, got)
+		}
+	}
+}
+
+// Test that Open selects the configured backend
+func TestOpenSelectsBackend(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	jsonStore, err := storage.Open("")
+	if err != nil {
+		t.Fatalf("failed to open default backend: %v", err)
+	}
+	if _, ok := jsonStore.(*storage.FileTaskStorage); !ok {
+		t.Errorf("expected FileTaskStorage by default, got %T", jsonStore)
+	}
+
+	sqliteStore, err := storage.Open(storage.BackendSQLite)
+	if err != nil {
+		t.Fatalf("failed to open sqlite backend: %v", err)
+	}
+	if s, ok := sqliteStore.(*storage.SQLiteTaskStorage); !ok {
+		t.Errorf("expected SQLiteTaskStorage, got %T", sqliteStore)
+	} else {
+		s.Close()
+	}
+
+	if _, err := storage.Open("postgres"); err == nil {
+		t.Errorf("expected an error for an unknown backend")
+	}
+}
+
+func taskIDs(tasks []*task.Task) []string {
+	ids := make([]string, len(tasks))
+	for i, t := range tasks {
+		ids[i] = t.ID
+	}
+	return ids
+}
@@ -0,0 +1,203 @@
+package storage_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ludwig/internal/storage"
+	"ludwig/internal/types/task"
+)
+
+// Test that versions advance on every save
+func TestUpdateTaskAdvancesVersion(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	s, _ := storage.NewFileTaskStorage()
+	testTask := &task.Task{ID: "versioned", Name: "Versioned", Status: task.Pending}
+	if err := s.AddTask(testTask); err != nil {
+		t.Fatalf("failed to add task: %v", err)
+	}
+	if testTask.Version != 1 {
+		t.Errorf("expected version 1 after add, got %d", testTask.Version)
+	}
+
+	testTask.Status = task.InProgress
+	if err := s.UpdateTask(testTask); err != nil {
+		t.Fatalf("failed to update task: %v", err)
+	}
+	retrieved, _ := s.GetTask("versioned")
+	if testTask.Version != 2 || retrieved.Version != 2 {
+		t.Errorf("expected version 2 after update, got caller %d, stored %d", testTask.Version, retrieved.Version)
+	}
+}
+
+// Test that an update based on an old read is rejected
+func TestUpdateTaskRejectsStaleWrite(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	s, _ := storage.NewFileTaskStorage()
+	s.AddTask(&task.Task{ID: "contested", Name: "Contested", Status: task.Pending})
+
+	first, _ := s.GetTask("contested")
+	second, _ := s.GetTask("contested")
+
+	first.Status = task.InProgress
+	if err := s.UpdateTask(first); err != nil {
+		t.Fatalf("first update should succeed: %v", err)
+	}
+
+	second.Status = task.Completed
+	err := s.UpdateTask(second)
+	if !errors.Is(err, storage.ErrStaleTask) {
+		t.Fatalf("expected ErrStaleTask, got %v", err)
+	}
+
+	retrieved, _ := s.GetTask("contested")
+	if retrieved.Status != task.InProgress {
+		t.Errorf("stale write should not be saved, got status %v", retrieved.Status)
+	}
+}
+
+// Test that writers using separate storage instances (as separate processes do) never lose updates
+func TestConcurrentWritersDoNotLoseUpdates(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	numWriters := 4
+	tasksPerWriter := 15
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWriters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			s, err := storage.NewFileTaskStorage()
+			if err != nil {
+				t.Errorf("failed to create storage: %v", err)
+				return
+			}
+			for i := 0; i < tasksPerWriter; i++ {
+				id := fmt.Sprintf("writer-%d-task-%d", w, i)
+				if err := s.AddTask(&task.Task{ID: id, Name: id, Status: task.Pending}); err != nil {
+					t.Errorf("failed to add %s: %v", id, err)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	s, _ := storage.NewFileTaskStorage()
+	tasks, err := s.ListTasks()
+	if err != nil {
+		t.Fatalf("failed to list tasks: %v", err)
+	}
+	if len(tasks) != numWriters*tasksPerWriter {
+		t.Errorf("expected %d tasks, got %d", numWriters*tasksPerWriter, len(tasks))
+	}
+}
+
+// Test that saves leave no temp files behind and tasks.json always holds valid JSON
+func TestSaveWritesAtomically(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	s, _ := storage.NewFileTaskStorage()
+	for i := 0; i < 5; i++ {
+		s.AddTask(&task.Task{ID: fmt.Sprintf("task-%d", i), Name: "Task", Status: task.Pending})
+	}
+
+	cwd, _ := os.Getwd()
+	entries, err := os.ReadDir(filepath.Join(cwd, ".ludwig"))
+	if err != nil {
+		t.Fatalf("failed to read .ludwig: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("expected no temp files left behind, found %s", entry.Name())
+		}
+	}
+
+	fresh, err := storage.NewFileTaskStorage()
+	if err != nil {
+		t.Fatalf("expected tasks.json to load cleanly: %v", err)
+	}
+	tasks, _ := fresh.ListTasks()
+	if len(tasks) != 5 {
+		t.Errorf("expected 5 tasks, got %d", len(tasks))
+	}
+}
+
+// Test that merging a stale write keeps the user's review answer
+func TestMergeTaskKeepsReviewResponse(t *testing.T) {
+	review := &task.ReviewRequest{Question: "REST or GraphQL?", CreatedAt: time.Now()}
+	answer := &task.ReviewResponse{ChosenOptionID: "rest", ChosenLabel: "REST"}
+
+	stored := &task.Task{ID: "t", Name: "API", Status: task.NeedsReview, Version: 3, Review: review, ReviewResponse: answer}
+	stale := &task.Task{ID: "t", Name: "API", Status: task.NeedsReview, Version: 2, Review: review, WorkInProgress: "updated notes"}
+
+	merged := storage.MergeTask(stored, stale)
+	if merged.Version != 3 {
+		t.Errorf("expected merged task to carry the stored version, got %d", merged.Version)
+	}
+	if merged.ReviewResponse != answer {
+		t.Errorf("expected user's review response to be kept")
+	}
+	if merged.WorkInProgress != "updated notes" {
+		t.Errorf("expected writer's changes to be kept, got %q", merged.WorkInProgress)
+	}
+}
+
+// Test that an answer to an older review is not carried over to a new one
+func TestMergeTaskDropsAnswerToDifferentReview(t *testing.T) {
+	oldReview := &task.ReviewRequest{Question: "Old question?", CreatedAt: time.Now().Add(-time.Hour)}
+	newReview := &task.ReviewRequest{Question: "New question?", CreatedAt: time.Now()}
+
+	stored := &task.Task{ID: "t", Version: 5, Review: oldReview, ReviewResponse: &task.ReviewResponse{ChosenOptionID: "a"}}
+	stale := &task.Task{ID: "t", Version: 4, Review: newReview}
+
+	if merged := storage.MergeTask(stored, stale); merged.ReviewResponse != nil {
+		t.Errorf("expected answer to a different review to be dropped, got %+v", merged.ReviewResponse)
+	}
+}
+
+// Test that added tasks are queued after every existing task and listed in queue order
+func TestAddTaskAssignsQueuePosition(t *testing.T) {
+	setupTestStorage(t)
+	defer cleanupTestStorage(t)
+
+	s, _ := storage.NewFileTaskStorage()
+	first := &task.Task{ID: "first", Name: "First", Status: task.Pending, CreatedAt: time.Now()}
+	second := &task.Task{ID: "second", Name: "Second", Status: task.Pending, CreatedAt: time.Now().Add(-time.Hour)}
+	s.AddTask(first)
+	s.AddTask(second)
+
+	if first.QueuePosition != 1 || second.QueuePosition != 2 {
+		t.Errorf("expected queue positions 1 and 2, got %d and %d", first.QueuePosition, second.QueuePosition)
+	}
+	tasks, _ := s.ListTasks()
+	if len(tasks) != 2 || tasks[0].ID != "first" {
+		t.Errorf("expected tasks listed in queue order, got %v", taskIDs(tasks))
+	}
+}
+
+// Test that a stale write does not undo a priority change made by the user
+func TestMergeTaskKeepsQueueOrder(t *testing.T) {
+	stored := &task.Task{ID: "t", Name: "Task", Status: task.Pending, Priority: 2, QueuePosition: 5, Version: 3}
+	stale := &task.Task{ID: "t", Name: "Task", Status: task.InProgress, Priority: 0, QueuePosition: 1, Version: 2}
+
+	merged := storage.MergeTask(stored, stale)
+	if merged.Priority != 2 || merged.QueuePosition != 5 {
+		t.Errorf("expected stored queue order to win, got priority %d position %d", merged.Priority, merged.QueuePosition)
+	}
+	if merged.Status != task.InProgress {
+		t.Errorf("expected writer's status to win, got %s", task.StatusString(*merged))
+	}
+}
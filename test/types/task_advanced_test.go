@@ -58,6 +58,7 @@ func TestStatusStringAllStatuses(t *testing.T) {
 		{task.InProgress, "In Progress"},
 		{task.NeedsReview, "In Review"},
 		{task.Completed, "Completed"},
+		{task.Failed, "Failed"},
 	}
 
 	for _, tc := range testCases {
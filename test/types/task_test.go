@@ -1,7 +1,9 @@
 package types_test
 
 import (
+	"slices"
 	"testing"
+	"time"
 
 	"ludwig/internal/types/task"
 )
@@ -32,6 +34,11 @@ func TestStatusString(t *testing.T) {
 			status:   task.Completed,
 			expected: "Completed",
 		},
+		{
+			name:     "Failed status",
+			status:   task.Failed,
+			expected: "Failed",
+		},
 		{
 			name:     "Invalid status",
 			status:   task.Status(999),
@@ -113,6 +120,25 @@ func TestReviewRequest(t *testing.T) {
 	}
 }
 
+func TestReviewRequestSameRequest(t *testing.T) {
+	asked := time.Now()
+	review := &task.ReviewRequest{Question: "Which database?", CreatedAt: asked}
+
+	if !review.SameRequest(&task.ReviewRequest{Question: "Which database?", CreatedAt: asked}) {
+		t.Error("expected a copy of the review to be the same request")
+	}
+	if review.SameRequest(&task.ReviewRequest{Question: "Which port?", CreatedAt: asked}) {
+		t.Error("expected a different question to be a different request")
+	}
+	if review.SameRequest(&task.ReviewRequest{Question: "Which database?", CreatedAt: asked.Add(time.Second)}) {
+		t.Error("expected the question asked again later to be a different request")
+	}
+	var none *task.ReviewRequest
+	if none.SameRequest(review) || review.SameRequest(nil) {
+		t.Error("expected a missing review never to be the same request")
+	}
+}
+
 func TestTaskWithWorktreePath(t *testing.T) {
 	testTask := task.Task{
 		ID:           "test-1",
@@ -129,3 +155,55 @@ func TestTaskWithWorktreePath(t *testing.T) {
 		t.Errorf("expected branch name ludwig/test-task, got %s", testTask.BranchName)
 	}
 }
+
+func TestQueueOrder(t *testing.T) {
+	now := time.Now()
+	tasks := []*task.Task{
+		{ID: "old-low", Priority: -1, QueuePosition: 1, CreatedAt: now},
+		{ID: "late", QueuePosition: 3, CreatedAt: now},
+		{ID: "early", QueuePosition: 2, CreatedAt: now.Add(time.Hour)},
+		{ID: "urgent", Priority: 1, QueuePosition: 4, CreatedAt: now},
+	}
+	slices.SortFunc(tasks, task.QueueOrder)
+
+	var ids []string
+	for _, tk := range tasks {
+		ids = append(ids, tk.ID)
+	}
+	expected := []string{"urgent", "early", "late", "old-low"}
+	if !slices.Equal(ids, expected) {
+		t.Errorf("expected order %v, got %v", expected, ids)
+	}
+}
+
+func TestBlockedBy(t *testing.T) {
+	tk := task.Task{ID: "api", DependsOn: []string{"schema", "auth", "deleted"}}
+	statuses := map[string]task.Status{"schema": task.Completed, "auth": task.InProgress}
+
+	blocking := task.BlockedBy(tk, statuses)
+	if !slices.Equal(blocking, []string{"auth"}) {
+		t.Errorf("expected only auth to block, got %v", blocking)
+	}
+
+	statuses["auth"] = task.Completed
+	if blocking := task.BlockedBy(tk, statuses); len(blocking) != 0 {
+		t.Errorf("expected no blocking dependencies, got %v", blocking)
+	}
+
+	statuses["auth"] = task.Archived
+	if blocking := task.BlockedBy(tk, statuses); len(blocking) != 0 {
+		t.Errorf("expected an archived dependency not to block, got %v", blocking)
+	}
+}
+
+func TestFailedDependencies(t *testing.T) {
+	tk := task.Task{ID: "api", DependsOn: []string{"schema", "auth", "deleted"}}
+	statuses := map[string]task.Status{"schema": task.Failed, "auth": task.InProgress}
+
+	if failed := task.FailedDependencies(tk, statuses); !slices.Equal(failed, []string{"schema"}) {
+		t.Errorf("expected only schema to have failed, got %v", failed)
+	}
+	if blocking := task.BlockedBy(tk, statuses); !slices.Equal(blocking, []string{"schema", "auth"}) {
+		t.Errorf("expected a failed dependency to keep blocking until retried, got %v", blocking)
+	}
+}
@@ -0,0 +1,77 @@
+package utils_test
+
+import (
+	"strings"
+	"testing"
+
+	"ludwig/internal/utils"
+)
+
+// responseLines wraps event lines in the header/footer layout written by storage.ResponseWriter
+func responseLines(events ...string) []string {
+	lines := []string{"# AI Response for Task: t", "", "Generated: now", "", "---", ""}
+	lines = append(lines, events...)
+	return append(lines, "", "---", "", "Completed: now")
+}
+
+func TestOutputLinesMergesTextDeltas(t *testing.T) {
+	output := utils.OutputLines(responseLines(
+		`{"type":"text","timestamp":"2025-01-01T00:00:00Z","text":"Hel"}`,
+		`{"type":"text","timestamp":"2025-01-01T00:00:01Z","text":"lo wor"}`,
+		`{"type":"text","timestamp":"2025-01-01T00:00:02Z","text":"ld"}`,
+		`{"type":"result","timestamp":"2025-01-01T00:00:03Z","text":"Hello world","status":"success"}`,
+	))
+
+	if !strings.Contains(output, "Hello world") {
+		t.Errorf("expected merged text 'Hello world', got %q", output)
+	}
+	if strings.Count(output, "2025-01-01") != 1 {
+		t.Errorf("expected a single timestamp for merged text, got %q", output)
+	}
+}
+
+func TestOutputLinesRendersToolEvents(t *testing.T) {
+	output := utils.OutputLines(responseLines(
+		`{"type":"tool_call","timestamp":"2025-01-01T00:00:00Z","tool_name":"read_file","parameters":{"path":"main.go","limit":10}}`,
+		`{"type":"tool_result","timestamp":"2025-01-01T00:00:01Z","status":"success","output":"package main"}`,
+	))
+
+	if !strings.Contains(output, "Using tool: read_file") {
+		t.Errorf("expected tool call rendering, got %q", output)
+	}
+	if !strings.Contains(output, "limit: 10") {
+		t.Errorf("expected non-string parameters to be rendered, got %q", output)
+	}
+	if !strings.Contains(output, "Tool result: success") {
+		t.Errorf("expected tool result rendering, got %q", output)
+	}
+}
+
+func TestOutputLinesRendersUsageAndErrors(t *testing.T) {
+	output := utils.OutputLines(responseLines(
+		`{"type":"error","timestamp":"2025-01-01T00:00:00Z","text":"rate limited"}`,
+		`{"type":"usage","timestamp":"2025-01-01T00:00:01Z","usage":{"input_tokens":12,"output_tokens":5,"total_tokens":17}}`,
+	))
+
+	if !strings.Contains(output, "rate limited") {
+		t.Errorf("expected error text, got %q", output)
+	}
+	if !strings.Contains(output, "12 in, 5 out") {
+		t.Errorf("expected token usage, got %q", output)
+	}
+}
+
+func TestOutputLinesHidesLegacyPromptEcho(t *testing.T) {
+	output := utils.OutputLines(responseLines(
+		`{"type":"init","timestamp":"2025-01-01T00:00:00Z"}`,
+		`{"type":"message","timestamp":"2025-01-01T00:00:00Z","role":"user","content":"SYSTEM PROMPT"}`,
+		`{"type":"message","timestamp":"2025-01-01T00:00:01Z","role":"assistant","content":"Legacy answer","delta":true}`,
+	))
+
+	if strings.Contains(output, "SYSTEM PROMPT") {
+		t.Errorf("expected user prompt echo to be hidden, got %q", output)
+	}
+	if !strings.Contains(output, "Legacy answer") {
+		t.Errorf("expected legacy assistant message, got %q", output)
+	}
+}